@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Cshiyuan/Gomarkdown2image/internal/config"
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// runConfigCommand 执行 config 子命令,返回退出码
+//
+//	config print [-config 文件] [-format yaml|toml|env]
+//
+// 输出合并默认值、配置文件和环境变量后生效的配置 (密钥已隐藏),配置无效时列出所有错误。
+func runConfigCommand(args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintf(os.Stderr, "usage: %s config print [-config file] [-format yaml|toml|env]\n", os.Args[0])
+		return 2
+	}
+
+	flags := flag.NewFlagSet("config print", flag.ContinueOnError)
+	configPath := flags.String("config", os.Getenv("CONFIG_FILE"), "配置文件路径 (YAML 或 TOML)")
+	format := flags.String("format", "yaml", "输出格式: yaml, toml 或 env")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	cfg, err := config.LoadServer(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	if err := printConfig(os.Stdout, cfg, *format); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// printConfig 按 format 输出隐藏了密钥的配置
+func printConfig(w io.Writer, cfg *config.Server, format string) error {
+	var data []byte
+	var err error
+	switch format {
+	case "yaml":
+		data, err = yaml.Marshal(cfg.Redacted())
+	case "toml":
+		data, err = toml.Marshal(cfg.Redacted())
+	case "env":
+		data = []byte(strings.Join(cfg.Env(), "\n") + "\n")
+	default:
+		return fmt.Errorf("unsupported format %q (expected yaml, toml or env)", format)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
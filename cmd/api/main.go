@@ -1,11 +1,32 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"flag"
 	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
 	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/Cshiyuan/Gomarkdown2image/internal/config"
 	"github.com/Cshiyuan/Gomarkdown2image/internal/handlers"
+	"github.com/Cshiyuan/Gomarkdown2image/internal/metrics"
+	"github.com/Cshiyuan/Gomarkdown2image/internal/signing"
+	"github.com/Cshiyuan/Gomarkdown2image/internal/tracing"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/ai"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/ai/factory"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/cache"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/converter"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/fonts"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/renderer"
 	"github.com/gin-gonic/gin"
 )
 
@@ -14,18 +35,159 @@ const (
 )
 
 func main() {
-	// 设置 Gin 模式 (可通过环境变量 GIN_MODE 控制)
-	// 生产环境: export GIN_MODE=release
-	// 开发环境: export GIN_MODE=debug (默认)
+	// config 子命令 (config print 输出生效的配置)
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfigCommand(os.Args[2:]))
+	}
+
+	// 加载配置: 默认值 → 配置文件 (-config 或 CONFIG_FILE) → 环境变量
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "配置文件路径 (YAML 或 TOML)")
+	flag.Parse()
+	cfg, err := config.LoadServer(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	if *configPath != "" {
+		fmt.Printf("⚙️  配置文件: %s\n", *configPath)
+	}
+
+	// Gin 运行模式 (生产环境使用 release)
+	gin.SetMode(cfg.Mode)
+
+	// 结构化日志
+	slog.SetDefault(newLogger(cfg.Log))
+
+	// 链路追踪 (设置 OTEL_EXPORTER_OTLP_ENDPOINT 时通过 OTLP/HTTP 导出 span)
+	shutdownTracing, err := tracing.Setup(context.Background(), version)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 链路追踪初始化失败: %v\n", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
+	if tracing.Enabled() {
+		fmt.Printf("🔭 链路追踪: 已启用 (OTLP/HTTP)\n")
+	}
+
+	// 浏览器 (未配置路径时由 Rod 自动查找或下载)
+	if cfg.Browser.Path != "" {
+		renderer.SetBrowserPath(cfg.Browser.Path)
+		fmt.Printf("🌐 浏览器: %s\n", cfg.Browser.Path)
+	}
+
+	// 请求大小限制和默认转换选项
+	handlers.SetSizeLimits(cfg.Limits.MaxMarkdownSize, cfg.Limits.MaxUploadSize)
+	handlers.SetDefaultOptions(defaultConvertOptions(cfg.Defaults))
+
+	// 服务端 AI 配置 (请求通过 aiProfile 引用,不必携带密钥)
+	handlers.SetAIPolicy(handlers.AIPolicy{
+		Profiles:        cfg.AI.ResolvedProfiles(),
+		DefaultProfile:  cfg.AI.DefaultProfile,
+		AllowClientKeys: cfg.AI.AllowClientKeys,
+	})
+	if len(cfg.AI.Profiles) > 0 {
+		fmt.Printf("🤖 AI 配置: %v (默认: %s)\n", slices.Sorted(maps.Keys(cfg.AI.Profiles)), cmp.Or(cfg.AI.DefaultProfile, "无"))
+	}
+	if !cfg.AI.AllowClientKeys {
+		fmt.Printf("🔒 客户端 AI 密钥: 已禁止\n")
+	}
+
+	// 加载文档模板
+	if dir := cfg.Content.TemplateDir; dir != "" {
+		names, err := handlers.LoadDocumentTemplates(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ 文档模板加载失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("📄 已加载文档模板: %v\n", names)
+	}
+
+	// 加载字体 (请求通过 fontFamily 按名称引用)
+	if dir := cfg.Content.FontDir; dir != "" {
+		families, err := fonts.Default.LoadDir(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ 字体加载失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("🔤 已加载字体: %v\n", families)
+	}
+
+	// 文档目录 (GET /api/render 通过 doc 参数引用)
+	if dir := cfg.Content.ContentDir; dir != "" {
+		if err := handlers.SetContentDir(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ 文档目录加载失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("📚 文档目录: %s\n", dir)
+	}
+
+	// 渲染 URL 签名 (配置签名密钥时,GET /api/render 只接受签名 URL)
+	if key := cfg.Auth.SigningKey; key != "" {
+		signer, err := signing.NewSigner([]byte(key))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ 签名密钥无效: %v\n", err)
+			os.Exit(1)
+		}
+		handlers.SetRenderSigner(signer)
+		fmt.Printf("🔏 渲染 URL 签名: 已启用\n")
+	}
+
+	// 渲染缓存 (容量为 0 时禁用;配置目录时使用磁盘缓存)
+	renderCache, err := newRenderCache(cfg.Cache)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 渲染缓存初始化失败: %v\n", err)
+		os.Exit(1)
+	}
+	if renderCache != nil {
+		handlers.SetRenderCache(renderCache)
+		stats := renderCache.Stats()
+		fmt.Printf("🗄️  渲染缓存: %s (%d MB)\n", stats.Backend, stats.MaxBytes>>20)
+	}
+
+	// API Key 认证 (API_KEYS_FILE 为策略文件,API_KEYS 为 "名称:密钥,..." 列表;都未设置时不启用)
+	apiKeys, err := newAPIKeyStore(cfg.Auth)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ API Key 加载失败: %v\n", err)
+		os.Exit(1)
+	}
+	if apiKeys != nil {
+		fmt.Printf("🔑 API Key 认证: 已启用 (%d 个)\n", apiKeys.Len())
+	} else {
+		fmt.Printf("⚠️  API Key 认证: 未启用 (设置 API_KEYS_FILE 或 API_KEYS 启用)\n")
+	}
+
+	// 限流 (默认不启用) 和并发转换数限制 (上限为 0 时禁用)
+	rateLimiter := newRateLimiter(cfg.Limits, apiKeys)
+	concurrencyLimiter := newConcurrencyLimiter(cfg.Browser)
+
+	// 就绪检查 (浏览器,以及配置的 AI 提供器)
+	readiness, err := newReadiness(cfg.AI)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 就绪检查配置无效: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Prometheus 指标 (GET /metrics)
+	serverMetrics := metrics.New()
+	converter.SetObserver(serverMetrics)
+	ai.SetObserver(serverMetrics)
+	if renderCache != nil {
+		serverMetrics.RegisterCache(renderCache)
+	}
+	if concurrencyLimiter != nil {
+		serverMetrics.RegisterConcurrency(concurrencyLimiter)
+	}
 
 	// 创建路由器
 	router := gin.New()
 
 	// 应用中间件
-	router.Use(handlers.ErrorRecovery()) // 错误恢复
-	router.Use(handlers.SetupCORS())     // CORS 跨域
-	router.Use(gin.Logger())             // Gin 内置日志
-	router.Use(handlers.RequestLogger()) // 自定义日志
+	router.Use(handlers.ErrorRecovery())                    // 错误恢复
+	router.Use(handlers.RequestID())                        // 请求 ID (X-Request-ID)
+	router.Use(handlers.Tracing())                          // 链路追踪 (traceparent)
+	router.Use(handlers.Metrics(serverMetrics))             // 请求指标
+	router.Use(handlers.SetupCORS(cfg.CORS.AllowedOrigins)) // CORS 跨域
+	router.Use(handlers.RequestLogger())                    // 请求日志
 
 	// 设置最大上传文件大小
 	router.MaxMultipartMemory = config.MaxMultipartMemory
@@ -33,14 +195,47 @@ func main() {
 	// 健康检查端点
 	router.GET("/health", handlers.HealthCheckHandler)
 
+	// 存活和就绪探针
+	router.GET("/livez", handlers.LivezHandler)
+	router.GET("/readyz", handlers.ReadyzHandler(readiness))
+
+	// Prometheus 指标端点
+	router.GET("/metrics", gin.WrapH(serverMetrics.Handler()))
+
 	// API 路由组
 	api := router.Group("/api")
+	if apiKeys != nil {
+		api.Use(handlers.APIKeyAuth(apiKeys))
+	}
+	if rateLimiter != nil {
+		api.Use(handlers.RateLimit(rateLimiter))
+	}
+	// 转换端点 (限制并发转换数)
+	convert := api.Group("")
+	if concurrencyLimiter != nil {
+		convert.Use(handlers.ConcurrencyLimit(concurrencyLimiter))
+	}
 	{
 		// POST /api/convert - JSON 方式转换 Markdown
-		api.POST("/convert", handlers.ConvertHandler)
+		convert.POST("/convert", handlers.ConvertHandler)
 
 		// POST /api/upload - 文件上传方式转换 Markdown
-		api.POST("/upload", handlers.UploadHandler)
+		convert.POST("/upload", handlers.UploadHandler)
+
+		// GET /api/render - 查询参数方式渲染 (支持 ETag/304,可用于 <img src>)
+		convert.GET("/render", handlers.RenderHandler)
+
+		// POST /api/render/sign - 生成签名渲染 URL
+		api.POST("/render/sign", handlers.SignRenderHandler)
+
+		// GET /api/fonts - 已注册的字体
+		api.GET("/fonts", handlers.FontsHandler)
+
+		// GET /api/cache - 渲染缓存统计
+		api.GET("/cache", handlers.CacheStatsHandler)
+
+		// GET /api/usage - 当前 API Key 的用量
+		api.GET("/usage", handlers.UsageHandler(apiKeys))
 	}
 
 	// 根路径欢迎信息
@@ -50,31 +245,206 @@ func main() {
 			"version": version,
 			"endpoints": gin.H{
 				"health":  "GET /health",
+				"livez":   "GET /livez",
+				"readyz":  "GET /readyz",
+				"metrics": "GET /metrics",
 				"convert": "POST /api/convert",
 				"upload":  "POST /api/upload",
+				"render":  "GET /api/render",
+				"sign":    "POST /api/render/sign",
+				"fonts":   "GET /api/fonts",
+				"cache":   "GET /api/cache",
+				"usage":   "GET /api/usage",
 			},
 			"docs": "https://github.com/Cshiyuan/Gomarkdown2image",
 		})
 	})
 
-	// 获取端口 (默认 8080,可通过环境变量 PORT 指定)
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+	port := strconv.Itoa(cfg.Port)
 
 	// 启动服务
 	fmt.Printf("\n🚀 Gomarkdown2image API 服务启动中...\n")
 	fmt.Printf("📡 监听端口: %s\n", port)
 	fmt.Printf("🌍 访问地址: http://localhost:%s\n", port)
 	fmt.Printf("💚 健康检查: http://localhost:%s/health\n", port)
+	fmt.Printf("🩺 探针: http://localhost:%s/livez, http://localhost:%s/readyz\n", port, port)
+	fmt.Printf("📊 指标: http://localhost:%s/metrics\n", port)
 	fmt.Printf("\n可用端点:\n")
 	fmt.Printf("  POST http://localhost:%s/api/convert - JSON 转换\n", port)
 	fmt.Printf("  POST http://localhost:%s/api/upload  - 文件上传\n", port)
+	fmt.Printf("  GET  http://localhost:%s/api/render  - 查询参数渲染 (ETag)\n", port)
+	fmt.Printf("  POST http://localhost:%s/api/render/sign - 生成签名渲染 URL\n", port)
+	fmt.Printf("  GET  http://localhost:%s/api/fonts   - 已注册字体\n", port)
+	fmt.Printf("  GET  http://localhost:%s/api/cache   - 渲染缓存统计\n", port)
+	fmt.Printf("  GET  http://localhost:%s/api/usage   - API Key 用量\n", port)
 	fmt.Printf("\n按 Ctrl+C 停止服务\n\n")
 
-	if err := router.Run(":" + port); err != nil {
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// 收到 SIGINT/SIGTERM 时优雅关闭
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
 		fmt.Fprintf(os.Stderr, "❌ 服务启动失败: %v\n", err)
 		os.Exit(1)
+	case <-ctx.Done():
+		stop() // 再次收到信号时直接退出
+	}
+
+	shutdown(server, readiness, cfg.Limits.ShutdownTimeout.Std())
+}
+
+// shutdown 优雅关闭服务
+//
+// 就绪检查立即返回 503,停止接受新连接并等待进行中的请求完成;
+// 超过 timeout 后关闭剩余转换的浏览器 (未完成的转换以错误结束) 并断开连接。
+func shutdown(server *http.Server, readiness *handlers.Readiness, timeout time.Duration) {
+	fmt.Printf("\n🛑 正在关闭服务,等待进行中的请求完成 (最长 %s)...\n", timeout)
+	readiness.SetDraining()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		n := handlers.CloseActiveConverters()
+		slog.Warn("shutdown timed out, cancelled in-flight conversions", "conversions", n, "error", err)
+		_ = server.Close()
+	}
+	fmt.Printf("👋 服务已关闭\n")
+}
+
+// newRenderCache 根据配置创建渲染缓存,禁用时返回 nil
+func newRenderCache(cfg config.CacheConfig) (cache.Cache, error) {
+	if cfg.SizeMB == 0 {
+		return nil, nil
+	}
+
+	maxBytes := int64(cfg.SizeMB) << 20
+	if cfg.Dir != "" {
+		return cache.NewDisk(cfg.Dir, maxBytes)
+	}
+	return cache.NewMemory(maxBytes), nil
+}
+
+// newAPIKeyStore 根据配置加载 API Key (策略文件和 "名称:密钥,..." 列表),未配置时返回 nil
+func newAPIKeyStore(cfg config.AuthConfig) (*handlers.APIKeyStore, error) {
+	var policies []handlers.APIKeyPolicy
+	if cfg.APIKeysFile != "" {
+		loaded, err := handlers.LoadAPIKeyFile(cfg.APIKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, loaded...)
+	}
+	if cfg.APIKeys != "" {
+		parsed, err := handlers.ParseAPIKeys(cfg.APIKeys)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, parsed...)
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+	return handlers.NewAPIKeyStore(policies)
+}
+
+// newRateLimiter 根据配置创建限流器,禁用时返回 nil
+//
+// 全局速率为 0 时只限制单独设置了 rateLimit 的 API Key。
+func newRateLimiter(cfg config.LimitsConfig, apiKeys *handlers.APIKeyStore) *handlers.RateLimiter {
+	switch {
+	case cfg.RateLimit > 0:
+		fmt.Printf("🚦 限流: 每个客户端 %g 次/秒 (突发 %d)\n", cfg.RateLimit, cfg.RateBurst)
+	case apiKeys != nil && apiKeys.HasRateLimits():
+		fmt.Printf("🚦 限流: 仅限制设置了 rateLimit 的 API Key\n")
+	default:
+		fmt.Printf("⚠️  限流: 未启用 (设置 RATE_LIMIT 启用)\n")
+		return nil
+	}
+	return handlers.NewRateLimiter(cfg.RateLimit, cfg.RateBurst)
+}
+
+// newConcurrencyLimiter 根据配置创建并发转换数限制,禁用时返回 nil
+func newConcurrencyLimiter(cfg config.BrowserConfig) *handlers.ConcurrencyLimiter {
+	if cfg.MaxConcurrent == 0 {
+		fmt.Printf("⚠️  并发限制: 未启用\n")
+		return nil
+	}
+	fmt.Printf("🧵 并发限制: 最多 %d 个转换,排队 %d 个 (超时 %s)\n", cfg.MaxConcurrent, cfg.QueueSize, cfg.QueueTimeout)
+	return handlers.NewConcurrencyLimiter(cfg.MaxConcurrent, cfg.QueueSize, cfg.QueueTimeout.Std())
+}
+
+// newReadiness 创建就绪检查: 总是检查浏览器能否启动,
+// cfg.ReadyProviders ("提供器:模型" 如 "gemini:gemini-2.0-flash"、"ollama:llama3.2") 中的 AI 提供器同时检查
+func newReadiness(cfg config.AIConfig) (*handlers.Readiness, error) {
+	checks := []handlers.ReadinessCheck{{
+		Name: "browser",
+		Check: func(ctx context.Context) error {
+			_, err := renderer.CheckBrowser(ctx)
+			return err
+		},
+	}}
+
+	for _, item := range cfg.ReadyProviders {
+		name, model, _ := strings.Cut(item, ":")
+		aiConfig := &ai.Config{Provider: ai.ProviderType(name), Model: model}
+		if aiConfig.Provider == ai.ProviderGemini {
+			aiConfig.APIKey = cfg.GeminiAPIKey
+		}
+		provider, err := factory.NewProvider(aiConfig)
+		if err != nil {
+			return nil, fmt.Errorf("ai provider %s: %w", name, err)
+		}
+		checker, ok := provider.(ai.Checker)
+		if !ok {
+			return nil, fmt.Errorf("ai provider %s does not support readiness checks", name)
+		}
+		checks = append(checks, handlers.ReadinessCheck{Name: "ai:" + name, Check: checker.Check})
 	}
+
+	names := make([]string, len(checks))
+	for i, check := range checks {
+		names[i] = check.Name
+	}
+	fmt.Printf("🩺 就绪检查: %v\n", names)
+	return handlers.NewReadiness(config.ReadyCheckInterval, config.ReadyCheckTimeout, checks...), nil
+}
+
+// newLogger 根据配置创建日志记录器 (输出到标准输出,配置已校验)
+func newLogger(cfg config.LogConfig) *slog.Logger {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(cfg.Level))
+	opts := &slog.HandlerOptions{Level: level}
+
+	if cfg.Format == "text" {
+		return slog.New(slog.NewTextHandler(os.Stdout, opts))
+	}
+	return slog.New(slog.NewJSONHandler(os.Stdout, opts))
+}
+
+// defaultConvertOptions 根据配置创建请求未指定时使用的转换选项
+func defaultConvertOptions(cfg config.DefaultsConfig) *converter.ConvertOptions {
+	opts := converter.DefaultConvertOptions()
+	opts.Title = cfg.Title
+	opts.Theme = cfg.Theme
+	opts.Width = cfg.Width
+	opts.FontSize = cfg.FontSize
+	opts.FontFamily = cfg.FontFamily
+	opts.ImageFormat = renderer.ImageFormat(cfg.Format)
+	opts.ImageQuality = cfg.Quality
+	opts.DevicePixelRatio = cfg.DevicePixelRatio
+	opts.AIProvider = cfg.AIProvider
+	opts.AIModel = cfg.AIModel
+	return opts
 }
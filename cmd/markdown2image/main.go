@@ -1,13 +1,25 @@
 package main
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"html/template"
+	"log/slog"
+	"mime"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/Cshiyuan/Gomarkdown2image/internal/config"
 	"github.com/Cshiyuan/Gomarkdown2image/internal/utils"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/cache"
 	"github.com/Cshiyuan/Gomarkdown2image/pkg/converter"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/fonts"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/parser"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/renderer"
 )
 
 const (
@@ -15,19 +27,63 @@ const (
 )
 
 func main() {
+	// 转换过程日志 (阶段耗时等) 只输出警告和错误
+	slog.SetLogLoggerLevel(slog.LevelWarn)
+
+	// 子命令: sign 生成签名渲染 URL
+	if len(os.Args) > 1 && os.Args[1] == "sign" {
+		if err := runSign(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// 定义命令行参数
 	var (
-		input       = flag.String("input", "", "输入的 Markdown 文件路径 (必需)")
-		output      = flag.String("output", "", "输出的图片文件路径 (必需)")
-		title       = flag.String("title", "Markdown to Image", "页面标题")
-		theme       = flag.String("theme", "light", "主题 (light, dark)")
-		width       = flag.Int("width", 1200, "页面宽度(像素)")
-		fontSize    = flag.Int("font-size", 16, "字体大小(px)")
-		fontFamily  = flag.String("font-family", "Arial, sans-serif", "字体族")
-		format      = flag.String("format", "png", "图片格式 (png, jpeg, webp)")
-		quality     = flag.Int("quality", 90, "图片质量 1-100 (仅 JPEG/WebP)")
-		dpr         = flag.Float64("dpr", 1.0, "设备像素比")
-		showVersion = flag.Bool("version", false, "显示版本信息")
+		input             = flag.String("input", "", "输入的 Markdown 文件路径 (必需)")
+		output            = flag.String("output", "", "输出的图片文件路径 (必需)")
+		title             = flag.String("title", "Markdown to Image", "页面标题")
+		theme             = flag.String("theme", "light", "主题 (light, dark)")
+		templateFile      = flag.String("template", "", "自定义 html/template 文档模板文件路径")
+		toc               = flag.Bool("toc", false, "在文档开头插入目录 (内容中的 [TOC] 标记总是替换为目录)")
+		tocDepth          = flag.Int("toc-depth", 3, "目录包含的最大标题级别 (1-6)")
+		outlinePath       = flag.String("outline", "", "将文档标题大纲以 JSON 写入指定文件 (可选)")
+		extensions        = flag.String("extensions", "", "启用的 Markdown 扩展,逗号分隔 (footnote, deflist, attributes, cjk, emoji, heading-ids, wikilink; -名称 表示禁用)")
+		header            = flag.String("header", "", "页眉 (文本或 HTML,支持 {{title}} {{date}} {{author}} {{url}} {{page}} {{pages}})")
+		footer            = flag.String("footer", "", "页脚 (文本或 HTML,占位符同 -header)")
+		brandLogo         = flag.String("logo", "", "页眉 Logo (URL 或本地图片路径)")
+		watermarkText     = flag.String("watermark", "", "文字水印")
+		watermarkImage    = flag.String("watermark-image", "", "图片水印 (URL 或本地图片路径,优先于文字)")
+		watermarkPosition = flag.String("watermark-position", "center", "水印位置 (center, top-left, top-right, bottom-left, bottom-right)")
+		watermarkOpacity  = flag.Float64("watermark-opacity", 0.15, "水印不透明度 (0-1)")
+		watermarkRotation = flag.Int("watermark-rotation", -30, "水印旋转角度 (度)")
+		watermarkTile     = flag.Bool("watermark-tile", false, "平铺水印")
+		width             = flag.Int("width", 1200, "页面宽度(像素)")
+		fontSize          = flag.Int("font-size", 16, "字体大小(px)")
+		fontFamily        = flag.String("font-family", "Arial, sans-serif", "字体族 (可引用 -font-dir 中的字体名称)")
+		lang              = flag.String("lang", "", "文档语言 (BCP 47,如 en、ar;默认使用 front matter 的 lang 或 zh-CN)")
+		dir               = flag.String("dir", "auto", "文字方向 (auto, ltr, rtl; auto 根据语言和内容检测)")
+		fontDir           = flag.String("font-dir", "", "字体目录,加载其中的 .ttf/.otf/.woff/.woff2 字体 (中日韩字体自动用作回退)")
+		format            = flag.String("format", "png", "输出格式 (png, jpeg, webp, pdf; pdf 仅用于 slides 布局)")
+		quality           = flag.Int("quality", 90, "图片质量 1-100 (仅 JPEG/WebP)")
+		dpr               = flag.Float64("dpr", 1.0, "设备像素比")
+		selector          = flag.String("selector", "", "仅截取匹配 CSS 选择器的元素 (如 .container, table)")
+		section           = flag.String("section", "", "仅截取指定标题锚点 ID 对应的章节")
+		transparent       = flag.Bool("transparent", false, "透明背景 (仅 png, webp)")
+		layout            = flag.String("layout", "document", "布局模式 (document, card, snippet, slides)")
+		cardPreset        = flag.String("card-preset", "og", "社交卡片尺寸预设 (og, twitter, square)")
+		cardLogo          = flag.String("card-logo", "", "社交卡片 Logo (URL 或本地图片路径)")
+		snippetLang       = flag.String("snippet-lang", "", "代码片段语言 (默认根据文件扩展名或内容识别)")
+		snippetStyle      = flag.String("snippet-style", "monokai", "代码片段 Chroma 样式")
+		snippetTitle      = flag.String("snippet-title", "", "代码片段窗口标题")
+		snippetWindow     = flag.Bool("snippet-window", true, "显示代码片段窗口标题栏")
+		snippetHighlight  = flag.String("snippet-highlight", "", "代码片段高亮行 (如 1,3-5)")
+		snippetBackground = flag.String("snippet-background", "", "代码片段背景颜色或渐变 CSS")
+		slideAspect       = flag.String("slide-aspect", "16:9", "幻灯片宽高比 (16:9, 4:3)")
+		cacheDir          = flag.String("cache-dir", "", "渲染缓存目录 (相同内容和选项直接复用上次的结果)")
+		cacheSize         = flag.Int("cache-size", config.DefaultCacheSizeMB, "渲染缓存容量 (MB)")
+		showVersion       = flag.Bool("version", false, "显示版本信息")
 	)
 
 	flag.Parse()
@@ -70,37 +126,190 @@ func main() {
 		os.Exit(1)
 	}
 
+	// 透明背景需要支持 alpha 通道的格式
+	if err := utils.ValidateTransparentFormat(imageFormat, *transparent); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 元素截图和章节截图只能二选一
+	if *selector != "" && *section != "" {
+		fmt.Fprintln(os.Stderr, "错误: -selector 和 -section 不能同时使用")
+		os.Exit(1)
+	}
+
+	// PDF 仅用于多页幻灯片
+	if imageFormat == renderer.FormatPDF && *layout != converter.LayoutSlides {
+		fmt.Fprintln(os.Stderr, "错误: pdf 格式仅支持 slides 布局")
+		os.Exit(1)
+	}
+
+	// 验证语言和文字方向
+	if *lang != "" {
+		if err := parser.ValidateLang(*lang); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if err := parser.ValidateDir(*dir); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 验证 Markdown 扩展
+	var markdownExtensions []string
+	if *extensions != "" {
+		markdownExtensions = []string{*extensions}
+		if _, err := parser.ResolveExtensions(markdownExtensions); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// 加载字体目录
+	if *fontDir != "" {
+		families, err := fonts.Default.LoadDir(*fontDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("已加载字体: %v\n", families)
+	}
+
+	// 加载自定义文档模板
+	var documentTemplate *template.Template
+	if *templateFile != "" {
+		documentTemplate, err = parser.LoadDocumentTemplate(*templateFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// 本地 Logo 图片内嵌为 data URI
+	logo, err := resolveLogo(*cardLogo)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: 无法读取 Logo: %v\n", err)
+		os.Exit(1)
+	}
+	headerLogo, err := resolveLogo(*brandLogo)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: 无法读取 Logo: %v\n", err)
+		os.Exit(1)
+	}
+	watermarkLogo, err := resolveLogo(*watermarkImage)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: 无法读取水印图片: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 代码片段语言缺省时根据输入文件扩展名识别 (Markdown 文件由围栏代码块决定)
+	if *layout == converter.LayoutSnippet && *snippetLang == "" {
+		switch ext := strings.TrimPrefix(filepath.Ext(*input), "."); ext {
+		case "md", "markdown":
+		default:
+			*snippetLang = ext
+		}
+	}
+
 	// 创建输出目录(如果不存在)
 	if err := os.MkdirAll(filepath.Dir(*output), 0755); err != nil {
 		fmt.Fprintf(os.Stderr, "错误: 无法创建输出目录: %v\n", err)
 		os.Exit(1)
 	}
 
-	// 创建转换器
+	// 配置转换选项
+	opts := &converter.ConvertOptions{
+		Title:             *title,
+		Theme:             *theme,
+		DocumentTemplate:  documentTemplate,
+		TOC:               *toc,
+		TOCDepth:          *tocDepth,
+		Extensions:        markdownExtensions,
+		Header:            *header,
+		Footer:            *footer,
+		BrandLogo:         headerLogo,
+		WatermarkText:     *watermarkText,
+		WatermarkImage:    watermarkLogo,
+		WatermarkPosition: *watermarkPosition,
+		WatermarkOpacity:  *watermarkOpacity,
+		WatermarkRotation: *watermarkRotation,
+		WatermarkTile:     *watermarkTile,
+		Width:             *width,
+		FontSize:          *fontSize,
+		FontFamily:        *fontFamily,
+		Transparent:       *transparent,
+		Lang:              *lang,
+		Dir:               *dir,
+		Layout:            *layout,
+		CardPreset:        *cardPreset,
+		CardLogo:          logo,
+		SnippetLanguage:   *snippetLang,
+		SnippetStyle:      *snippetStyle,
+		SnippetTitle:      *snippetTitle,
+		SnippetWindow:     *snippetWindow,
+		SnippetHighlight:  *snippetHighlight,
+		SnippetBackground: *snippetBackground,
+		SlideAspect:       *slideAspect,
+		ImageFormat:       imageFormat,
+		ImageQuality:      *quality,
+		FullPage:          true,
+		DevicePixelRatio:  *dpr,
+		Selector:          *selector,
+		Section:           *section,
+	}
+
+	// 验证水印配置
+	if wm := opts.Watermark(); wm != nil {
+		if err := wm.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// 创建转换器 (启用缓存时,命中缓存不会启动浏览器)
 	fmt.Println("正在初始化转换器...")
-	conv, err := converter.NewConverter()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "错误: 无法创建转换器: %v\n", err)
-		os.Exit(1)
+	var conv converter.Converter
+	var renderCache *cache.Disk
+	if *cacheDir != "" {
+		renderCache, err = cache.NewDisk(*cacheDir, int64(*cacheSize)<<20)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+		conv = converter.NewCachingConverter(renderCache, converter.NewConverter)
+	} else {
+		conv, err = converter.NewConverter()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: 无法创建转换器: %v\n", err)
+			os.Exit(1)
+		}
 	}
 	defer conv.Close()
 
-	// 配置转换选项
-	opts := &converter.ConvertOptions{
-		Title:            *title,
-		Theme:            *theme,
-		Width:            *width,
-		FontSize:         *fontSize,
-		FontFamily:       *fontFamily,
-		ImageFormat:      imageFormat,
-		ImageQuality:     *quality,
-		FullPage:         true,
-		DevicePixelRatio: *dpr,
+	// 幻灯片输出图片格式时,每页写入单独的文件
+	if *layout == converter.LayoutSlides && imageFormat != renderer.FormatPDF {
+		fmt.Printf("正在转换 %s...\n", *input)
+		files, err := convertSlidePages(conv, *input, *output, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: 转换失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✅ 转换成功!\n")
+		fmt.Printf("   输入: %s\n", *input)
+		fmt.Printf("   输出: %d 页\n", len(files))
+		for _, f := range files {
+			fmt.Printf("     - %s\n", f)
+		}
+		fmt.Printf("   格式: %s\n", imageFormat)
+		printCacheStatus(renderCache)
+		return
 	}
 
 	// 执行转换
 	fmt.Printf("正在转换 %s...\n", *input)
-	if err := conv.ConvertFile(*input, *output, opts); err != nil {
+	if err := convertFile(conv, *input, *output, *outlinePath, opts); err != nil {
 		fmt.Fprintf(os.Stderr, "错误: 转换失败: %v\n", err)
 		os.Exit(1)
 	}
@@ -119,4 +328,91 @@ func main() {
 	}
 	fmt.Printf("   格式: %s\n", imageFormat)
 	fmt.Printf("   尺寸: %dpx (宽度)\n", *width)
+	printCacheStatus(renderCache)
+}
+
+// printCacheStatus 输出本次转换是否命中渲染缓存 (未启用缓存时不输出)
+func printCacheStatus(c *cache.Disk) {
+	if c == nil {
+		return
+	}
+	if c.Stats().Hits > 0 {
+		fmt.Printf("   缓存: 命中\n")
+	} else {
+		fmt.Printf("   缓存: 未命中\n")
+	}
+}
+
+// convertFile 转换单个文件,outlinePath 非空时同时写出 JSON 格式的标题大纲
+func convertFile(conv converter.Converter, input, output, outlinePath string, opts *converter.ConvertOptions) error {
+	if outlinePath == "" {
+		return conv.ConvertFile(input, output, opts)
+	}
+
+	markdown, err := os.ReadFile(input)
+	if err != nil {
+		return fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	result, err := conv.ConvertWithResult(markdown, opts)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(output, result.Data, 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	outline, err := json.MarshalIndent(result.Outline, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode outline: %w", err)
+	}
+	if err := os.WriteFile(outlinePath, outline, 0644); err != nil {
+		return fmt.Errorf("failed to write outline file: %w", err)
+	}
+	return nil
+}
+
+// convertSlidePages 将幻灯片逐页转换为图片
+//
+// 输出文件名由输出路径去掉扩展名后追加页码生成,例如 deck.png -> deck-01.png。
+func convertSlidePages(conv converter.Converter, input, output string, opts *converter.ConvertOptions) ([]string, error) {
+	markdown, err := os.ReadFile(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	pages, err := conv.ConvertSlides(markdown, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := strings.TrimSuffix(output, filepath.Ext(output))
+	files := make([]string, 0, len(pages))
+	for i, page := range pages {
+		name := utils.PageFileName(prefix, i, opts.ImageFormat)
+		if err := os.WriteFile(name, page, 0644); err != nil {
+			return files, fmt.Errorf("failed to write output file: %w", err)
+		}
+		files = append(files, name)
+	}
+	return files, nil
+}
+
+// resolveLogo 将本地 Logo 图片转换为 data URI,URL 原样返回
+func resolveLogo(logo string) (string, error) {
+	if logo == "" || strings.Contains(logo, "://") || strings.HasPrefix(logo, "data:") {
+		return logo, nil
+	}
+
+	data, err := os.ReadFile(logo)
+	if err != nil {
+		return "", err
+	}
+
+	mimeType := http.DetectContentType(data)
+	if !strings.HasPrefix(mimeType, "image/") {
+		// SVG 等文本格式无法通过内容嗅探识别
+		mimeType = mime.TypeByExtension(filepath.Ext(logo))
+	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
 }
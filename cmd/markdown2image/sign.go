@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Cshiyuan/Gomarkdown2image/internal/config"
+	"github.com/Cshiyuan/Gomarkdown2image/internal/signing"
+)
+
+// renderPath API 服务的 GET 渲染端点
+const renderPath = "/api/render"
+
+// paramFlags 可重复的 key=value 参数
+type paramFlags []string
+
+func (p *paramFlags) String() string { return strings.Join(*p, ",") }
+
+func (p *paramFlags) Set(v string) error {
+	if !strings.Contains(v, "=") {
+		return fmt.Errorf("参数格式应为 key=value: %s", v)
+	}
+	*p = append(*p, v)
+	return nil
+}
+
+// runSign 实现 sign 子命令: 生成 GET /api/render 的签名 URL
+//
+// 示例:
+//
+//	markdown2image sign -input README.md -param theme=dark -expires 720h
+func runSign(args []string) error {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	var (
+		baseURL   = fs.String("base-url", "http://localhost:8080", "API 服务地址")
+		input     = fs.String("input", "", "Markdown 文件 (以 markdownBase64 内联到 URL)")
+		doc       = fs.String("doc", "", "API 服务 CONTENT_DIR 中的文档路径 (与 -input 二选一)")
+		expiresIn = fs.Duration("expires", config.DefaultSignedURLTTL, "有效期 (如 1h, 720h)")
+		params    paramFlags
+	)
+	fs.Var(&params, "param", "转换选项 key=value,可重复 (如 -param theme=dark -param width=800)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "用法: markdown2image sign [选项]\n\n签名密钥从环境变量 RENDER_SIGNING_KEY 读取。\n\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	signer, err := signing.NewSigner([]byte(os.Getenv("RENDER_SIGNING_KEY")))
+	if err != nil {
+		return fmt.Errorf("RENDER_SIGNING_KEY 无效: %w", err)
+	}
+	if (*input == "") == (*doc == "") {
+		return fmt.Errorf("必须指定 -input 或 -doc 之一")
+	}
+	if *expiresIn <= 0 {
+		return fmt.Errorf("有效期必须大于 0")
+	}
+
+	query := url.Values{}
+	if *input != "" {
+		markdown, err := os.ReadFile(*input)
+		if err != nil {
+			return fmt.Errorf("failed to read input file: %w", err)
+		}
+		query.Set("markdownBase64", base64.RawURLEncoding.EncodeToString(markdown))
+	} else {
+		query.Set("doc", *doc)
+	}
+	for _, p := range params {
+		key, value, _ := strings.Cut(p, "=")
+		query.Add(key, value)
+	}
+
+	signed := signer.Sign(renderPath, query, time.Now().Add(*expiresIn))
+	fmt.Println(strings.TrimRight(*baseURL, "/") + renderPath + "?" + signed.Encode())
+	return nil
+}
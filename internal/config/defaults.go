@@ -1,6 +1,10 @@
 package config
 
-import "github.com/Cshiyuan/Gomarkdown2image/pkg/renderer"
+import (
+	"time"
+
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/renderer"
+)
 
 // 默认配置值常量
 const (
@@ -24,6 +28,42 @@ const (
 
 	// DefaultDevicePixelRatio 默认设备像素比
 	DefaultDevicePixelRatio = 1.0
+
+	// DefaultCacheSizeMB 渲染缓存默认容量 (MB)
+	DefaultCacheSizeMB = 256
+
+	// RenderMaxAge GET /api/render 内联 Markdown 结果的 HTTP 缓存时间 (秒)
+	RenderMaxAge = 7 * 24 * 60 * 60
+
+	// DefaultSignedURLTTL 签名渲染 URL 的默认有效期
+	DefaultSignedURLTTL = 24 * time.Hour
+
+	// DefaultRateLimit 每个客户端每秒允许的请求数 (默认 0,不限流)
+	DefaultRateLimit = 0.0
+
+	// DefaultRateBurst 每个客户端允许的突发请求数
+	DefaultRateBurst = 10
+
+	// DefaultMaxConcurrent 同时进行的转换数上限 (每个转换启动一个浏览器)
+	DefaultMaxConcurrent = 4
+
+	// DefaultQueueSize 等待转换的请求数上限
+	DefaultQueueSize = 16
+
+	// DefaultQueueTimeout 请求排队等待的最长时间
+	DefaultQueueTimeout = 30 * time.Second
+
+	// BusyRetryAfter 服务繁忙 (503) 时建议的重试间隔
+	BusyRetryAfter = 5 * time.Second
+
+	// DefaultShutdownTimeout 关闭服务时等待进行中的请求完成的最长时间 (超时后取消剩余的转换)
+	DefaultShutdownTimeout = 30 * time.Second
+
+	// ReadyCheckInterval 就绪检查结果的缓存时间 (检查浏览器需要启动一个实例)
+	ReadyCheckInterval = 30 * time.Second
+
+	// ReadyCheckTimeout 单次就绪检查的最长时间
+	ReadyCheckTimeout = 15 * time.Second
 )
 
 // DefaultImageFormat 返回默认图片格式
@@ -0,0 +1,302 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Redacted 隐藏的密钥在输出中的显示值
+const Redacted = "[REDACTED]"
+
+// LoadServer 加载服务配置: 默认值 → 配置文件 (path 为空时跳过) → 环境变量 → 校验
+func LoadServer(path string) (*Server, error) {
+	cfg := DefaultServer()
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.ApplyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile 按扩展名读取 YAML (.yaml/.yml) 或 TOML (.toml) 配置文件,未知字段视为错误
+func (s *Server) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalWithOptions(data, s, yaml.Strict())
+	case ".toml":
+		err = toml.NewDecoder(bytes.NewReader(data)).DisallowUnknownFields().Decode(s)
+	default:
+		return fmt.Errorf("unsupported config file format %q (expected .yaml, .yml or .toml)", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// EnvVar 可覆盖配置的环境变量
+type EnvVar struct {
+	Name   string // 环境变量名
+	Key    string // 配置项路径 (如 limits.rateLimit)
+	Secret bool   // 是否为密钥
+	field  reflect.Value
+}
+
+// envVars 遍历配置中带 env 标签的字段
+func (s *Server) envVars() []EnvVar {
+	var vars []EnvVar
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := range t.NumField() {
+			f := t.Field(i)
+			key := prefix + strings.Split(f.Tag.Get("yaml"), ",")[0]
+			if name := f.Tag.Get("env"); name != "" {
+				vars = append(vars, EnvVar{Name: name, Key: key, Secret: f.Tag.Get("secret") == "true", field: v.Field(i)})
+				continue
+			}
+			if f.Type.Kind() == reflect.Struct {
+				walk(v.Field(i), key+".")
+			}
+		}
+	}
+	walk(reflect.ValueOf(s).Elem(), "")
+	return vars
+}
+
+// EnvVars 返回所有可覆盖配置的环境变量 (按配置项顺序)
+func EnvVars() []EnvVar {
+	return DefaultServer().envVars()
+}
+
+// ApplyEnv 使用环境变量覆盖配置 (lookup 通常为 os.LookupEnv)
+//
+// 列表以逗号分隔,时长可为 "30s" 或秒数;空值视为未设置。
+func (s *Server) ApplyEnv(lookup func(string) (string, bool)) error {
+	var errs []error
+	for _, env := range s.envVars() {
+		value, ok := lookup(env.Name)
+		if !ok || value == "" {
+			continue
+		}
+		if err := setField(env.field, value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", env.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// setField 将字符串解析为字段类型并赋值
+func setField(field reflect.Value, value string) error {
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		field.SetFloat(n)
+	case reflect.Slice:
+		var items []string
+		for item := range strings.SplitSeq(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+// Redacted 返回隐藏了密钥的配置副本 (用于输出)
+func (s *Server) Redacted() *Server {
+	c := *s
+	c.CORS.AllowedOrigins = append([]string(nil), s.CORS.AllowedOrigins...)
+	c.AI.ReadyProviders = append([]string(nil), s.AI.ReadyProviders...)
+	if s.AI.Profiles != nil {
+		c.AI.Profiles = make(map[string]AIProfile, len(s.AI.Profiles))
+		for name, profile := range s.AI.Profiles {
+			if profile.APIKey != "" {
+				profile.APIKey = Redacted
+			}
+			c.AI.Profiles[name] = profile
+		}
+	}
+	for _, env := range c.envVars() {
+		if env.Secret && env.field.String() != "" {
+			env.field.SetString(Redacted)
+		}
+	}
+	return &c
+}
+
+// value 返回环境变量形式的配置值 (列表以逗号连接)
+func (e EnvVar) value() string {
+	if m, ok := e.field.Interface().(encoding.TextMarshaler); ok {
+		text, _ := m.MarshalText()
+		return string(text)
+	}
+	if e.field.Kind() == reflect.Slice {
+		return strings.Join(e.field.Interface().([]string), ",")
+	}
+	return fmt.Sprint(e.field.Interface())
+}
+
+// Env 返回 "名称=值" 形式的环境变量列表 (密钥按 Redacted 隐藏)
+func (s *Server) Env() []string {
+	vars := s.Redacted().envVars()
+	lines := make([]string, len(vars))
+	for i, env := range vars {
+		lines[i] = env.Name + "=" + env.value()
+	}
+	return lines
+}
+
+// Validate 校验配置,返回所有错误 (每行一个配置项)
+func (s *Server) Validate() error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(s.Port >= 1 && s.Port <= 65535, "port", "must be between 1 and 65535, got %d", s.Port)
+	check(oneOf(s.Mode, "debug", "release", "test"), "mode", "must be debug, release or test, got %q", s.Mode)
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(s.Log.Level)) == nil, "log.level", "must be debug, info, warn or error, got %q", s.Log.Level)
+	check(oneOf(s.Log.Format, "json", "text"), "log.format", "must be json or text, got %q", s.Log.Format)
+
+	check(len(s.CORS.AllowedOrigins) > 0, "cors.allowedOrigins", "must not be empty (use \"*\" to allow all origins)")
+	for _, origin := range s.CORS.AllowedOrigins {
+		check(origin == "*" || strings.HasPrefix(origin, "http://") || strings.HasPrefix(origin, "https://"),
+			"cors.allowedOrigins", "%q must be \"*\" or start with http:// or https://", origin)
+	}
+
+	check(s.Limits.MaxMarkdownSize > 0, "limits.maxMarkdownSize", "must be positive")
+	check(s.Limits.MaxUploadSize > 0, "limits.maxUploadSize", "must be positive")
+	check(s.Limits.RateLimit >= 0, "limits.rateLimit", "must not be negative")
+	check(s.Limits.RateBurst >= 1, "limits.rateBurst", "must be at least 1")
+	checkDuration(check, "limits.shutdownTimeout", s.Limits.ShutdownTimeout)
+
+	d := s.Defaults
+	check(d.Width >= MinWidth && d.Width <= MaxWidth, "defaults.width", "must be between %d and %d", MinWidth, MaxWidth)
+	check(d.FontSize >= MinFontSize && d.FontSize <= MaxFontSize, "defaults.fontSize", "must be between %d and %d", MinFontSize, MaxFontSize)
+	check(d.Quality >= MinQuality && d.Quality <= MaxQuality, "defaults.quality", "must be between %d and %d", MinQuality, MaxQuality)
+	check(d.DevicePixelRatio >= MinDevicePixelRatio && d.DevicePixelRatio <= MaxDevicePixelRatio,
+		"defaults.devicePixelRatio", "must be between %g and %g", MinDevicePixelRatio, MaxDevicePixelRatio)
+	check(oneOf(d.Theme, "light", "dark"), "defaults.theme", "must be light or dark, got %q", d.Theme)
+	check(oneOf(d.Format, "png", "jpeg", "webp"), "defaults.format", "must be png, jpeg or webp, got %q", d.Format)
+	check(oneOf(d.AIProvider, "gemini", "ollama"), "defaults.aiProvider", "must be gemini or ollama, got %q", d.AIProvider)
+
+	for _, name := range slices.Sorted(maps.Keys(s.AI.Profiles)) {
+		profile, key := s.AI.Profiles[name], "ai.profiles."+name
+		check(name != "" && !strings.ContainsAny(name, " ,:"), key, "profile names must not be empty or contain spaces, commas or colons")
+		check(oneOf(profile.Provider, "gemini", "ollama"), key+".provider", "must be gemini or ollama, got %q", profile.Provider)
+		check(profile.Model != "", key+".model", "is required")
+		check(profile.Endpoint == "" || strings.HasPrefix(profile.Endpoint, "http://") || strings.HasPrefix(profile.Endpoint, "https://"),
+			key+".endpoint", "%q must start with http:// or https://", profile.Endpoint)
+		check(profile.Provider != "gemini" || profile.APIKey != "" || s.AI.GeminiAPIKey != "",
+			key+".apiKey", "is required for gemini (or set ai.geminiApiKey)")
+	}
+	if name := s.AI.DefaultProfile; name != "" {
+		_, ok := s.AI.Profiles[name]
+		check(ok, "ai.defaultProfile", "profile %q is not defined in ai.profiles", name)
+	}
+
+	for _, item := range s.AI.ReadyProviders {
+		provider, model, _ := strings.Cut(item, ":")
+		check(oneOf(provider, "gemini", "ollama") && model != "", "ai.readyProviders", "%q must be gemini:<model> or ollama:<model>", item)
+		check(provider != "gemini" || s.AI.GeminiAPIKey != "", "ai.geminiApiKey", "is required to check %q", item)
+	}
+
+	check(s.Browser.MaxConcurrent >= 0, "browser.maxConcurrent", "must not be negative")
+	check(s.Browser.QueueSize >= 0, "browser.queueSize", "must not be negative")
+	checkDuration(check, "browser.queueTimeout", s.Browser.QueueTimeout)
+	if s.Browser.Path != "" {
+		info, err := os.Stat(s.Browser.Path)
+		check(err == nil && !info.IsDir(), "browser.path", "%s is not a file", s.Browser.Path)
+	}
+
+	check(s.Cache.SizeMB >= 0, "cache.sizeMB", "must not be negative")
+
+	// 与 signing.MinKeyLength 一致
+	check(s.Auth.SigningKey == "" || len(s.Auth.SigningKey) >= 32, "auth.signingKey", "must be at least 32 bytes")
+
+	for _, dir := range []struct{ key, path string }{
+		{"content.templateDir", s.Content.TemplateDir},
+		{"content.fontDir", s.Content.FontDir},
+		{"content.contentDir", s.Content.ContentDir},
+	} {
+		if dir.path != "" {
+			info, err := os.Stat(dir.path)
+			check(err == nil && info.IsDir(), dir.key, "%s is not a directory", dir.path)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid server config:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// checkDuration 检查时长至少为 1 秒
+func checkDuration(check func(bool, string, string, ...any), key string, d Duration) {
+	check(d.Std() >= time.Second, key, "must be at least 1s, got %s", d)
+}
+
+// oneOf 检查 v 是否为候选值之一
+func oneOf(v string, candidates ...string) bool {
+	for _, c := range candidates {
+		if v == c {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Server API 服务配置
+//
+// 加载顺序: 默认值 (DefaultServer) → 配置文件 (YAML 或 TOML) → 环境变量 (env 标签) → 校验。
+// 带 secret 标签的字段在 config print 中隐藏。
+type Server struct {
+	Port int    `yaml:"port" toml:"port" env:"PORT"`     // 监听端口
+	Mode string `yaml:"mode" toml:"mode" env:"GIN_MODE"` // Gin 运行模式: debug, release, test
+
+	Log      LogConfig      `yaml:"log" toml:"log"`
+	CORS     CORSConfig     `yaml:"cors" toml:"cors"`
+	Limits   LimitsConfig   `yaml:"limits" toml:"limits"`
+	Defaults DefaultsConfig `yaml:"defaults" toml:"defaults"`
+	AI       AIConfig       `yaml:"ai" toml:"ai"`
+	Browser  BrowserConfig  `yaml:"browser" toml:"browser"`
+	Cache    CacheConfig    `yaml:"cache" toml:"cache"`
+	Auth     AuthConfig     `yaml:"auth" toml:"auth"`
+	Content  ContentConfig  `yaml:"content" toml:"content"`
+}
+
+// LogConfig 日志配置
+type LogConfig struct {
+	Level  string `yaml:"level" toml:"level" env:"LOG_LEVEL"`    // debug, info, warn, error
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"` // json 或 text
+}
+
+// CORSConfig 跨域配置
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowedOrigins" toml:"allowedOrigins" env:"ALLOWED_ORIGINS"` // 允许的源 ("*" 表示全部)
+}
+
+// LimitsConfig 请求大小和限流配置
+type LimitsConfig struct {
+	MaxMarkdownSize int      `yaml:"maxMarkdownSize" toml:"maxMarkdownSize" env:"MAX_MARKDOWN_SIZE"` // Markdown 最大字节数
+	MaxUploadSize   int      `yaml:"maxUploadSize" toml:"maxUploadSize" env:"MAX_UPLOAD_SIZE"`       // 上传文件最大字节数
+	RateLimit       float64  `yaml:"rateLimit" toml:"rateLimit" env:"RATE_LIMIT"`                    // 每个客户端每秒请求数 (0 禁用限流)
+	RateBurst       int      `yaml:"rateBurst" toml:"rateBurst" env:"RATE_BURST"`                    // 每个客户端的突发请求数
+	ShutdownTimeout Duration `yaml:"shutdownTimeout" toml:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT"`  // 关闭时等待进行中请求的最长时间
+}
+
+// DefaultsConfig 请求未指定时使用的转换选项
+type DefaultsConfig struct {
+	Title            string  `yaml:"title" toml:"title" env:"DEFAULT_TITLE"`
+	Theme            string  `yaml:"theme" toml:"theme" env:"DEFAULT_THEME"`
+	Width            int     `yaml:"width" toml:"width" env:"DEFAULT_WIDTH"`
+	FontSize         int     `yaml:"fontSize" toml:"fontSize" env:"DEFAULT_FONT_SIZE"`
+	FontFamily       string  `yaml:"fontFamily" toml:"fontFamily" env:"DEFAULT_FONT_FAMILY"`
+	Format           string  `yaml:"format" toml:"format" env:"DEFAULT_FORMAT"` // png, jpeg, webp
+	Quality          int     `yaml:"quality" toml:"quality" env:"DEFAULT_QUALITY"`
+	DevicePixelRatio float64 `yaml:"devicePixelRatio" toml:"devicePixelRatio" env:"DEFAULT_DEVICE_PIXEL_RATIO"`
+	AIProvider       string  `yaml:"aiProvider" toml:"aiProvider" env:"DEFAULT_AI_PROVIDER"` // gemini 或 ollama
+	AIModel          string  `yaml:"aiModel" toml:"aiModel" env:"DEFAULT_AI_MODEL"`
+}
+
+// AIConfig AI 提供器配置
+type AIConfig struct {
+	GeminiAPIKey    string               `yaml:"geminiApiKey" toml:"geminiApiKey" env:"GEMINI_API_KEY" secret:"true"` // Gemini API 密钥 (就绪检查和未设置密钥的 Gemini 配置使用)
+	Profiles        map[string]AIProfile `yaml:"profiles" toml:"profiles"`                                            // 命名的 AI 配置 (请求通过 aiProfile 引用)
+	DefaultProfile  string               `yaml:"defaultProfile" toml:"defaultProfile" env:"AI_DEFAULT_PROFILE"`       // AI 模式请求未指定提供器时使用的配置
+	AllowClientKeys bool                 `yaml:"allowClientKeys" toml:"allowClientKeys" env:"AI_ALLOW_CLIENT_KEYS"`   // 是否允许请求携带 aiApiKey
+	ReadyProviders  []string             `yaml:"readyProviders" toml:"readyProviders" env:"READY_AI_PROVIDERS"`       // /readyz 检查的 AI 提供器 (提供器:模型)
+}
+
+// AIProfile 服务端保存的 AI 提供器配置
+type AIProfile struct {
+	Provider string `yaml:"provider" toml:"provider"`                     // gemini 或 ollama
+	Model    string `yaml:"model" toml:"model"`                           // 模型名称
+	Endpoint string `yaml:"endpoint,omitempty" toml:"endpoint,omitempty"` // 服务端点 (为空时使用提供器默认值)
+	APIKey   string `yaml:"apiKey,omitempty" toml:"apiKey,omitempty"`     // API 密钥 (Gemini 为空时使用 geminiApiKey)
+}
+
+// ResolvedProfiles 返回 AI 配置副本,未设置密钥的 Gemini 配置使用 GeminiAPIKey
+func (a AIConfig) ResolvedProfiles() map[string]AIProfile {
+	profiles := make(map[string]AIProfile, len(a.Profiles))
+	for name, profile := range a.Profiles {
+		if profile.Provider == "gemini" && profile.APIKey == "" {
+			profile.APIKey = a.GeminiAPIKey
+		}
+		profiles[name] = profile
+	}
+	return profiles
+}
+
+// BrowserConfig 浏览器配置 (每个转换使用一个浏览器)
+type BrowserConfig struct {
+	Path          string   `yaml:"path" toml:"path" env:"BROWSER_PATH"`                     // 浏览器可执行文件 (为空时自动查找或下载)
+	MaxConcurrent int      `yaml:"maxConcurrent" toml:"maxConcurrent" env:"MAX_CONCURRENT"` // 同时进行的转换数上限 (0 禁用并发限制)
+	QueueSize     int      `yaml:"queueSize" toml:"queueSize" env:"QUEUE_SIZE"`             // 等待转换的请求数上限
+	QueueTimeout  Duration `yaml:"queueTimeout" toml:"queueTimeout" env:"QUEUE_TIMEOUT"`    // 请求排队等待的最长时间
+}
+
+// CacheConfig 渲染缓存配置
+type CacheConfig struct {
+	SizeMB int    `yaml:"sizeMB" toml:"sizeMB" env:"CACHE_SIZE_MB"` // 容量 (MB,0 禁用缓存)
+	Dir    string `yaml:"dir" toml:"dir" env:"CACHE_DIR"`           // 磁盘缓存目录 (为空时使用内存缓存)
+}
+
+// AuthConfig 认证和签名配置
+type AuthConfig struct {
+	APIKeysFile string `yaml:"apiKeysFile" toml:"apiKeysFile" env:"API_KEYS_FILE"`                  // API Key 策略文件
+	APIKeys     string `yaml:"apiKeys" toml:"apiKeys" env:"API_KEYS" secret:"true"`                 // "名称:密钥,..." 列表
+	SigningKey  string `yaml:"signingKey" toml:"signingKey" env:"RENDER_SIGNING_KEY" secret:"true"` // 渲染 URL 签名密钥 (至少 32 字节)
+}
+
+// ContentConfig 模板、字体和文档目录
+type ContentConfig struct {
+	TemplateDir string `yaml:"templateDir" toml:"templateDir" env:"TEMPLATE_DIR"` // 文档模板目录
+	FontDir     string `yaml:"fontDir" toml:"fontDir" env:"FONT_DIR"`             // 字体目录
+	ContentDir  string `yaml:"contentDir" toml:"contentDir" env:"CONTENT_DIR"`    // GET /api/render 的文档目录
+}
+
+// DefaultServer 返回默认服务配置
+func DefaultServer() *Server {
+	return &Server{
+		Port: 8080,
+		Mode: "debug",
+		Log:  LogConfig{Level: "info", Format: "json"},
+		CORS: CORSConfig{AllowedOrigins: []string{"http://localhost:3000", "http://localhost:8080"}},
+		Limits: LimitsConfig{
+			MaxMarkdownSize: MaxMarkdownSize,
+			MaxUploadSize:   MaxFileUploadSize,
+			RateLimit:       DefaultRateLimit,
+			RateBurst:       DefaultRateBurst,
+			ShutdownTimeout: Duration(DefaultShutdownTimeout),
+		},
+		Defaults: DefaultsConfig{
+			Title:            DefaultTitle,
+			Theme:            DefaultTheme,
+			Width:            DefaultWidth,
+			FontSize:         DefaultFontSize,
+			FontFamily:       DefaultFontFamily,
+			Format:           string(DefaultImageFormat()),
+			Quality:          DefaultQuality,
+			DevicePixelRatio: DefaultDevicePixelRatio,
+			AIProvider:       DefaultAIProvider,
+			AIModel:          DefaultAIModel,
+		},
+		Browser: BrowserConfig{
+			MaxConcurrent: DefaultMaxConcurrent,
+			QueueSize:     DefaultQueueSize,
+			QueueTimeout:  Duration(DefaultQueueTimeout),
+		},
+		AI:    AIConfig{AllowClientKeys: true},
+		Cache: CacheConfig{SizeMB: DefaultCacheSizeMB},
+	}
+}
+
+// Duration 配置中的时长,格式为 "30s"、"1m30s",或表示秒数的整数
+type Duration time.Duration
+
+// Std 返回 time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// String 返回 "30s" 形式的时长
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalText 实现 encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler
+func (d *Duration) UnmarshalText(text []byte) error {
+	s := string(text)
+	if seconds, err := strconv.Atoi(s); err == nil {
+		*d = Duration(time.Duration(seconds) * time.Second)
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q (expected e.g. 30s or a number of seconds)", s)
+	}
+	*d = Duration(v)
+	return nil
+}
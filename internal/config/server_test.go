@@ -0,0 +1,224 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLoadServer 测试配置文件、环境变量的优先级和文件格式
+func TestLoadServer(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	yamlFile := write("server.yaml", "port: 9090\nlimits:\n  rateLimit: 5\n  shutdownTimeout: 10\ndefaults:\n  theme: dark\n"+
+		"ai:\n  defaultProfile: local\n  profiles:\n    local:\n      provider: ollama\n      model: llama3.2\n")
+	tomlFile := write("server.toml", "port = 9090\n[limits]\nrateLimit = 5.0\nshutdownTimeout = \"10s\"\n[defaults]\ntheme = \"dark\"\n")
+
+	tests := []struct {
+		name    string
+		path    string
+		env     map[string]string
+		check   func(t *testing.T, cfg *Server)
+		wantErr string
+	}{
+		{
+			name: "默认值",
+			check: func(t *testing.T, cfg *Server) {
+				if cfg.Port != 8080 || cfg.Limits.RateBurst != DefaultRateBurst || cfg.Browser.QueueTimeout.Std() != DefaultQueueTimeout {
+					t.Errorf("unexpected defaults: %+v", cfg)
+				}
+			},
+		},
+		{
+			name: "YAML 文件",
+			path: yamlFile,
+			check: func(t *testing.T, cfg *Server) {
+				if cfg.Port != 9090 || cfg.Limits.RateLimit != 5 || cfg.Defaults.Theme != "dark" {
+					t.Errorf("file values not applied: %+v", cfg)
+				}
+				if cfg.Limits.ShutdownTimeout.Std() != 10*time.Second {
+					t.Errorf("shutdownTimeout = %s, want 10s", cfg.Limits.ShutdownTimeout)
+				}
+				if cfg.Defaults.Width != DefaultWidth {
+					t.Errorf("width = %d, want default %d", cfg.Defaults.Width, DefaultWidth)
+				}
+				if cfg.AI.Profiles["local"].Model != "llama3.2" || !cfg.AI.AllowClientKeys {
+					t.Errorf("ai = %+v, want local profile and client keys allowed by default", cfg.AI)
+				}
+			},
+		},
+		{
+			name: "TOML 文件",
+			path: tomlFile,
+			check: func(t *testing.T, cfg *Server) {
+				if cfg.Port != 9090 || cfg.Limits.RateLimit != 5 || cfg.Limits.ShutdownTimeout.Std() != 10*time.Second {
+					t.Errorf("file values not applied: %+v", cfg)
+				}
+			},
+		},
+		{
+			name: "环境变量覆盖配置文件",
+			path: yamlFile,
+			env:  map[string]string{"PORT": "7000", "ALLOWED_ORIGINS": "https://a.com, https://b.com", "QUEUE_TIMEOUT": "1m", "AI_ALLOW_CLIENT_KEYS": "false"},
+			check: func(t *testing.T, cfg *Server) {
+				if cfg.Port != 7000 {
+					t.Errorf("port = %d, want 7000", cfg.Port)
+				}
+				if want := []string{"https://a.com", "https://b.com"}; !slices.Equal(cfg.CORS.AllowedOrigins, want) {
+					t.Errorf("allowedOrigins = %v, want %v", cfg.CORS.AllowedOrigins, want)
+				}
+				if cfg.Browser.QueueTimeout.Std() != time.Minute {
+					t.Errorf("queueTimeout = %s, want 1m", cfg.Browser.QueueTimeout)
+				}
+				if cfg.Defaults.Theme != "dark" {
+					t.Error("file value should be kept when env is not set")
+				}
+				if cfg.AI.AllowClientKeys {
+					t.Error("AI_ALLOW_CLIENT_KEYS=false not applied")
+				}
+			},
+		},
+		{
+			name:    "未知字段",
+			path:    write("typo.yaml", "limts:\n  rateLimit: 5\n"),
+			wantErr: "limts",
+		},
+		{
+			name:    "不支持的格式",
+			path:    write("server.json", "{}"),
+			wantErr: "unsupported config file format",
+		},
+		{
+			name:    "环境变量类型错误",
+			env:     map[string]string{"RATE_BURST": "ten"},
+			wantErr: "invalid RATE_BURST",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, env := range EnvVars() {
+				t.Setenv(env.Name, tt.env[env.Name])
+			}
+			cfg, err := LoadServer(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadServer() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadServer() error = %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+// TestServerValidate 测试配置校验列出所有错误的配置项
+func TestServerValidate(t *testing.T) {
+	if err := DefaultServer().Validate(); err != nil {
+		t.Fatalf("default config should be valid: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(cfg *Server)
+		want   []string
+	}{
+		{"端口超出范围", func(cfg *Server) { cfg.Port = 70000 }, []string{"port:"}},
+		{"日志级别无效", func(cfg *Server) { cfg.Log.Level = "verbose" }, []string{"log.level:"}},
+		{"跨域源缺少协议", func(cfg *Server) { cfg.CORS.AllowedOrigins = []string{"example.com"} }, []string{"cors.allowedOrigins:"}},
+		{"时长过短", func(cfg *Server) { cfg.Limits.ShutdownTimeout = 0 }, []string{"limits.shutdownTimeout:"}},
+		{"默认选项超出范围", func(cfg *Server) {
+			cfg.Defaults.Width = 10
+			cfg.Defaults.Format = "gif"
+		}, []string{"defaults.width:", "defaults.format:"}},
+		{"Gemini 就绪检查缺少密钥", func(cfg *Server) { cfg.AI.ReadyProviders = []string{"gemini:gemini-2.0-flash"} }, []string{"ai.geminiApiKey:"}},
+		{"就绪检查格式错误", func(cfg *Server) { cfg.AI.ReadyProviders = []string{"ollama"} }, []string{"ai.readyProviders:"}},
+		{"AI 配置缺少模型", func(cfg *Server) {
+			cfg.AI.Profiles = map[string]AIProfile{"fast": {Provider: "gemini", APIKey: "k"}}
+		}, []string{"ai.profiles.fast.model:"}},
+		{"Gemini 配置缺少密钥", func(cfg *Server) {
+			cfg.AI.Profiles = map[string]AIProfile{"fast": {Provider: "gemini", Model: "gemini-2.0-flash"}}
+		}, []string{"ai.profiles.fast.apiKey:"}},
+		{"默认配置不存在", func(cfg *Server) { cfg.AI.DefaultProfile = "missing" }, []string{"ai.defaultProfile:"}},
+		{"签名密钥过短", func(cfg *Server) { cfg.Auth.SigningKey = "short" }, []string{"auth.signingKey:"}},
+		{"目录不存在", func(cfg *Server) { cfg.Content.FontDir = filepath.Join(t.TempDir(), "missing") }, []string{"content.fontDir:"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultServer()
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if err == nil {
+				t.Fatal("Validate() error = nil")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() error = %v, want %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+// TestResolvedProfiles 测试未设置密钥的 Gemini 配置使用 GeminiAPIKey
+func TestResolvedProfiles(t *testing.T) {
+	cfg := AIConfig{
+		GeminiAPIKey: "global",
+		Profiles: map[string]AIProfile{
+			"shared": {Provider: "gemini", Model: "gemini-2.0-flash"},
+			"own":    {Provider: "gemini", Model: "gemini-2.0-flash", APIKey: "own"},
+			"local":  {Provider: "ollama", Model: "llama3.2"},
+		},
+	}
+	profiles := cfg.ResolvedProfiles()
+	if profiles["shared"].APIKey != "global" || profiles["own"].APIKey != "own" || profiles["local"].APIKey != "" {
+		t.Errorf("ResolvedProfiles() = %+v", profiles)
+	}
+	if cfg.Profiles["shared"].APIKey != "" {
+		t.Error("ResolvedProfiles() should not modify the config")
+	}
+}
+
+// TestServerRedacted 测试输出配置时隐藏密钥
+func TestServerRedacted(t *testing.T) {
+	cfg := DefaultServer()
+	cfg.AI.GeminiAPIKey = "gemini-secret"
+	cfg.Auth.APIKeys = "team:key-secret"
+	cfg.Auth.SigningKey = strings.Repeat("s", 32)
+	cfg.AI.Profiles = map[string]AIProfile{"fast": {Provider: "gemini", Model: "gemini-2.0-flash", APIKey: "profile-secret"}}
+
+	redacted := cfg.Redacted()
+	if redacted.AI.GeminiAPIKey != Redacted || redacted.Auth.APIKeys != Redacted || redacted.Auth.SigningKey != Redacted {
+		t.Errorf("secrets not redacted: %+v %+v", redacted.AI, redacted.Auth)
+	}
+	if redacted.AI.Profiles["fast"].APIKey != Redacted {
+		t.Errorf("profile key not redacted: %+v", redacted.AI.Profiles)
+	}
+	if cfg.AI.GeminiAPIKey != "gemini-secret" || cfg.AI.Profiles["fast"].APIKey != "profile-secret" {
+		t.Error("Redacted() should not modify the original config")
+	}
+	if redacted.Auth.APIKeysFile != "" {
+		t.Error("empty values should stay empty")
+	}
+
+	env := strings.Join(cfg.Env(), "\n")
+	if strings.Contains(env, "secret") || !strings.Contains(env, "GEMINI_API_KEY="+Redacted) {
+		t.Errorf("Env() leaks secrets:\n%s", env)
+	}
+	if !strings.Contains(env, "SHUTDOWN_TIMEOUT=30s") {
+		t.Errorf("Env() = %s, want SHUTDOWN_TIMEOUT=30s", env)
+	}
+}
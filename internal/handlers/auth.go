@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/converter"
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-yaml"
+)
+
+// apiKeyContextKey gin.Context 中保存当前 API Key 策略的键
+const apiKeyContextKey = "apiKey"
+
+// APIKeyPolicy API Key 及其访问策略
+//
+// 列表字段为空时不限制。
+type APIKeyPolicy struct {
+	Name            string   `yaml:"name" json:"name"`                                           // 名称 (如团队名,用于用量统计)
+	Key             string   `yaml:"key" json:"-"`                                               // 密钥
+	ParserModes     []string `yaml:"parserModes,omitempty" json:"parserModes,omitempty"`         // 允许的解析器模式 (traditional, ai)
+	AIProviders     []string `yaml:"aiProviders,omitempty" json:"aiProviders,omitempty"`         // 允许的 AI 提供器 (gemini, ollama)
+	MaxMarkdownSize int      `yaml:"maxMarkdownSize,omitempty" json:"maxMarkdownSize,omitempty"` // Markdown 最大字节数 (0 使用全局限制)
+	Admin           bool     `yaml:"admin,omitempty" json:"admin,omitempty"`                     // 可查看所有 Key 的用量
+	RateLimit       float64  `yaml:"rateLimit,omitempty" json:"rateLimit,omitempty"`             // 每秒请求数 (0 使用全局限制)
+	RateBurst       int      `yaml:"rateBurst,omitempty" json:"rateBurst,omitempty"`             // 突发请求数 (0 使用全局限制)
+}
+
+// APIKeyUsage 单个 API Key 的用量统计
+type APIKeyUsage struct {
+	Requests int64     `json:"requests"`           // 请求数
+	Errors   int64     `json:"errors"`             // 失败请求数 (状态码 >= 400,不含客户端断开)
+	Bytes    int64     `json:"bytes"`              // 响应字节数
+	LastUsed time.Time `json:"lastUsed,omitempty"` // 最近使用时间
+}
+
+// APIKeyStore API Key 存储和用量统计
+type APIKeyStore struct {
+	keys map[[sha256.Size]byte]*APIKeyPolicy // 按密钥哈希索引
+
+	mu    sync.Mutex
+	usage map[string]*APIKeyUsage // 按名称索引
+}
+
+// apiKeyFile API Key 配置文件格式 (YAML 或 JSON)
+type apiKeyFile struct {
+	Keys []APIKeyPolicy `yaml:"keys"`
+}
+
+// NewAPIKeyStore 创建 API Key 存储,名称和密钥不能重复
+func NewAPIKeyStore(policies []APIKeyPolicy) (*APIKeyStore, error) {
+	store := &APIKeyStore{
+		keys:  make(map[[sha256.Size]byte]*APIKeyPolicy, len(policies)),
+		usage: make(map[string]*APIKeyUsage, len(policies)),
+	}
+	for i := range policies {
+		p := &policies[i]
+		if p.Name == "" || p.Key == "" {
+			return nil, fmt.Errorf("api key #%d: name and key are required", i+1)
+		}
+		if _, ok := store.usage[p.Name]; ok {
+			return nil, fmt.Errorf("duplicate api key name: %s", p.Name)
+		}
+		hash := sha256.Sum256([]byte(p.Key))
+		if _, ok := store.keys[hash]; ok {
+			return nil, fmt.Errorf("duplicate api key for %s", p.Name)
+		}
+		store.keys[hash] = p
+		store.usage[p.Name] = &APIKeyUsage{}
+	}
+	return store, nil
+}
+
+// LoadAPIKeyFile 从 YAML/JSON 文件读取 API Key 策略
+//
+// 文件格式:
+//
+//	keys:
+//	  - name: docs-team
+//	    key: "..."
+//	    parserModes: [traditional]
+//	    maxMarkdownSize: 1048576
+func LoadAPIKeyFile(path string) ([]APIKeyPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read api key file: %w", err)
+	}
+	var file apiKeyFile
+	if err := yaml.UnmarshalWithOptions(data, &file, yaml.Strict()); err != nil {
+		return nil, fmt.Errorf("failed to parse api key file %s: %w", path, err)
+	}
+	return file.Keys, nil
+}
+
+// ParseAPIKeys 解析环境变量格式的 API Key 列表: "名称:密钥,名称:密钥" (不限制策略)
+func ParseAPIKeys(value string) ([]APIKeyPolicy, error) {
+	var policies []APIKeyPolicy
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, key, ok := strings.Cut(item, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid api key entry (expected name:key): %s", item)
+		}
+		policies = append(policies, APIKeyPolicy{Name: strings.TrimSpace(name), Key: strings.TrimSpace(key)})
+	}
+	return policies, nil
+}
+
+// Len 返回 API Key 数量
+func (s *APIKeyStore) Len() int {
+	return len(s.keys)
+}
+
+// HasRateLimits 检查是否有 API Key 单独设置了限流速率
+func (s *APIKeyStore) HasRateLimits() bool {
+	for _, p := range s.keys {
+		if p.RateLimit > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup 按密钥查找策略
+func (s *APIKeyStore) lookup(key string) (*APIKeyPolicy, bool) {
+	p, ok := s.keys[sha256.Sum256([]byte(key))]
+	return p, ok
+}
+
+// record 记录一次请求的用量
+func (s *APIKeyStore) record(name string, status, bytes int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := s.usage[name]
+	u.Requests++
+	if status >= http.StatusBadRequest && status != statusClientClosedRequest {
+		u.Errors++
+	}
+	if bytes > 0 {
+		u.Bytes += int64(bytes)
+	}
+	u.LastUsed = time.Now()
+}
+
+// Usage 返回指定名称的用量快照
+func (s *APIKeyStore) Usage(name string) APIKeyUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u, ok := s.usage[name]; ok {
+		return *u
+	}
+	return APIKeyUsage{}
+}
+
+// AllUsage 返回所有 API Key 的用量快照
+func (s *APIKeyStore) AllUsage() map[string]APIKeyUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all := make(map[string]APIKeyUsage, len(s.usage))
+	for name, u := range s.usage {
+		all[name] = *u
+	}
+	return all
+}
+
+// requestAPIKey 从 Authorization: Bearer 或 X-API-Key 请求头读取密钥
+func requestAPIKey(c *gin.Context) string {
+	if auth := c.GetHeader("Authorization"); auth != "" {
+		if scheme, token, ok := strings.Cut(auth, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	return c.GetHeader("X-API-Key")
+}
+
+// APIKeyAuth API Key 认证中间件
+//
+// 认证通过后将策略保存到上下文,并在请求结束后记录用量。
+// 启用渲染 URL 签名时,GET /api/render 由签名授权,不要求 API Key。
+func APIKeyAuth(store *APIKeyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if renderSigner != nil && c.Request.Method == http.MethodGet && c.Request.URL.Path == renderPath {
+			c.Next()
+			return
+		}
+
+		key := requestAPIKey(c)
+		if key == "" {
+			respondError(c, http.StatusUnauthorized, &APIError{
+				Code:    "UNAUTHORIZED",
+				Message: "缺少 API Key",
+				Details: "请通过 Authorization: Bearer <key> 或 X-API-Key 请求头提供 API Key",
+			})
+			return
+		}
+
+		policy, ok := store.lookup(key)
+		if !ok {
+			respondError(c, http.StatusUnauthorized, &APIError{
+				Code:    "INVALID_API_KEY",
+				Message: "API Key 无效",
+			})
+			return
+		}
+
+		c.Set(apiKeyContextKey, policy)
+		c.Next()
+		store.record(policy.Name, c.Writer.Status(), c.Writer.Size())
+	}
+}
+
+// keyPolicy 返回当前请求的 API Key 策略 (未启用认证时返回 nil)
+func keyPolicy(c *gin.Context) *APIKeyPolicy {
+	if v, ok := c.Get(apiKeyContextKey); ok {
+		return v.(*APIKeyPolicy)
+	}
+	return nil
+}
+
+// checkKeyPolicy 检查当前 API Key 的策略是否允许本次转换,通过时返回 nil
+func checkKeyPolicy(c *gin.Context, opts *converter.ConvertOptions, markdownSize int) (int, *APIError) {
+	policy := keyPolicy(c)
+	if policy == nil {
+		return http.StatusOK, nil
+	}
+
+	if policy.MaxMarkdownSize > 0 && markdownSize > policy.MaxMarkdownSize {
+		return http.StatusBadRequest, &APIError{
+			Code:    "CONTENT_TOO_LARGE",
+			Message: "Markdown 内容过大",
+			Details: fmt.Sprintf("API Key %s 最大支持 %d 字节", policy.Name, policy.MaxMarkdownSize),
+		}
+	}
+
+	forbidden := func(details string) (int, *APIError) {
+		return http.StatusForbidden, &APIError{
+			Code:    "FORBIDDEN_BY_POLICY",
+			Message: "API Key 策略不允许该请求",
+			Details: details,
+		}
+	}
+	mode := opts.ParserMode
+	if mode == "" {
+		mode = "traditional"
+	}
+	if len(policy.ParserModes) > 0 && !slices.Contains(policy.ParserModes, mode) {
+		return forbidden(fmt.Sprintf("parser mode %s is not allowed (allowed: %s)", mode, strings.Join(policy.ParserModes, ", ")))
+	}
+	if mode == "ai" && len(policy.AIProviders) > 0 && !slices.Contains(policy.AIProviders, opts.AIProvider) {
+		return forbidden(fmt.Sprintf("AI provider %s is not allowed (allowed: %s)", opts.AIProvider, strings.Join(policy.AIProviders, ", ")))
+	}
+	return http.StatusOK, nil
+}
+
+// UsageHandler 返回当前 API Key 的用量 (管理员 Key 返回所有 Key 的用量)
+func UsageHandler(store *APIKeyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		policy := keyPolicy(c)
+		if policy == nil {
+			c.JSON(http.StatusOK, APIResponse{
+				Success: true,
+				Data:    gin.H{"enabled": false},
+			})
+			return
+		}
+
+		data := gin.H{
+			"enabled": true,
+			"key":     policy,
+			"usage":   store.Usage(policy.Name),
+		}
+		if policy.Admin {
+			all := store.AllUsage()
+			names := make([]string, 0, len(all))
+			for name := range all {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			keys := make([]gin.H, 0, len(names))
+			for _, name := range names {
+				keys = append(keys, gin.H{"name": name, "usage": all[name]})
+			}
+			data["keys"] = keys
+		}
+		c.JSON(http.StatusOK, APIResponse{
+			Success: true,
+			Data:    data,
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/converter"
+)
+
+// TestAPIKeyAuth 测试 API Key 认证、策略和用量统计
+func TestAPIKeyAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store, err := NewAPIKeyStore([]APIKeyPolicy{
+		{Name: "admin", Key: "admin-key", Admin: true},
+		{Name: "docs", Key: "docs-key", ParserModes: []string{"traditional"}, MaxMarkdownSize: 16},
+		{Name: "ai", Key: "ai-key", AIProviders: []string{"ollama"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	api := router.Group("/api", APIKeyAuth(store))
+	api.GET("/render", RenderHandler)
+	api.GET("/usage", UsageHandler(store))
+
+	etag, _ := renderETag([]byte("# Hello"), converter.DefaultConvertOptions())
+
+	tests := []struct {
+		name       string
+		rawQuery   string
+		header     string
+		value      string
+		wantStatus int
+		wantCode   string
+	}{
+		{"缺少 Key", "markdown=%23%20Hello", "", "", http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"无效 Key", "markdown=%23%20Hello", "X-API-Key", "wrong", http.StatusUnauthorized, "INVALID_API_KEY"},
+		{"非 Bearer 方案", "markdown=%23%20Hello", "Authorization", "Basic docs-key", http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"Bearer", "markdown=%23%20Hello", "Authorization", "Bearer docs-key", http.StatusNotModified, ""},
+		{"X-API-Key", "markdown=%23%20Hello", "X-API-Key", "docs-key", http.StatusNotModified, ""},
+		{"超出大小限制", "markdown=" + strings.Repeat("a", 17), "X-API-Key", "docs-key", http.StatusBadRequest, "CONTENT_TOO_LARGE"},
+		{"不允许的解析器模式", "markdown=%23%20Hello&parserMode=ai", "X-API-Key", "docs-key", http.StatusForbidden, "FORBIDDEN_BY_POLICY"},
+		{"不允许的 AI 提供器", "markdown=%23%20Hello&parserMode=ai&aiProvider=gemini", "X-API-Key", "ai-key", http.StatusForbidden, "FORBIDDEN_BY_POLICY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/render?"+tt.rawQuery, nil)
+			req.Header.Set("If-None-Match", etag)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode != "" && !strings.Contains(w.Body.String(), `"code":"`+tt.wantCode+`"`) {
+				t.Errorf("body = %s, want error code %s", w.Body.String(), tt.wantCode)
+			}
+		})
+	}
+
+	usage := store.Usage("docs")
+	if usage.Requests != 4 || usage.Errors != 2 {
+		t.Errorf("docs usage = %+v, want 4 requests and 2 errors", usage)
+	}
+	if usage := store.Usage("ai"); usage.Requests != 1 || usage.Errors != 1 {
+		t.Errorf("ai usage = %+v, want 1 request and 1 error", usage)
+	}
+
+	// 普通 Key 只能查看自己的用量,管理员 Key 可以查看所有 Key
+	for key, wantAll := range map[string]bool{"docs-key": false, "admin-key": true} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/usage", nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("usage status = %d, body = %s", w.Code, w.Body.String())
+		}
+		if got := strings.Contains(w.Body.String(), `"keys":`); got != wantAll {
+			t.Errorf("%s: usage body = %s, want all keys = %v", key, w.Body.String(), wantAll)
+		}
+		if strings.Contains(w.Body.String(), key) {
+			t.Errorf("%s: usage body leaks the key: %s", key, w.Body.String())
+		}
+	}
+}
+
+// TestNewAPIKeyStore 测试 API Key 加载和校验
+func TestNewAPIKeyStore(t *testing.T) {
+	policies, err := ParseAPIKeys(" team-a:key-a , team-b:key-b ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policies) != 2 || policies[1].Name != "team-b" || policies[1].Key != "key-b" {
+		t.Errorf("ParseAPIKeys() = %+v", policies)
+	}
+	if _, err := ParseAPIKeys("no-separator"); err == nil {
+		t.Error("ParseAPIKeys() 应拒绝缺少名称的条目")
+	}
+
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	content := "keys:\n  - name: docs\n    key: docs-key\n    parserModes: [traditional]\n    maxMarkdownSize: 1024\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadAPIKeyFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 1 || loaded[0].MaxMarkdownSize != 1024 || loaded[0].ParserModes[0] != "traditional" {
+		t.Errorf("LoadAPIKeyFile() = %+v", loaded)
+	}
+
+	if err := os.WriteFile(path, []byte("keys:\n  - name: docs\n    secret: x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadAPIKeyFile(path); err == nil {
+		t.Error("LoadAPIKeyFile() 应拒绝未知字段")
+	}
+
+	invalid := map[string][]APIKeyPolicy{
+		"缺少密钥": {{Name: "a"}},
+		"重复名称": {{Name: "a", Key: "1"}, {Name: "a", Key: "2"}},
+		"重复密钥": {{Name: "a", Key: "1"}, {Name: "b", Key: "1"}},
+	}
+	for name, policies := range invalid {
+		if _, err := NewAPIKeyStore(policies); err == nil {
+			t.Errorf("%s: NewAPIKeyStore() 应返回错误", name)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/cache"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/converter"
+	"github.com/gin-gonic/gin"
+)
+
+// renderCache 渲染结果缓存 (启动时配置,为 nil 时不缓存)
+var renderCache cache.Cache
+
+// SetRenderCache 设置转换接口使用的渲染结果缓存
+//
+// 应在启动服务之前调用。
+func SetRenderCache(c cache.Cache) {
+	renderCache = c
+}
+
+// newConverter 创建本次请求使用的转换器
+//
+// 转换器在启动浏览器前占用并发转换名额 (见 ConcurrencyLimit),配置了渲染缓存时,
+// 命中缓存的请求不会启动浏览器,也不占用名额。
+// 转换器登记为进行中,关闭服务超时时由 CloseActiveConverters 关闭。
+func newConverter(c *gin.Context) (converter.Converter, error) {
+	create := func() (converter.Converter, error) { return newSlotConverter(c) }
+	if renderCache == nil {
+		conv, err := create()
+		if err != nil {
+			return nil, err
+		}
+		return trackConverter(conv), nil
+	}
+	return trackConverter(converter.NewCachingConverter(renderCache, create)), nil
+}
+
+// CacheStatsHandler 返回渲染缓存的命中率和容量统计
+func CacheStatsHandler(c *gin.Context) {
+	if renderCache == nil {
+		c.JSON(http.StatusOK, APIResponse{
+			Success: true,
+			Data:    gin.H{"enabled": false},
+		})
+		return
+	}
+
+	stats := renderCache.Stats()
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data: gin.H{
+			"enabled":  true,
+			"stats":    stats,
+			"hitRatio": stats.HitRatio(),
+		},
+	})
+}
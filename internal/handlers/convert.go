@@ -1,13 +1,15 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 
-	"github.com/Cshiyuan/Gomarkdown2image/internal/config"
 	"github.com/Cshiyuan/Gomarkdown2image/internal/utils"
 	"github.com/Cshiyuan/Gomarkdown2image/pkg/converter"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/parser"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/renderer"
 	"github.com/gin-gonic/gin"
 )
 
@@ -26,78 +28,78 @@ func ConvertHandler(c *gin.Context) {
 
 	// 绑定并验证 JSON 请求
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Success: false,
-			Error: &APIError{
-				Code:    "INVALID_REQUEST",
-				Message: "请求参数验证失败",
-				Details: err.Error(),
-			},
+		respondError(c, http.StatusBadRequest, &APIError{
+			Code:    "INVALID_REQUEST",
+			Message: "请求参数验证失败",
+			Details: err.Error(),
 		})
 		return
 	}
 
 	// 验证 Markdown 内容大小
-	if len(req.Markdown) > config.MaxMarkdownSize {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Success: false,
-			Error: &APIError{
-				Code:    "CONTENT_TOO_LARGE",
-				Message: "Markdown 内容过大",
-				Details: fmt.Sprintf("最大支持 %d MB", config.MaxMarkdownSize/(1024*1024)),
-			},
+	if int64(len(req.Markdown)) > maxMarkdownSize {
+		respondError(c, http.StatusBadRequest, &APIError{
+			Code:    "CONTENT_TOO_LARGE",
+			Message: "Markdown 内容过大",
+			Details: sizeLimitDetails(maxMarkdownSize),
 		})
 		return
 	}
 
 	// 验证自定义 CSS (防止 XSS 注入)
 	if err := utils.ValidateCustomCSS(req.CustomCSS); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Success: false,
-			Error: &APIError{
-				Code:    "INVALID_CUSTOM_CSS",
-				Message: "自定义 CSS 验证失败",
-				Details: err.Error(),
-			},
+		respondError(c, http.StatusBadRequest, &APIError{
+			Code:    "INVALID_CUSTOM_CSS",
+			Message: "自定义 CSS 验证失败",
+			Details: err.Error(),
 		})
 		return
 	}
 
-	// 创建转换器
-	conv, err := converter.NewConverter()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{
-			Success: false,
-			Error: &APIError{
-				Code:    "CONVERTER_INIT_FAILED",
-				Message: "转换器初始化失败",
-				Details: err.Error(),
-			},
-		})
+	// 验证文档模板名称
+	if apiErr := validateTemplateName(req.Template); apiErr != nil {
+		respondError(c, http.StatusBadRequest, apiErr)
 		return
 	}
-	defer conv.Close()
 
 	// 构建转换选项
 	opts := buildConvertOptions(&req)
 
-	// 执行转换
-	imageData, err := conv.Convert([]byte(req.Markdown), opts)
+	// 验证转换选项之间的组合
+	if apiErr := validateConvertOptions(opts); apiErr != nil {
+		respondError(c, http.StatusBadRequest, apiErr)
+		return
+	}
+
+	// 应用服务端 AI 配置
+	if status, apiErr := applyAIProfile(&req, opts); apiErr != nil {
+		respondError(c, status, apiErr)
+		return
+	}
+
+	// 检查 API Key 策略
+	if status, apiErr := checkKeyPolicy(c, opts, len(req.Markdown)); apiErr != nil {
+		respondError(c, status, apiErr)
+		return
+	}
+
+	// 创建转换器
+	conv, err := newConverter(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{
-			Success: false,
-			Error: &APIError{
-				Code:    "CONVERSION_FAILED",
-				Message: "Markdown 转换失败",
-				Details: err.Error(),
-			},
+		if respondBusy(c, err) || abortCanceled(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, &APIError{
+			Code:    "CONVERTER_INIT_FAILED",
+			Message: "转换器初始化失败",
+			Details: err.Error(),
 		})
 		return
 	}
+	defer conv.Close()
 
-	// 返回图片
-	contentType := utils.GetContentType(opts.ImageFormat)
-	c.Data(http.StatusOK, contentType, imageData)
+	// 执行转换并返回结果
+	respondConversion(c, conv, []byte(req.Markdown), opts)
 }
 
 // UploadHandler 处理文件上传方式的 Markdown 转换
@@ -120,13 +122,10 @@ func UploadHandler(c *gin.Context) {
 
 	// 绑定并验证表单参数
 	if err := c.ShouldBind(&formReq); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Success: false,
-			Error: &APIError{
-				Code:    "INVALID_FORM",
-				Message: "表单参数验证失败",
-				Details: err.Error(),
-			},
+		respondError(c, http.StatusBadRequest, &APIError{
+			Code:    "INVALID_FORM",
+			Message: "表单参数验证失败",
+			Details: err.Error(),
 		})
 		return
 	}
@@ -134,26 +133,20 @@ func UploadHandler(c *gin.Context) {
 	// 获取上传的文件
 	file, err := c.FormFile("file")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Success: false,
-			Error: &APIError{
-				Code:    "NO_FILE_UPLOADED",
-				Message: "未找到上传文件",
-				Details: "请在表单中上传文件 (字段名: file)",
-			},
+		respondError(c, http.StatusBadRequest, &APIError{
+			Code:    "NO_FILE_UPLOADED",
+			Message: "未找到上传文件",
+			Details: "请在表单中上传文件 (字段名: file)",
 		})
 		return
 	}
 
 	// 验证文件大小
-	if file.Size > config.MaxFileUploadSize {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Success: false,
-			Error: &APIError{
-				Code:    "FILE_TOO_LARGE",
-				Message: "文件过大",
-				Details: fmt.Sprintf("最大支持 %d MB", config.MaxFileUploadSize/(1024*1024)),
-			},
+	if file.Size > maxUploadSize {
+		respondError(c, http.StatusBadRequest, &APIError{
+			Code:    "FILE_TOO_LARGE",
+			Message: "文件过大",
+			Details: sizeLimitDetails(maxUploadSize),
 		})
 		return
 	}
@@ -161,13 +154,10 @@ func UploadHandler(c *gin.Context) {
 	// 打开文件
 	src, err := file.Open()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{
-			Success: false,
-			Error: &APIError{
-				Code:    "FILE_READ_FAILED",
-				Message: "文件读取失败",
-				Details: err.Error(),
-			},
+		respondError(c, http.StatusInternalServerError, &APIError{
+			Code:    "FILE_READ_FAILED",
+			Message: "文件读取失败",
+			Details: err.Error(),
 		})
 		return
 	}
@@ -176,71 +166,126 @@ func UploadHandler(c *gin.Context) {
 	// 读取文件内容
 	markdownData, err := io.ReadAll(src)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{
-			Success: false,
-			Error: &APIError{
-				Code:    "FILE_READ_FAILED",
-				Message: "文件内容读取失败",
-				Details: err.Error(),
-			},
+		respondError(c, http.StatusInternalServerError, &APIError{
+			Code:    "FILE_READ_FAILED",
+			Message: "文件内容读取失败",
+			Details: err.Error(),
 		})
 		return
 	}
 
 	// 验证自定义 CSS (防止 XSS 注入)
 	if err := utils.ValidateCustomCSS(formReq.CustomCSS); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Success: false,
-			Error: &APIError{
-				Code:    "INVALID_CUSTOM_CSS",
-				Message: "自定义 CSS 验证失败",
-				Details: err.Error(),
-			},
+		respondError(c, http.StatusBadRequest, &APIError{
+			Code:    "INVALID_CUSTOM_CSS",
+			Message: "自定义 CSS 验证失败",
+			Details: err.Error(),
 		})
 		return
 	}
 
+	// 验证文档模板名称
+	if apiErr := validateTemplateName(formReq.Template); apiErr != nil {
+		respondError(c, http.StatusBadRequest, apiErr)
+		return
+	}
+
+	// 构建转换选项 (从表单参数)
+	opts := buildConvertOptionsFromForm(&formReq)
+
+	// 验证转换选项之间的组合
+	if apiErr := validateConvertOptions(opts); apiErr != nil {
+		respondError(c, http.StatusBadRequest, apiErr)
+		return
+	}
+
+	// 应用服务端 AI 配置
+	if status, apiErr := applyAIProfile(&formReq, opts); apiErr != nil {
+		respondError(c, status, apiErr)
+		return
+	}
+
+	// 检查 API Key 策略
+	if status, apiErr := checkKeyPolicy(c, opts, len(markdownData)); apiErr != nil {
+		respondError(c, status, apiErr)
+		return
+	}
+
 	// 创建转换器
-	conv, err := converter.NewConverter()
+	conv, err := newConverter(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{
-			Success: false,
-			Error: &APIError{
-				Code:    "CONVERTER_INIT_FAILED",
-				Message: "转换器初始化失败",
-				Details: err.Error(),
-			},
+		if respondBusy(c, err) || abortCanceled(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, &APIError{
+			Code:    "CONVERTER_INIT_FAILED",
+			Message: "转换器初始化失败",
+			Details: err.Error(),
 		})
 		return
 	}
 	defer conv.Close()
 
-	// 构建转换选项 (从表单参数)
-	opts := buildConvertOptionsFromForm(&formReq)
+	// 执行转换并返回结果
+	respondConversion(c, conv, markdownData, opts)
+}
 
-	// 执行转换
-	imageData, err := conv.Convert(markdownData, opts)
+// respondConversion 执行转换并写入响应
+func respondConversion(c *gin.Context, conv converter.Converter, markdown []byte, opts *converter.ConvertOptions) {
+	opts.Logger = requestLogger(c)
+	opts.Context = c.Request.Context()
+	data, contentType, err := runConversion(conv, markdown, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{
-			Success: false,
-			Error: &APIError{
-				Code:    "CONVERSION_FAILED",
-				Message: "Markdown 转换失败",
-				Details: err.Error(),
-			},
-		})
+		if respondBusy(c, err) || abortCanceled(c, err) {
+			return
+		}
+		status, apiErr := conversionError(err)
+		respondError(c, status, apiErr)
 		return
 	}
 
-	// 返回图片
-	contentType := utils.GetContentType(opts.ImageFormat)
-	c.Data(http.StatusOK, contentType, imageData)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// conversionError 将转换错误映射为 HTTP 状态码和 API 错误
+//
+// 选择器或章节不存在属于请求选项错误,返回 400,其余返回 500。
+func conversionError(err error) (int, *APIError) {
+	if errors.Is(err, renderer.ErrElementNotFound) {
+		return http.StatusBadRequest, &APIError{
+			Code:    "INVALID_OPTIONS",
+			Message: "转换选项验证失败",
+			Details: err.Error(),
+		}
+	}
+	return http.StatusInternalServerError, &APIError{
+		Code:    "CONVERSION_FAILED",
+		Message: "Markdown 转换失败",
+		Details: err.Error(),
+	}
+}
+
+// runConversion 执行转换,返回响应内容和 Content-Type
+//
+// 幻灯片布局的逐页图片打包为 ZIP 返回,其余情况直接返回图片 (或 PDF)。
+func runConversion(conv converter.Converter, markdown []byte, opts *converter.ConvertOptions) ([]byte, string, error) {
+	if opts.Layout == converter.LayoutSlides && opts.ImageFormat != renderer.FormatPDF {
+		pages, err := conv.ConvertSlides(markdown, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		data, err := utils.ZipPages(pages, "slide", opts.ImageFormat)
+		return data, "application/zip", err
+	}
+
+	data, err := conv.Convert(markdown, opts)
+	return data, utils.GetContentType(opts.ImageFormat), err
 }
 
 // buildConvertOptionsFromParams 从 RequestParams 接口构建 ConvertOptions
 // 这是统一的构建函数,消除了 buildConvertOptions 和 buildConvertOptionsFromForm 的代码重复
 func buildConvertOptionsFromParams(params RequestParams) *converter.ConvertOptions {
-	opts := converter.DefaultConvertOptions()
+	opts := newDefaultOptions()
 
 	// HTML 模板选项
 	if v := params.GetTitle(); v != "" {
@@ -249,9 +294,50 @@ func buildConvertOptionsFromParams(params RequestParams) *converter.ConvertOptio
 	if v := params.GetTheme(); v != "" {
 		opts.Theme = v
 	}
+	if v := params.GetTemplate(); v != "" {
+		opts.DocumentTemplate = documentTemplates[v]
+	}
 	if v := params.GetCustomCSS(); v != "" {
 		opts.CustomCSS = v
 	}
+	if params.GetTOC() {
+		opts.TOC = true
+	}
+	if v := params.GetTOCDepth(); v > 0 {
+		opts.TOCDepth = v
+	}
+	if v := params.GetExtensions(); len(v) > 0 {
+		opts.Extensions = v
+	}
+	if v := params.GetHeader(); v != "" {
+		opts.Header = v
+	}
+	if v := params.GetFooter(); v != "" {
+		opts.Footer = v
+	}
+	if v := params.GetLogo(); v != "" {
+		opts.BrandLogo = v
+	}
+
+	// 水印选项
+	if v := params.GetWatermarkText(); v != "" {
+		opts.WatermarkText = v
+	}
+	if v := params.GetWatermarkImage(); v != "" {
+		opts.WatermarkImage = v
+	}
+	if v := params.GetWatermarkPosition(); v != "" {
+		opts.WatermarkPosition = v
+	}
+	if v := params.GetWatermarkOpacity(); v > 0 {
+		opts.WatermarkOpacity = v
+	}
+	if v := params.GetWatermarkRotation(); v != nil {
+		opts.WatermarkRotation = *v
+	}
+	if params.GetWatermarkTile() {
+		opts.WatermarkTile = true
+	}
 	if v := params.GetWidth(); v > 0 {
 		opts.Width = v
 	}
@@ -261,6 +347,51 @@ func buildConvertOptionsFromParams(params RequestParams) *converter.ConvertOptio
 	if v := params.GetFontFamily(); v != "" {
 		opts.FontFamily = v
 	}
+	if params.GetTransparent() {
+		opts.Transparent = true
+	}
+	if v := params.GetLang(); v != "" {
+		opts.Lang = v
+	}
+	if v := params.GetDir(); v != "" {
+		opts.Dir = v
+	}
+
+	// 布局选项
+	if v := params.GetLayout(); v != "" {
+		opts.Layout = v
+	}
+	if v := params.GetCardPreset(); v != "" {
+		opts.CardPreset = v
+	}
+	if v := params.GetCardLogo(); v != "" {
+		opts.CardLogo = v
+	}
+
+	// 代码片段选项
+	if v := params.GetSnippetLanguage(); v != "" {
+		opts.SnippetLanguage = v
+	}
+	if v := params.GetSnippetStyle(); v != "" {
+		opts.SnippetStyle = v
+	}
+	if v := params.GetSnippetTitle(); v != "" {
+		opts.SnippetTitle = v
+	}
+	if v := params.GetSnippetWindow(); v != nil {
+		opts.SnippetWindow = *v
+	}
+	if v := params.GetSnippetHighlight(); v != "" {
+		opts.SnippetHighlight = v
+	}
+	if v := params.GetSnippetBackground(); v != "" {
+		opts.SnippetBackground = v
+	}
+
+	// 幻灯片选项
+	if v := params.GetSlideAspect(); v != "" {
+		opts.SlideAspect = v
+	}
 
 	// 图像渲染选项
 	if v := params.GetImageFormat(); v != "" {
@@ -272,6 +403,12 @@ func buildConvertOptionsFromParams(params RequestParams) *converter.ConvertOptio
 	if v := params.GetDevicePixelRatio(); v > 0 {
 		opts.DevicePixelRatio = v
 	}
+	if v := params.GetSelector(); v != "" {
+		opts.Selector = v
+	}
+	if v := params.GetSection(); v != "" {
+		opts.Section = v
+	}
 
 	// AI 增强选项
 	if v := params.GetParserMode(); v != "" {
@@ -299,6 +436,65 @@ func buildConvertOptionsFromParams(params RequestParams) *converter.ConvertOptio
 	return opts
 }
 
+// validateConvertOptions 验证构建后的转换选项,通过时返回 nil
+func validateConvertOptions(opts *converter.ConvertOptions) *APIError {
+	invalid := func(err error) *APIError {
+		return &APIError{
+			Code:    "INVALID_OPTIONS",
+			Message: "转换选项验证失败",
+			Details: err.Error(),
+		}
+	}
+
+	if err := utils.ValidateTransparentFormat(opts.ImageFormat, opts.Transparent); err != nil {
+		return invalid(err)
+	}
+	if opts.Selector != "" && opts.Section != "" {
+		return invalid(fmt.Errorf("selector 和 section 不能同时使用"))
+	}
+	if _, err := parser.ResolveExtensions(opts.Extensions); err != nil {
+		return invalid(err)
+	}
+	if opts.Lang != "" {
+		if err := parser.ValidateLang(opts.Lang); err != nil {
+			return invalid(err)
+		}
+	}
+	if err := parser.ValidateDir(opts.Dir); err != nil {
+		return invalid(err)
+	}
+	if opts.ImageFormat == renderer.FormatPDF && opts.Layout != converter.LayoutSlides {
+		return invalid(fmt.Errorf("pdf 格式仅支持 slides 布局"))
+	}
+	if opts.Layout == converter.LayoutSlides {
+		if _, err := parser.LookupSlideAspect(opts.SlideAspect); err != nil {
+			return invalid(err)
+		}
+	}
+	if !parser.HasCodeStyle(opts.SnippetStyle) {
+		return invalid(fmt.Errorf("不支持的代码样式: %s", opts.SnippetStyle))
+	}
+	if _, err := parser.ParseLineRanges(opts.SnippetHighlight); err != nil {
+		return invalid(err)
+	}
+	if opts.BrandLogo != "" {
+		if err := parser.ValidateImageURL(opts.BrandLogo); err != nil {
+			return invalid(err)
+		}
+	}
+	if wm := opts.Watermark(); wm != nil {
+		if err := wm.Validate(); err != nil {
+			return invalid(err)
+		}
+	}
+	// 背景会写入 <style>,按自定义 CSS 规则校验
+	if err := utils.ValidateCustomCSS(opts.SnippetBackground); err != nil {
+		return invalid(err)
+	}
+
+	return nil
+}
+
 // buildConvertOptions 从 ConvertRequest 构建 ConvertOptions
 func buildConvertOptions(req *ConvertRequest) *converter.ConvertOptions {
 	return buildConvertOptionsFromParams(req)
@@ -1,31 +1,65 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
 	"testing"
 
+	"github.com/gin-gonic/gin/binding"
+
 	"github.com/Cshiyuan/Gomarkdown2image/pkg/converter"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/parser"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/renderer"
 )
 
 // TestBuildConvertOptions 测试从 ConvertRequest 构建选项
 func TestBuildConvertOptions(t *testing.T) {
+	noWindow := false
+	rotation := 0
 	req := &ConvertRequest{
-		Markdown:         "# Test",
-		Title:            "测试标题",
-		Theme:            "dark",
-		CustomCSS:        "body { color: red; }",
-		Width:            1400,
-		FontSize:         18,
-		FontFamily:       "Arial",
-		ImageFormat:      "jpeg",
-		ImageQuality:     85,
-		DevicePixelRatio: 2.0,
-		ParserMode:       "ai",
-		AIProvider:       "gemini",
-		AIModel:          "gemini-2.0-flash-exp",
-		AIAPIKey:         "test-key",
-		AIEndpoint:       "https://api.example.com",
-		AIPromptTemplate: "enhance",
-		AICustomPrompt:   "自定义提示词",
+		Markdown:          "# Test",
+		Title:             "测试标题",
+		Theme:             "dark",
+		CustomCSS:         "body { color: red; }",
+		TOC:               true,
+		TOCDepth:          2,
+		Extensions:        []string{"footnote", "emoji"},
+		Header:            "{{title}}",
+		Footer:            "{{page}}",
+		Logo:              "https://example.com/brand.png",
+		WatermarkText:     "CONFIDENTIAL",
+		WatermarkPosition: "bottom-right",
+		WatermarkOpacity:  0.3,
+		WatermarkRotation: &rotation,
+		WatermarkTile:     true,
+		Width:             1400,
+		FontSize:          18,
+		FontFamily:        "Arial",
+		Transparent:       true,
+		Lang:              "ar",
+		Dir:               "rtl",
+		Layout:            "card",
+		CardPreset:        "twitter",
+		CardLogo:          "https://example.com/logo.png",
+		SnippetLanguage:   "go",
+		SnippetStyle:      "dracula",
+		SnippetWindow:     &noWindow,
+		SnippetHighlight:  "1,3-5",
+		SlideAspect:       "4:3",
+		ImageFormat:       "jpeg",
+		ImageQuality:      85,
+		DevicePixelRatio:  2.0,
+		Selector:          "table",
+		Section:           "usage",
+		ParserMode:        "ai",
+		AIProvider:        "gemini",
+		AIModel:           "gemini-2.0-flash-exp",
+		AIAPIKey:          "test-key",
+		AIEndpoint:        "https://api.example.com",
+		AIPromptTemplate:  "enhance",
+		AICustomPrompt:    "自定义提示词",
 	}
 
 	opts := buildConvertOptions(req)
@@ -39,11 +73,34 @@ func TestBuildConvertOptions(t *testing.T) {
 		{"Title", opts.Title, "测试标题"},
 		{"Theme", opts.Theme, "dark"},
 		{"CustomCSS", opts.CustomCSS, "body { color: red; }"},
+		{"TOC", opts.TOC, true},
+		{"TOCDepth", opts.TOCDepth, 2},
+		{"Header", opts.Header, "{{title}}"},
+		{"Footer", opts.Footer, "{{page}}"},
+		{"BrandLogo", opts.BrandLogo, "https://example.com/brand.png"},
+		{"WatermarkText", opts.WatermarkText, "CONFIDENTIAL"},
+		{"WatermarkPosition", opts.WatermarkPosition, "bottom-right"},
+		{"WatermarkOpacity", opts.WatermarkOpacity, 0.3},
+		{"WatermarkRotation", opts.WatermarkRotation, 0},
+		{"WatermarkTile", opts.WatermarkTile, true},
 		{"Width", opts.Width, 1400},
 		{"FontSize", opts.FontSize, 18},
 		{"FontFamily", opts.FontFamily, "Arial"},
+		{"Transparent", opts.Transparent, true},
+		{"Lang", opts.Lang, "ar"},
+		{"Dir", opts.Dir, "rtl"},
+		{"Layout", opts.Layout, "card"},
+		{"CardPreset", opts.CardPreset, "twitter"},
+		{"CardLogo", opts.CardLogo, "https://example.com/logo.png"},
+		{"SnippetLanguage", opts.SnippetLanguage, "go"},
+		{"SnippetStyle", opts.SnippetStyle, "dracula"},
+		{"SnippetWindow", opts.SnippetWindow, false},
+		{"SnippetHighlight", opts.SnippetHighlight, "1,3-5"},
+		{"SlideAspect", opts.SlideAspect, "4:3"},
 		{"ImageQuality", opts.ImageQuality, 85},
 		{"DevicePixelRatio", opts.DevicePixelRatio, 2.0},
+		{"Selector", opts.Selector, "table"},
+		{"Section", opts.Section, "usage"},
 		{"ParserMode", opts.ParserMode, "ai"},
 		{"AIProvider", opts.AIProvider, "gemini"},
 		{"AIModel", opts.AIModel, "gemini-2.0-flash-exp"},
@@ -60,6 +117,10 @@ func TestBuildConvertOptions(t *testing.T) {
 			}
 		})
 	}
+
+	if len(opts.Extensions) != 2 || opts.Extensions[0] != "footnote" {
+		t.Errorf("Extensions: got %v, want [footnote emoji]", opts.Extensions)
+	}
 }
 
 // TestBuildConvertOptionsFromForm 测试从 UploadRequest 构建选项
@@ -138,10 +199,142 @@ func TestBuildConvertOptionsDefaults(t *testing.T) {
 	if opts.ParserMode != defaults.ParserMode {
 		t.Errorf("ParserMode: got %v, want %v", opts.ParserMode, defaults.ParserMode)
 	}
+	if opts.SnippetWindow != defaults.SnippetWindow {
+		t.Errorf("SnippetWindow: got %v, want %v", opts.SnippetWindow, defaults.SnippetWindow)
+	}
+}
+
+// TestValidateConvertOptions 测试转换选项组合验证
+func TestValidateConvertOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(opts *converter.ConvertOptions)
+		wantErr bool
+	}{
+		{"默认选项", func(opts *converter.ConvertOptions) {}, false},
+		{"JPEG 透明背景", func(opts *converter.ConvertOptions) {
+			opts.ImageFormat = "jpeg"
+			opts.Transparent = true
+		}, true},
+		{"未知代码样式", func(opts *converter.ConvertOptions) { opts.SnippetStyle = "no-such-style" }, true},
+		{"无效高亮行", func(opts *converter.ConvertOptions) { opts.SnippetHighlight = "3-1" }, true},
+		{"背景注入", func(opts *converter.ConvertOptions) { opts.SnippetBackground = "red</style><script>" }, true},
+		{"渐变背景", func(opts *converter.ConvertOptions) { opts.SnippetBackground = "linear-gradient(#000, #fff)" }, false},
+		{"本地 Logo", func(opts *converter.ConvertOptions) { opts.BrandLogo = "/etc/passwd" }, true},
+		{"非法水印图片", func(opts *converter.ConvertOptions) {
+			opts.WatermarkText = "x"
+			opts.WatermarkImage = "javascript:alert(1)"
+		}, true},
+		{"文档 PDF", func(opts *converter.ConvertOptions) { opts.ImageFormat = "pdf" }, true},
+		{"幻灯片 PDF", func(opts *converter.ConvertOptions) {
+			opts.Layout = converter.LayoutSlides
+			opts.ImageFormat = "pdf"
+		}, false},
+		{"Markdown 扩展", func(opts *converter.ConvertOptions) { opts.Extensions = []string{"footnote,-heading-ids"} }, false},
+		{"未知扩展", func(opts *converter.ConvertOptions) { opts.Extensions = []string{"mermaid"} }, true},
+		{"语言和文字方向", func(opts *converter.ConvertOptions) { opts.Lang = "ar-EG"; opts.Dir = "rtl" }, false},
+		{"无效语言标签", func(opts *converter.ConvertOptions) { opts.Lang = "not a lang" }, true},
+		{"无效文字方向", func(opts *converter.ConvertOptions) { opts.Dir = "vertical" }, true},
+		{"未知幻灯片比例", func(opts *converter.ConvertOptions) {
+			opts.Layout = converter.LayoutSlides
+			opts.SlideAspect = "21:9"
+		}, true},
+		{"章节截图", func(opts *converter.ConvertOptions) { opts.Section = "install" }, false},
+		{"同时指定选择器和章节", func(opts *converter.ConvertOptions) {
+			opts.Selector = "table"
+			opts.Section = "install"
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := converter.DefaultConvertOptions()
+			tt.modify(opts)
+			if got := validateConvertOptions(opts); (got != nil) != tt.wantErr {
+				t.Errorf("validateConvertOptions() = %v, wantErr %v", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTemplateName(t *testing.T) {
+	saved := documentTemplates
+	defer func() { documentTemplates = saved }()
+	documentTemplates = map[string]*template.Template{
+		"report": parser.DefaultDocumentTemplate,
+	}
+
+	if err := validateTemplateName(""); err != nil {
+		t.Errorf("validateTemplateName(\"\") = %v, want nil", err)
+	}
+	if err := validateTemplateName("report"); err != nil {
+		t.Errorf("validateTemplateName(report) = %v, want nil", err)
+	}
+	if err := validateTemplateName("missing"); err == nil || err.Code != "INVALID_TEMPLATE" {
+		t.Errorf("validateTemplateName(missing) = %v, want INVALID_TEMPLATE", err)
+	}
+
+	opts := buildConvertOptions(&ConvertRequest{Markdown: "# x", Template: "report"})
+	if opts.DocumentTemplate != parser.DefaultDocumentTemplate {
+		t.Error("buildConvertOptions() should resolve DocumentTemplate by name")
+	}
+}
+
+// TestConversionError 测试转换错误的状态码映射
+func TestConversionError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"元素不存在", fmt.Errorf("failed to render image: %w", fmt.Errorf("%w: #missing", renderer.ErrElementNotFound)), http.StatusBadRequest, "INVALID_OPTIONS"},
+		{"渲染失败", errors.New("browser crashed"), http.StatusInternalServerError, "CONVERSION_FAILED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, apiErr := conversionError(tt.err)
+			if status != tt.wantStatus || apiErr.Code != tt.wantCode {
+				t.Errorf("conversionError() = %d %s, want %d %s", status, apiErr.Code, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
+
+// TestLayoutBinding 测试 JSON 和表单请求的布局模式校验
+func TestLayoutBinding(t *testing.T) {
+	tests := []struct {
+		name    string
+		layout  string
+		wantErr bool
+	}{
+		{"默认布局", "", false},
+		{"社交卡片", "card", false},
+		{"代码片段", "snippet", false},
+		{"幻灯片", "slides", false},
+		{"未知布局", "poster", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req ConvertRequest
+			body := fmt.Sprintf(`{"markdown":"# Hi","layout":%q}`, tt.layout)
+			if err := binding.JSON.BindBody([]byte(body), &req); (err != nil) != tt.wantErr {
+				t.Errorf("JSON binding error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			upload := UploadRequest{Layout: tt.layout}
+			if err := binding.Validator.ValidateStruct(&upload); (err != nil) != tt.wantErr {
+				t.Errorf("form validation error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
 }
 
 // TestRequestParamsInterface 验证两个请求类型都实现了 RequestParams 接口
 func TestRequestParamsInterface(t *testing.T) {
 	var _ RequestParams = (*ConvertRequest)(nil)
 	var _ RequestParams = (*UploadRequest)(nil)
+	var _ RequestParams = (*RenderRequest)(nil)
 }
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/fonts"
+	"github.com/gin-gonic/gin"
+)
+
+// FontsHandler 返回已注册的字体族 (内置字体和 FONT_DIR 中加载的字体)
+//
+// 请求可以在 fontFamily 中按名称引用这些字体,渲染时以 @font-face 注入。
+func FontsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data: gin.H{
+			"fonts":       fonts.Default.Families(),
+			"cjkFallback": fonts.Default.CJKFamily(),
+		},
+	})
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/converter"
+	"github.com/gin-gonic/gin"
+)
+
+// activeConverters 进行中的转换使用的转换器 (关闭服务时用于取消未完成的转换)
+var activeConverters sync.Map // *trackedConverter → struct{}
+
+// trackedConverter 登记在 activeConverters 中的转换器,关闭时移除
+type trackedConverter struct {
+	converter.Converter
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// trackConverter 登记转换器,调用方结束转换后应调用 Close
+func trackConverter(conv converter.Converter) converter.Converter {
+	t := &trackedConverter{Converter: conv}
+	activeConverters.Store(t, struct{}{})
+	return t
+}
+
+// Close 关闭转换器 (可重复调用,只关闭一次)
+func (t *trackedConverter) Close() error {
+	t.closeOnce.Do(func() {
+		activeConverters.Delete(t)
+		t.closeErr = t.Converter.Close()
+	})
+	return t.closeErr
+}
+
+// CloseActiveConverters 关闭所有进行中的转换器 (中止 AI 调用并关闭浏览器,未完成的转换以错误结束)
+//
+// 用于优雅关闭超时后取消剩余的转换,返回关闭的转换器数量。
+func CloseActiveConverters() int {
+	n := 0
+	activeConverters.Range(func(key, _ any) bool {
+		_ = key.(*trackedConverter).Close()
+		n++
+		return true
+	})
+	return n
+}
+
+// ReadinessCheck 就绪检查项
+type ReadinessCheck struct {
+	Name  string                          // 名称 (如 browser, ai:gemini)
+	Check func(ctx context.Context) error // 检查函数,返回 nil 表示就绪
+}
+
+// CheckResult 就绪检查项的结果
+type CheckResult struct {
+	Status     string    `json:"status"`          // ok 或 error
+	Error      string    `json:"error,omitempty"` // 失败原因
+	DurationMs int64     `json:"durationMs"`      // 检查耗时 (毫秒)
+	CheckedAt  time.Time `json:"checkedAt"`       // 检查时间
+}
+
+// Readiness 服务就绪状态
+//
+// 检查结果缓存 interval,避免探针频繁启动浏览器;并发的探针共享同一次检查。
+type Readiness struct {
+	checks   []ReadinessCheck
+	interval time.Duration
+	timeout  time.Duration
+	now      func() time.Time
+	draining atomic.Bool
+
+	mu      sync.Mutex
+	results map[string]CheckResult
+	checked time.Time
+}
+
+// NewReadiness 创建就绪状态,检查结果缓存 interval,每次检查最长 timeout
+func NewReadiness(interval, timeout time.Duration, checks ...ReadinessCheck) *Readiness {
+	return &Readiness{
+		checks:   checks,
+		interval: interval,
+		timeout:  timeout,
+		now:      time.Now,
+	}
+}
+
+// SetDraining 标记服务正在关闭,之后总是未就绪 (负载均衡器停止转发新请求)
+func (r *Readiness) SetDraining() {
+	r.draining.Store(true)
+}
+
+// Draining 服务是否正在关闭
+func (r *Readiness) Draining() bool {
+	return r.draining.Load()
+}
+
+// Results 返回各检查项的结果和是否全部通过 (缓存过期时并发执行所有检查)
+func (r *Readiness) Results() (map[string]CheckResult, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.results == nil || r.now().Sub(r.checked) >= r.interval {
+		r.results = r.run()
+		r.checked = r.now()
+	}
+
+	ready := true
+	for _, result := range r.results {
+		if result.Status != "ok" {
+			ready = false
+		}
+	}
+	return r.results, ready
+}
+
+// run 并发执行所有检查 (调用方持有锁)
+func (r *Readiness) run() map[string]CheckResult {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	results := make(map[string]CheckResult, len(r.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range r.checks {
+		wg.Go(func() {
+			start := r.now()
+			err := check.Check(ctx)
+			result := CheckResult{Status: "ok", DurationMs: r.now().Sub(start).Milliseconds(), CheckedAt: start}
+			if err != nil {
+				result.Status, result.Error = "error", err.Error()
+			}
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+	return results
+}
+
+// LivezHandler 存活检查处理器 (进程能处理请求即存活,不检查依赖)
+func LivezHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    gin.H{"status": "alive"},
+	})
+}
+
+// ReadyzHandler 返回就绪检查处理器
+//
+// 浏览器和已配置的 AI 提供器都可用时返回 200,否则 (或服务正在关闭时) 返回 503 和各检查项的结果。
+func ReadyzHandler(r *Readiness) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if r.Draining() {
+			c.JSON(http.StatusServiceUnavailable, APIResponse{
+				Success: false,
+				Error:   &APIError{Code: "SHUTTING_DOWN", Message: "服务正在关闭", RequestID: requestID(c)},
+				Data:    gin.H{"status": "draining"},
+			})
+			return
+		}
+
+		results, ready := r.Results()
+		if !ready {
+			requestLogger(c).Warn("readiness check failed", "checks", results)
+			c.JSON(http.StatusServiceUnavailable, APIResponse{
+				Success: false,
+				Error:   &APIError{Code: "NOT_READY", Message: "依赖服务不可用", RequestID: requestID(c)},
+				Data:    gin.H{"status": "not_ready", "checks": results},
+			})
+			return
+		}
+		c.JSON(http.StatusOK, APIResponse{
+			Success: true,
+			Data:    gin.H{"status": "ready", "checks": results},
+		})
+	}
+}
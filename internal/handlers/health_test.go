@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/converter"
+)
+
+// TestReadiness 测试就绪检查的结果缓存、失败和关闭状态
+func TestReadiness(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	now := time.Unix(1700000000, 0)
+	calls := 0
+	var aiErr error
+	readiness := NewReadiness(30*time.Second, time.Second,
+		ReadinessCheck{Name: "browser", Check: func(ctx context.Context) error { calls++; return nil }},
+		ReadinessCheck{Name: "ai:ollama", Check: func(ctx context.Context) error { return aiErr }},
+	)
+	readiness.now = func() time.Time { return now }
+
+	router := gin.New()
+	router.GET("/livez", LivezHandler)
+	router.GET("/readyz", ReadyzHandler(readiness))
+
+	probe := func(path string) (int, APIResponse) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var resp APIResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return w.Code, resp
+	}
+
+	tests := []struct {
+		name      string
+		setup     func()
+		path      string
+		wantCode  int
+		wantError string
+		wantCalls int
+	}{
+		{"存活", func() {}, "/livez", http.StatusOK, "", 0},
+		{"全部就绪", func() {}, "/readyz", http.StatusOK, "", 1},
+		{"使用缓存结果", func() { now = now.Add(10 * time.Second) }, "/readyz", http.StatusOK, "", 1},
+		{"缓存过期后 AI 不可用", func() { now = now.Add(30 * time.Second); aiErr = errors.New("model not found") }, "/readyz", http.StatusServiceUnavailable, "NOT_READY", 2},
+		{"正在关闭", func() { aiErr = nil; readiness.SetDraining() }, "/readyz", http.StatusServiceUnavailable, "SHUTTING_DOWN", 2},
+		{"关闭时仍存活", func() {}, "/livez", http.StatusOK, "", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			code, resp := probe(tt.path)
+			if code != tt.wantCode {
+				t.Errorf("status = %d, want %d", code, tt.wantCode)
+			}
+			if tt.wantError != "" && (resp.Error == nil || resp.Error.Code != tt.wantError) {
+				t.Errorf("error = %+v, want %s", resp.Error, tt.wantError)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("browser checks = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// closeCounter 记录关闭次数的转换器
+type closeCounter struct {
+	converter.Converter
+	closed int
+}
+
+func (c *closeCounter) Close() error {
+	c.closed++
+	return nil
+}
+
+// TestCloseActiveConverters 测试关闭服务时取消进行中的转换
+func TestCloseActiveConverters(t *testing.T) {
+	first, second := &closeCounter{}, &closeCounter{}
+	done := trackConverter(first)
+	inFlight := trackConverter(second)
+
+	// 已完成的转换不再登记
+	_ = done.Close()
+	if n := CloseActiveConverters(); n != 1 {
+		t.Errorf("CloseActiveConverters() = %d, want 1", n)
+	}
+	// 处理器随后的 Close 不会重复关闭
+	_ = inFlight.Close()
+	if first.closed != 1 || second.closed != 1 {
+		t.Errorf("closed = %d, %d, want 1, 1", first.closed, second.closed)
+	}
+	if n := CloseActiveConverters(); n != 0 {
+		t.Errorf("CloseActiveConverters() after close = %d, want 0", n)
+	}
+}
@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Cshiyuan/Gomarkdown2image/internal/config"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/converter"
+	"github.com/gin-gonic/gin"
+)
+
+// tokenBucket 单个客户端的令牌桶
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	rate   float64
+	burst  int
+}
+
+// RateLimiter 按客户端 (API Key 或 IP) 的令牌桶限流器
+type RateLimiter struct {
+	rate  float64 // 每秒补充的令牌数
+	burst int     // 桶容量
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// NewRateLimiter 创建限流器,每个客户端每秒 rate 个请求,最多突发 burst 个
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		rate:    rate,
+		burst:   max(burst, 1),
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Allow 尝试为客户端取一个令牌,失败时返回需要等待的时间
+//
+// rate 或 burst 为 0 时使用限流器的默认值,默认速率也为 0 时不限流。
+func (l *RateLimiter) Allow(client string, rate float64, burst int) (bool, time.Duration) {
+	if rate <= 0 {
+		rate = l.rate
+	}
+	if rate <= 0 {
+		return true, 0
+	}
+	if burst <= 0 {
+		burst = l.burst
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &tokenBucket{tokens: float64(burst), last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last, b.rate, b.burst = now, rate, burst
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// sweep 每分钟清理一次已补满的令牌桶,避免客户端过多时内存增长 (调用方持有锁)
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for client, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*b.rate >= float64(b.burst) {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// RateLimit 限流中间件,超出限制时返回 429
+//
+// 认证后的请求按 API Key 限流 (可由策略的 rateLimit/rateBurst 覆盖),否则按客户端 IP。
+func RateLimit(l *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := "ip:" + c.ClientIP()
+		var rate float64
+		var burst int
+		if policy := keyPolicy(c); policy != nil {
+			client = "key:" + policy.Name
+			rate, burst = policy.RateLimit, policy.RateBurst
+		}
+
+		if ok, wait := l.Allow(client, rate, burst); !ok {
+			c.Header("Retry-After", retryAfter(wait))
+			respondError(c, http.StatusTooManyRequests, &APIError{
+				Code:    "RATE_LIMITED",
+				Message: "请求过于频繁",
+				Details: fmt.Sprintf("请在 %s 秒后重试", retryAfter(wait)),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// ConcurrencyLimiter 全局并发转换数限制,超出时在有界队列中等待
+type ConcurrencyLimiter struct {
+	slots   chan struct{}
+	queue   int
+	timeout time.Duration
+
+	waiting atomic.Int64
+}
+
+// NewConcurrencyLimiter 创建并发限制器
+//
+// 最多同时处理 maxConcurrent 个转换,最多 queue 个请求等待,每个请求最多等待 timeout。
+func NewConcurrencyLimiter(maxConcurrent, queue int, timeout time.Duration) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		slots:   make(chan struct{}, max(maxConcurrent, 1)),
+		queue:   max(queue, 0),
+		timeout: timeout,
+	}
+}
+
+// InFlight 返回正在处理的转换数
+func (l *ConcurrencyLimiter) InFlight() int {
+	return len(l.slots)
+}
+
+// Capacity 返回同时处理的转换数上限
+func (l *ConcurrencyLimiter) Capacity() int {
+	return cap(l.slots)
+}
+
+// Waiting 返回排队等待的请求数
+func (l *ConcurrencyLimiter) Waiting() int {
+	return int(l.waiting.Load())
+}
+
+// concurrencyContextKey gin.Context 中保存并发限制器的键
+const concurrencyContextKey = "concurrencyLimiter"
+
+// busyError 没有可用的转换名额 (等待队列已满或排队超时)
+type busyError struct {
+	message  string
+	capacity int
+}
+
+func (e *busyError) Error() string {
+	return e.message
+}
+
+// Acquire 占用一个转换名额,返回释放函数 (可重复调用)
+//
+// 名额已满时在队列中最多等待 timeout,队列已满或等待超时返回 *busyError,
+// ctx 结束 (客户端断开) 时返回 ctx.Err()。
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) (func(), error) {
+	var once sync.Once
+	release := func() { once.Do(func() { <-l.slots }) }
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	if l.waiting.Add(1) > int64(l.queue) {
+		l.waiting.Add(-1)
+		return nil, &busyError{message: "服务繁忙,等待队列已满", capacity: l.Capacity()}
+	}
+	defer l.waiting.Add(-1)
+
+	timer := time.NewTimer(l.timeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		return nil, &busyError{message: "服务繁忙,排队等待超时", capacity: l.Capacity()}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ConcurrencyLimit 并发限制中间件
+//
+// 只在请求上下文中登记限制器,转换实际需要启动浏览器时才占用名额 (见 newConverter),
+// 返回 304 或命中渲染缓存的请求不占用名额。没有可用名额时由处理器返回 503 (见 respondBusy)。
+func ConcurrencyLimit(l *ConcurrencyLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(concurrencyContextKey, l)
+		c.Next()
+	}
+}
+
+// slotConverter 占用转换名额的转换器,关闭时释放名额
+type slotConverter struct {
+	converter.Converter
+	release func()
+}
+
+// Close 关闭转换器并释放名额
+func (s *slotConverter) Close() error {
+	defer s.release()
+	return s.Converter.Close()
+}
+
+// newSlotConverter 占用转换名额后创建转换器
+//
+// 请求未经过 ConcurrencyLimit 中间件时不限制并发数。
+func newSlotConverter(c *gin.Context) (converter.Converter, error) {
+	l, ok := c.Value(concurrencyContextKey).(*ConcurrencyLimiter)
+	if !ok {
+		return converter.NewConverter()
+	}
+	release, err := l.Acquire(c.Request.Context())
+	if err != nil {
+		return nil, err
+	}
+	conv, err := converter.NewConverter()
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &slotConverter{Converter: conv, release: release}, nil
+}
+
+// respondBusy 没有可用的转换名额时返回 503 (带 Retry-After),返回是否已写入响应
+func respondBusy(c *gin.Context, err error) bool {
+	var busy *busyError
+	if !errors.As(err, &busy) {
+		return false
+	}
+	c.Header("Retry-After", retryAfter(config.BusyRetryAfter))
+	respondError(c, http.StatusServiceUnavailable, &APIError{
+		Code:    "SERVER_BUSY",
+		Message: busy.message,
+		Details: fmt.Sprintf("最多同时处理 %d 个转换,请稍后重试", busy.capacity),
+	})
+	return true
+}
+
+// statusClientClosedRequest 客户端在响应前断开连接 (nginx 使用的非标准状态码)
+const statusClientClosedRequest = 499
+
+// abortCanceled 客户端断开导致排队或转换中止时结束请求,返回是否已处理
+//
+// 客户端取消不是服务端错误:不写响应体,以 499 记录并输出 info 日志。
+func abortCanceled(c *gin.Context, err error) bool {
+	if !errors.Is(err, context.Canceled) || c.Request.Context().Err() == nil {
+		return false
+	}
+	requestLogger(c).InfoContext(c.Request.Context(), "request canceled by client", "error", err.Error())
+	c.AbortWithStatus(statusClientClosedRequest)
+	return true
+}
+
+// retryAfter 将等待时间格式化为 Retry-After 秒数 (向上取整,至少 1 秒)
+func retryAfter(wait time.Duration) string {
+	return strconv.Itoa(max(int(math.Ceil(wait.Seconds())), 1))
+}
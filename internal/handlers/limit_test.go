@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/cache"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/converter"
+)
+
+// TestRateLimit 测试令牌桶限流
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := NewRateLimiter(1, 2)
+	now := time.Unix(1700000000, 0)
+	limiter.now = func() time.Time { return now }
+
+	store, err := NewAPIKeyStore([]APIKeyPolicy{
+		{Name: "batch", Key: "batch-key", RateLimit: 10, RateBurst: 5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.GET("/ip", RateLimit(limiter), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	router.GET("/key", APIKeyAuth(store), RateLimit(limiter), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	request := func(path, ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		req.Header.Set("X-API-Key", "batch-key")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 突发 2 个后限流,其他 IP 不受影响
+	for i := range 2 {
+		if w := request("/ip", "10.0.0.1"); w.Code != http.StatusNoContent {
+			t.Fatalf("request %d status = %d", i+1, w.Code)
+		}
+	}
+	w := request("/ip", "10.0.0.1")
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), `"code":"RATE_LIMITED"`) {
+		t.Fatalf("status = %d, body = %s, want 429 RATE_LIMITED", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+	if w := request("/ip", "10.0.0.2"); w.Code != http.StatusNoContent {
+		t.Errorf("other IP status = %d, want 204", w.Code)
+	}
+
+	// 令牌按速率补充
+	now = now.Add(time.Second)
+	if w := request("/ip", "10.0.0.1"); w.Code != http.StatusNoContent {
+		t.Errorf("status after refill = %d, want 204", w.Code)
+	}
+
+	// API Key 按策略的限制计数,与 IP 无关
+	for i := range 5 {
+		if w := request("/key", fmt.Sprintf("10.0.1.%d", i)); w.Code != http.StatusNoContent {
+			t.Fatalf("key request %d status = %d", i+1, w.Code)
+		}
+	}
+	if w := request("/key", "10.0.1.9"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("key status = %d, want 429", w.Code)
+	}
+
+	// 默认速率为 0 时只限制单独设置了速率的 API Key
+	if !store.HasRateLimits() {
+		t.Error("HasRateLimits() = false, want true")
+	}
+	limiter = NewRateLimiter(0, 2)
+	limiter.now = func() time.Time { return now }
+	router = gin.New()
+	router.GET("/ip", RateLimit(limiter), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	router.GET("/key", APIKeyAuth(store), RateLimit(limiter), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	for i := range 20 {
+		if w := request("/ip", "10.0.2.1"); w.Code != http.StatusNoContent {
+			t.Fatalf("unlimited request %d status = %d", i+1, w.Code)
+		}
+	}
+	for range 5 {
+		request("/key", "10.0.2.1")
+	}
+	if w := request("/key", "10.0.2.1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("key status without global limit = %d, want 429", w.Code)
+	}
+}
+
+// TestConcurrencyLimit 测试并发转换数限制和等待队列
+func TestConcurrencyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := NewConcurrencyLimiter(1, 1, 50*time.Millisecond)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	router := gin.New()
+	router.GET("/slow", ConcurrencyLimit(limiter), func(c *gin.Context) {
+		done, err := c.MustGet(concurrencyContextKey).(*ConcurrencyLimiter).Acquire(c.Request.Context())
+		if err != nil {
+			respondBusy(c, err)
+			return
+		}
+		defer done()
+		started <- struct{}{}
+		<-release
+		c.Status(http.StatusNoContent)
+	})
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+		return w
+	}
+
+	// 占用唯一的转换槽
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- serve() }()
+	<-started
+
+	// 第二个请求排队,第三个请求队列已满
+	second := make(chan *httptest.ResponseRecorder)
+	go func() { second <- serve() }()
+	for limiter.Waiting() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	w := serve()
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), `"code":"SERVER_BUSY"`) {
+		t.Fatalf("queue full: status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("queue full: missing Retry-After")
+	}
+
+	// 排队的请求等待超时
+	if w := <-second; w.Code != http.StatusServiceUnavailable {
+		t.Errorf("queued request status = %d, want 503", w.Code)
+	}
+
+	close(release)
+	if w := <-first; w.Code != http.StatusNoContent {
+		t.Errorf("first request status = %d, want 204", w.Code)
+	}
+	if limiter.InFlight() != 0 || limiter.Waiting() != 0 {
+		t.Errorf("InFlight = %d, Waiting = %d, want 0", limiter.InFlight(), limiter.Waiting())
+	}
+
+	// 释放后可以继续处理
+	go func() { <-started }()
+	if w := serve(); w.Code != http.StatusNoContent {
+		t.Errorf("status after release = %d, want 204", w.Code)
+	}
+}
+
+// TestConcurrencyLimitRender 测试只有实际渲染的请求占用转换名额
+func TestConcurrencyLimitRender(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	SetRenderCache(cache.NewMemory(1 << 20))
+	defer SetRenderCache(nil)
+
+	// 唯一的名额已被占用,且不允许排队
+	limiter := NewConcurrencyLimiter(1, 0, time.Millisecond)
+	done, err := limiter.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done()
+
+	router := gin.New()
+	router.GET("/api/render", ConcurrencyLimit(limiter), RenderHandler)
+
+	opts := converter.DefaultConvertOptions()
+	key, err := converter.CacheKey(converter.CacheKindResult, []byte("# Cached"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cached bytes.Buffer
+	if err := gob.NewEncoder(&cached).Encode(&converter.ConvertResult{Data: []byte("cached-image")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := renderCache.Set(key, cached.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	helloTag, err := renderETag([]byte("# Hello"), converter.DefaultConvertOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		markdown    string
+		ifNoneMatch string
+		wantStatus  int
+		wantBody    string
+	}{
+		{"ETag 匹配", "# Hello", helloTag, http.StatusNotModified, ""},
+		{"命中渲染缓存", "# Cached", "", http.StatusOK, "cached-image"},
+		{"需要渲染", "# Hello", "", http.StatusServiceUnavailable, `"code":"SERVER_BUSY"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/render?"+url.Values{"markdown": {tt.markdown}}.Encode(), nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want %s", w.Body.String(), tt.wantBody)
+			}
+			if tt.wantStatus == http.StatusServiceUnavailable && w.Header().Get("Retry-After") == "" {
+				t.Error("missing Retry-After")
+			}
+		})
+	}
+	if limiter.InFlight() != 1 || limiter.Waiting() != 0 {
+		t.Errorf("InFlight = %d, Waiting = %d, want 1, 0", limiter.InFlight(), limiter.Waiting())
+	}
+}
+
+// TestConcurrencyLimitCanceled 测试排队时客户端断开不按服务端错误处理
+func TestConcurrencyLimitCanceled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store, err := NewAPIKeyStore([]APIKeyPolicy{{Name: "docs", Key: "docs-key"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		renderCache cache.Cache
+	}{
+		// 不使用渲染缓存时在创建转换器时排队,使用时在转换中排队
+		{"未启用渲染缓存", nil},
+		{"启用渲染缓存", cache.NewMemory(1 << 20)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetRenderCache(tt.renderCache)
+			defer SetRenderCache(nil)
+
+			// 唯一的名额已被占用,请求进入等待队列
+			limiter := NewConcurrencyLimiter(1, 1, 5*time.Second)
+			done, err := limiter.Acquire(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer done()
+
+			router := gin.New()
+			router.GET("/api/render", APIKeyAuth(store), ConcurrencyLimit(limiter), RenderHandler)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			req := httptest.NewRequest(http.MethodGet, "/api/render?"+url.Values{"markdown": {"# " + tt.name}}.Encode(), nil).WithContext(ctx)
+			req.Header.Set("X-API-Key", "docs-key")
+			result := make(chan *httptest.ResponseRecorder)
+			go func() {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				result <- w
+			}()
+			for limiter.Waiting() != 1 {
+				time.Sleep(time.Millisecond)
+			}
+			cancel()
+
+			w := <-result
+			if w.Code != statusClientClosedRequest || w.Body.Len() != 0 {
+				t.Errorf("status = %d, body = %s, want 499 without body", w.Code, w.Body.String())
+			}
+			if limiter.InFlight() != 1 || limiter.Waiting() != 0 {
+				t.Errorf("InFlight = %d, Waiting = %d, want 1, 0", limiter.InFlight(), limiter.Waiting())
+			}
+		})
+	}
+	if usage := store.Usage("docs"); usage.Requests != 2 || usage.Errors != 0 {
+		t.Errorf("usage = %+v, want 2 requests without errors", usage)
+	}
+}
@@ -1,69 +1,208 @@
 package handlers
 
 import (
-	"os"
-	"strconv"
+	"crypto/rand"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
 	"strings"
 	"time"
 
+	"github.com/Cshiyuan/Gomarkdown2image/internal/metrics"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// SetupCORS 配置 CORS 中间件
-func SetupCORS() gin.HandlerFunc {
-	// 从环境变量读取允许的域名
-	allowedOrigins := os.Getenv("ALLOWED_ORIGINS")
-	if allowedOrigins == "" {
-		// 默认值：开发环境
-		allowedOrigins = "http://localhost:3000,http://localhost:8080"
-	}
-
-	origins := strings.Split(allowedOrigins, ",")
-	for i, origin := range origins {
-		origins[i] = strings.TrimSpace(origin)
-	}
-
+// SetupCORS 配置 CORS 中间件,origins 为允许的源 ("*" 表示全部)
+func SetupCORS(origins []string) gin.HandlerFunc {
 	return cors.New(cors.Config{
 		AllowOrigins:     origins,
 		AllowMethods:     []string{"GET", "POST", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Content-Length", "Accept-Encoding"},
-		ExposeHeaders:    []string{"Content-Length", "Content-Type"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Content-Length", "Accept-Encoding", "Authorization", "X-API-Key", RequestIDHeader, "traceparent", "tracestate"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Type", "ETag", "Retry-After", RequestIDHeader},
 		AllowCredentials: false, // 除非必要，否则关闭
 		MaxAge:           12 * time.Hour,
 	})
 }
 
-// RequestLogger 自定义请求日志中间件
+// RequestIDHeader 请求 ID 请求头和响应头
+const RequestIDHeader = "X-Request-ID"
+
+// gin.Context 中保存请求 ID 和日志记录器的键
+const (
+	requestIDContextKey = "requestID"
+	loggerContextKey    = "logger"
+)
+
+// maxRequestIDLength 客户端提供的请求 ID 的最大长度
+const maxRequestIDLength = 128
+
+// RequestID 请求 ID 中间件
+//
+// 使用客户端提供的 X-Request-ID (仅限字母、数字和 -_.:,最长 128 字符),否则生成新的 ID。
+// ID 写入响应头、错误响应体和本次请求的所有日志 (request_id 字段)。
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = rand.Text()
+		}
+		c.Set(requestIDContextKey, id)
+		c.Set(loggerContextKey, slog.Default().With("request_id", id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// validRequestID 检查客户端提供的请求 ID 是否可以安全地写入日志和响应头
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// requestID 返回当前请求的 ID (未使用 RequestID 中间件时为空)
+func requestID(c *gin.Context) string {
+	return c.GetString(requestIDContextKey)
+}
+
+// requestLogger 返回当前请求的日志记录器 (带 request_id 字段)
+func requestLogger(c *gin.Context) *slog.Logger {
+	if logger, ok := c.Get(loggerContextKey); ok {
+		return logger.(*slog.Logger)
+	}
+	return slog.Default()
+}
+
+// respondError 写入错误响应 (包含请求 ID) 并记录日志,中止后续处理
+func respondError(c *gin.Context, status int, apiErr *APIError) {
+	apiErr.RequestID = requestID(c)
+	trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("error.code", apiErr.Code))
+
+	level := slog.LevelWarn
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	requestLogger(c).Log(c.Request.Context(), level, "request failed",
+		"status", status, "code", apiErr.Code, "message", apiErr.Message, "details", apiErr.Details)
+
+	c.AbortWithStatusJSON(status, APIResponse{
+		Success: false,
+		Error:   apiErr,
+	})
+}
+
+// Tracing 链路追踪中间件,为每个请求创建服务端 span
+//
+// 从 traceparent 请求头继续上游链路,span 写入请求 context (转换和 AI 调用的 span 以其为父级);
+// 应在 RequestID 之后使用,请求日志会带有 trace_id 字段。
+func Tracing() gin.HandlerFunc {
+	tracer := otel.Tracer("github.com/Cshiyuan/Gomarkdown2image/internal/handlers")
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		name := c.Request.Method
+		if route != "" {
+			name += " " + route
+		}
+		ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			attribute.String("http.request.method", c.Request.Method),
+			attribute.String("http.route", route),
+			attribute.String("url.path", c.Request.URL.Path),
+			attribute.String("client.address", c.ClientIP()),
+			attribute.String("user_agent.original", c.Request.UserAgent()),
+			attribute.String("request.id", requestID(c)),
+		))
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		if sc := span.SpanContext(); sc.IsValid() {
+			c.Set(loggerContextKey, requestLogger(c).With("trace_id", sc.TraceID().String()))
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// RequestLogger 请求日志中间件,每个请求结束后输出一条结构化日志
 func RequestLogger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
-		path := c.Request.URL.Path
-		method := c.Request.Method
 
 		// 处理请求
 		c.Next()
 
-		// 记录日志
-		latency := time.Since(start)
-		statusCode := c.Writer.Status()
-		clientIP := c.ClientIP()
-
-		// 使用 Gin 的日志格式 (修复: 使用 strconv.Itoa 而非 string(rune()))
-		_, _ = gin.DefaultWriter.Write([]byte(
-			time.Now().Format("2006/01/02 - 15:04:05") +
-				" | " + method +
-				" | " + path +
-				" | " + clientIP +
-				" | " + latency.String() +
-				" | " + strconv.Itoa(statusCode) + "\n",
-		))
+		attrs := []any{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"route", c.FullPath(),
+			"status", c.Writer.Status(),
+			"duration_ms", time.Since(start).Milliseconds(),
+			"bytes", max(c.Writer.Size(), 0),
+			"client_ip", c.ClientIP(),
+		}
+		if policy := keyPolicy(c); policy != nil {
+			attrs = append(attrs, "api_key", policy.Name)
+		}
+		requestLogger(c).Info("request", attrs...)
+	}
+}
+
+// Metrics 请求指标中间件,记录请求数、耗时和转换输出大小
+//
+// 路由按注册的模板记录 (如 /api/render),未匹配的请求记为 "unmatched"。
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := c.Writer.Status()
+		m.ObserveRequest(route, c.Request.Method, status, time.Since(start))
+
+		// 成功的非 JSON 响应为转换输出 (图片、PDF 或 zip)
+		contentType := c.Writer.Header().Get("Content-Type")
+		if status == http.StatusOK && contentType != "" && !strings.HasPrefix(contentType, "application/json") {
+			_, format, _ := strings.Cut(contentType, "/")
+			m.ObserveOutput(format, c.Writer.Size())
+		}
 	}
 }
 
-// ErrorRecovery 错误恢复中间件
+// ErrorRecovery 错误恢复中间件,记录 panic 并返回 500
 func ErrorRecovery() gin.HandlerFunc {
-	return gin.Recovery()
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		requestLogger(c).Error("panic recovered", "error", err, "stack", string(debug.Stack()))
+		respondError(c, http.StatusInternalServerError, &APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: "服务内部错误",
+		})
+	})
 }
 
 // HealthCheckHandler 健康检查处理器
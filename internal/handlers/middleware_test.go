@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cshiyuan/Gomarkdown2image/internal/metrics"
+	"github.com/Cshiyuan/Gomarkdown2image/internal/tracing"
+)
+
+// TestMetricsMiddleware 测试请求指标中间件
+func TestMetricsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	m := metrics.New()
+	router := gin.New()
+	router.Use(Metrics(m))
+	router.GET("/image/:name", func(c *gin.Context) { c.Data(http.StatusOK, "image/png", make([]byte, 100)) })
+	router.GET("/json", func(c *gin.Context) { c.JSON(http.StatusOK, APIResponse{Success: true}) })
+	router.GET("/metrics", gin.WrapH(m.Handler()))
+
+	for _, path := range []string{"/image/a", "/image/b", "/json", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		`markdown2image_http_requests_total{method="GET",route="/image/:name",status="200"} 2`,
+		`markdown2image_http_requests_total{method="GET",route="/json",status="200"} 1`,
+		`markdown2image_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`markdown2image_conversion_output_bytes_count{format="png"} 2`,
+		`markdown2image_conversion_output_bytes_sum{format="png"} 200`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics missing %q", want)
+		}
+	}
+	if strings.Contains(body, `format="json"`) {
+		t.Error("JSON 响应不应记录为转换输出")
+	}
+}
+
+// TestRequestID 测试请求 ID 的传递、响应头、错误响应体和结构化日志
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+
+	router := gin.New()
+	router.Use(RequestID(), RequestLogger())
+	router.GET("/ok", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	router.GET("/fail", func(c *gin.Context) {
+		respondError(c, http.StatusBadRequest, &APIError{Code: "INVALID_REQUEST", Message: "bad"})
+	})
+
+	tests := []struct {
+		name     string
+		path     string
+		incoming string
+		wantSame bool
+	}{
+		{"沿用客户端 ID", "/ok", "trace-123.abc:1", true},
+		{"生成新 ID", "/ok", "", false},
+		{"拒绝非法字符", "/ok", "bad id\n", false},
+		{"拒绝过长 ID", "/ok", strings.Repeat("a", maxRequestIDLength+1), false},
+		{"错误响应包含 ID", "/fail", "fail-1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			router.ServeHTTP(w, req)
+
+			id := w.Header().Get(RequestIDHeader)
+			if id == "" {
+				t.Fatal("missing X-Request-ID response header")
+			}
+			if (id == tt.incoming) != tt.wantSame {
+				t.Errorf("X-Request-ID = %q, incoming %q, want same = %v", id, tt.incoming, tt.wantSame)
+			}
+
+			if tt.path == "/fail" {
+				var resp APIResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatal(err)
+				}
+				if resp.Error == nil || resp.Error.RequestID != id {
+					t.Errorf("error body = %s, want requestId %q", w.Body.String(), id)
+				}
+			}
+
+			// 每条日志都带有请求 ID
+			lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+			for _, line := range lines {
+				var entry map[string]any
+				if err := json.Unmarshal([]byte(line), &entry); err != nil {
+					t.Fatalf("log line is not JSON: %s", line)
+				}
+				if entry["request_id"] != id {
+					t.Errorf("log line request_id = %v, want %q: %s", entry["request_id"], id, line)
+				}
+			}
+			if last := lines[len(lines)-1]; !strings.Contains(last, `"msg":"request"`) || !strings.Contains(last, `"path":"`+tt.path+`"`) {
+				t.Errorf("last log line = %s, want request log", last)
+			}
+		})
+	}
+}
+
+// TestTracing 测试服务端 span、traceparent 传播和请求 context
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(tracing.NewProvider(sdktrace.NewSimpleSpanProcessor(exporter), nil))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var handlerSpan trace.SpanContext
+	router := gin.New()
+	router.Use(RequestID(), Tracing())
+	router.GET("/api/render", func(c *gin.Context) {
+		handlerSpan = trace.SpanContextFromContext(c.Request.Context())
+		respondError(c, http.StatusInternalServerError, &APIError{Code: "CONVERSION_FAILED", Message: "failed"})
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const parentID = "00f067aa0ba902b7"
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/render?markdown=x", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+	router.ServeHTTP(w, req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "GET /api/render" {
+		t.Errorf("span name = %q", span.Name)
+	}
+	if span.SpanContext.TraceID().String() != traceID || span.Parent.SpanID().String() != parentID {
+		t.Errorf("span does not continue the incoming trace: trace %s parent %s", span.SpanContext.TraceID(), span.Parent.SpanID())
+	}
+	if handlerSpan.SpanID() != span.SpanContext.SpanID() {
+		t.Error("request context does not carry the server span")
+	}
+	if span.Status.Code != codes.Error {
+		t.Errorf("status = %v, want Error", span.Status.Code)
+	}
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, attr := range span.Attributes {
+		attrs[attr.Key] = attr.Value
+	}
+	if got := attrs["http.response.status_code"].AsInt64(); got != http.StatusInternalServerError {
+		t.Errorf("http.response.status_code = %d", got)
+	}
+	if got := attrs["error.code"].AsString(); got != "CONVERSION_FAILED" {
+		t.Errorf("error.code = %q", got)
+	}
+	if got := attrs["request.id"].AsString(); got != w.Header().Get(RequestIDHeader) {
+		t.Errorf("request.id = %q, want %q", got, w.Header().Get(RequestIDHeader))
+	}
+}
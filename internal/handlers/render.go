@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Cshiyuan/Gomarkdown2image/internal/config"
+	"github.com/Cshiyuan/Gomarkdown2image/internal/signing"
+	"github.com/Cshiyuan/Gomarkdown2image/internal/utils"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/converter"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/renderer"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// renderPath GET 渲染端点的路径 (签名覆盖的路径)
+const renderPath = "/api/render"
+
+// renderSigner 渲染 URL 签名器 (启动时配置,为 nil 时不要求签名)
+var renderSigner *signing.Signer
+
+// SetRenderSigner 启用渲染 URL 签名,GET /api/render 将拒绝未签名或已过期的请求
+//
+// 应在启动服务之前调用。
+func SetRenderSigner(s *signing.Signer) {
+	renderSigner = s
+}
+
+// contentRoot 服务端文档目录 (启动时配置,GET /api/render 通过 doc 参数引用)
+var contentRoot *os.Root
+
+// SetContentDir 设置 GET /api/render 可引用的文档目录
+//
+// 应在启动服务之前调用。文档通过相对路径引用,无法访问目录之外的文件。
+func SetContentDir(dir string) error {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open content directory: %w", err)
+	}
+	contentRoot = root
+	return nil
+}
+
+// RenderHandler 通过 GET 请求渲染 Markdown,可直接用于 <img src>
+// @Summary 渲染 Markdown 为图片 (GET)
+// @Description 通过查询参数传入 Markdown 和转换选项,返回带 ETag 和 Cache-Control 的图片
+// @Produce image/png,image/jpeg,image/webp
+// @Param markdown query string false "Markdown 原文"
+// @Param markdownBase64 query string false "URL 安全的 Base64 编码 Markdown"
+// @Param doc query string false "CONTENT_DIR 中的文档路径 (可省略 .md 扩展名)"
+// @Success 200 {file} binary "生成的图片"
+// @Success 304 "内容未变化"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 403 {object} APIResponse "签名无效或已过期 (启用签名时)"
+// @Failure 404 {object} APIResponse "文档不存在"
+// @Failure 500 {object} APIResponse "服务器内部错误"
+// @Router /api/render [get]
+func RenderHandler(c *gin.Context) {
+	query := c.Request.URL.Query()
+
+	// 启用签名时拒绝未签名、签名无效或已过期的请求
+	if renderSigner != nil {
+		if err := renderSigner.Verify(renderPath, query, time.Now()); err != nil {
+			respondError(c, http.StatusForbidden, signatureError(err))
+			return
+		}
+	}
+
+	req, markdown, opts, status, apiErr := parseRenderQuery(query)
+	if apiErr != nil {
+		respondError(c, status, apiErr)
+		return
+	}
+	if status, apiErr := checkKeyPolicy(c, opts, len(markdown)); apiErr != nil {
+		respondError(c, status, apiErr)
+		return
+	}
+
+	// ETag 由内容哈希计算,内容未变化时无需渲染
+	etag, err := renderETag(markdown, opts)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, &APIError{
+			Code:    "CONVERSION_FAILED",
+			Message: "Markdown 转换失败",
+			Details: err.Error(),
+		})
+		return
+	}
+	maxAge := int64(config.RenderMaxAge)
+	if renderSigner != nil {
+		// 缓存时间不超过签名的有效期
+		expires, _ := signing.Expires(query)
+		maxAge = min(maxAge, max(int64(time.Until(expires).Seconds()), 0))
+	}
+	cacheControl := fmt.Sprintf("public, max-age=%d", maxAge)
+	if req.Doc != "" {
+		// 引用的文档可能被修改,每次使用 ETag 重新验证
+		cacheControl = "public, no-cache"
+	}
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Header("ETag", etag)
+		c.Header("Cache-Control", cacheControl)
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	// 创建转换器
+	conv, err := newConverter(c)
+	if err != nil {
+		if respondBusy(c, err) || abortCanceled(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, &APIError{
+			Code:    "CONVERTER_INIT_FAILED",
+			Message: "转换器初始化失败",
+			Details: err.Error(),
+		})
+		return
+	}
+	defer conv.Close()
+
+	opts.Logger = requestLogger(c)
+	opts.Context = c.Request.Context()
+	data, contentType, err := runConversion(conv, markdown, opts)
+	if err != nil {
+		if respondBusy(c, err) || abortCanceled(c, err) {
+			return
+		}
+		status, apiErr := conversionError(err)
+		respondError(c, status, apiErr)
+		return
+	}
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", cacheControl)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// parseRenderQuery 解析并验证 GET /api/render 的查询参数,失败时返回 HTTP 状态码和错误
+func parseRenderQuery(query url.Values) (*RenderRequest, []byte, *converter.ConvertOptions, int, *APIError) {
+	invalid := func(code, message string, err error) (*RenderRequest, []byte, *converter.ConvertOptions, int, *APIError) {
+		return nil, nil, nil, http.StatusBadRequest, &APIError{Code: code, Message: message, Details: err.Error()}
+	}
+
+	// 绑定并验证查询参数
+	var req RenderRequest
+	if err := binding.MapFormWithTag(&req, query, "form"); err != nil {
+		return invalid("INVALID_REQUEST", "请求参数验证失败", err)
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return invalid("INVALID_REQUEST", "请求参数验证失败", err)
+	}
+
+	// 读取 Markdown 内容
+	markdown, status, apiErr := renderMarkdown(&req)
+	if apiErr != nil {
+		return nil, nil, nil, status, apiErr
+	}
+
+	// 验证自定义 CSS (防止 XSS 注入)
+	if err := utils.ValidateCustomCSS(req.CustomCSS); err != nil {
+		return invalid("INVALID_CUSTOM_CSS", "自定义 CSS 验证失败", err)
+	}
+
+	// 验证文档模板名称
+	if apiErr := validateTemplateName(req.Template); apiErr != nil {
+		return nil, nil, nil, http.StatusBadRequest, apiErr
+	}
+
+	// 构建并验证转换选项
+	opts := buildConvertOptionsFromParams(&req)
+	if apiErr := validateConvertOptions(opts); apiErr != nil {
+		return nil, nil, nil, http.StatusBadRequest, apiErr
+	}
+	if status, apiErr := applyAIProfile(&req, opts); apiErr != nil {
+		return nil, nil, nil, status, apiErr
+	}
+	return &req, markdown, opts, http.StatusOK, nil
+}
+
+// renderMarkdown 从查询参数读取 Markdown 内容,失败时返回 HTTP 状态码和错误
+func renderMarkdown(req *RenderRequest) ([]byte, int, *APIError) {
+	sources := 0
+	for _, v := range []string{req.Markdown, req.MarkdownBase64, req.Doc} {
+		if v != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return nil, http.StatusBadRequest, &APIError{
+			Code:    "INVALID_REQUEST",
+			Message: "请求参数验证失败",
+			Details: "markdown、markdownBase64 和 doc 必须且只能提供一个",
+		}
+	}
+
+	var markdown []byte
+	switch {
+	case req.Markdown != "":
+		markdown = []byte(req.Markdown)
+	case req.MarkdownBase64 != "":
+		data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(req.MarkdownBase64, "="))
+		if err != nil {
+			return nil, http.StatusBadRequest, &APIError{
+				Code:    "INVALID_REQUEST",
+				Message: "请求参数验证失败",
+				Details: fmt.Sprintf("markdownBase64 不是有效的 URL 安全 Base64: %v", err),
+			}
+		}
+		markdown = data
+	default:
+		data, err := readContentDoc(req.Doc)
+		if err != nil {
+			return nil, http.StatusNotFound, &APIError{
+				Code:    "DOCUMENT_NOT_FOUND",
+				Message: "文档不存在",
+				Details: err.Error(),
+			}
+		}
+		markdown = data
+	}
+
+	if int64(len(markdown)) > maxMarkdownSize {
+		return nil, http.StatusBadRequest, &APIError{
+			Code:    "CONTENT_TOO_LARGE",
+			Message: "Markdown 内容过大",
+			Details: sizeLimitDetails(maxMarkdownSize),
+		}
+	}
+	return markdown, http.StatusOK, nil
+}
+
+// readContentDoc 读取文档目录中的文档 (省略扩展名时补全 .md)
+func readContentDoc(name string) ([]byte, error) {
+	if contentRoot == nil {
+		return nil, fmt.Errorf("document references are disabled (CONTENT_DIR not configured)")
+	}
+	if path.Ext(name) == "" {
+		name += ".md"
+	}
+
+	info, err := contentRoot.Stat(name)
+	if err != nil || info.IsDir() {
+		return nil, fmt.Errorf("document not found: %s", name)
+	}
+	if info.Size() > maxMarkdownSize {
+		return nil, fmt.Errorf("document too large: %s", name)
+	}
+	data, err := contentRoot.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("document not found: %s", name)
+	}
+	return data, err
+}
+
+// renderETag 计算渲染结果的强 ETag (与渲染缓存使用相同的内容哈希)
+func renderETag(markdown []byte, opts *converter.ConvertOptions) (string, error) {
+	kind := converter.CacheKindResult
+	if opts.Layout == converter.LayoutSlides && opts.ImageFormat != renderer.FormatPDF {
+		kind = converter.CacheKindSlides
+	}
+	key, err := converter.CacheKey(kind, markdown, opts)
+	if err != nil {
+		return "", err
+	}
+	return `"` + key + `"`, nil
+}
+
+// etagMatches 检查 If-None-Match 请求头是否匹配 ETag (弱比较)
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// signatureError 将签名验证错误转换为 API 错误
+func signatureError(err error) *APIError {
+	switch {
+	case errors.Is(err, signing.ErrMissingSignature):
+		return &APIError{
+			Code:    "SIGNATURE_REQUIRED",
+			Message: "渲染 URL 需要签名",
+			Details: "请使用 POST /api/render/sign 或 markdown2image sign 生成签名 URL",
+		}
+	case errors.Is(err, signing.ErrExpired):
+		return &APIError{
+			Code:    "SIGNATURE_EXPIRED",
+			Message: "渲染 URL 已过期",
+			Details: err.Error(),
+		}
+	default:
+		return &APIError{
+			Code:    "INVALID_SIGNATURE",
+			Message: "渲染 URL 签名无效",
+			Details: err.Error(),
+		}
+	}
+}
+
+// SignRenderHandler 为 GET /api/render 的查询参数生成签名 URL
+// @Summary 生成签名渲染 URL
+// @Description 验证内容引用和转换选项,返回带过期时间和 HMAC 签名的 GET /api/render 地址
+// @Accept json
+// @Produce json
+// @Param request body SignRenderRequest true "签名请求"
+// @Success 200 {object} APIResponse "签名 URL"
+// @Failure 400 {object} APIResponse "请求参数错误或未启用签名"
+// @Router /api/render/sign [post]
+func SignRenderHandler(c *gin.Context) {
+	if renderSigner == nil {
+		respondError(c, http.StatusBadRequest, &APIError{
+			Code:    "SIGNING_DISABLED",
+			Message: "未启用渲染 URL 签名",
+			Details: "请设置环境变量 RENDER_SIGNING_KEY",
+		})
+		return
+	}
+
+	var req SignRenderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, &APIError{
+			Code:    "INVALID_REQUEST",
+			Message: "请求参数验证失败",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	// 只为可以成功渲染的参数签名
+	query := make(url.Values, len(req.Params))
+	for k, v := range req.Params {
+		query.Set(k, v)
+	}
+	_, markdown, opts, status, apiErr := parseRenderQuery(query)
+	if apiErr == nil {
+		// 签名 URL 不再要求 API Key,签发时检查签发者的策略
+		status, apiErr = checkKeyPolicy(c, opts, len(markdown))
+	}
+	if apiErr != nil {
+		respondError(c, status, apiErr)
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresIn) * time.Second
+	if ttl == 0 {
+		ttl = config.DefaultSignedURLTTL
+	}
+	expires := time.Now().Add(ttl)
+	signed := renderSigner.Sign(renderPath, query, expires)
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data: gin.H{
+			"url":       renderPath + "?" + signed.Encode(),
+			"expiresAt": expires.Unix(),
+		},
+	})
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Cshiyuan/Gomarkdown2image/internal/signing"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/converter"
+)
+
+// TestRenderHandler 测试 GET /api/render 的参数校验和 304 响应 (不启动浏览器)
+func TestRenderHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "guide.md"), []byte("# Guide"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetContentDir(dir); err != nil {
+		t.Fatalf("SetContentDir() error = %v", err)
+	}
+	defer func() { contentRoot = nil }()
+
+	etag := func(markdown string, modify func(opts *converter.ConvertOptions)) string {
+		opts := converter.DefaultConvertOptions()
+		modify(opts)
+		tag, err := renderETag([]byte(markdown), opts)
+		if err != nil {
+			t.Fatalf("renderETag() error = %v", err)
+		}
+		return tag
+	}
+	helloTag := etag("# Hello", func(opts *converter.ConvertOptions) {})
+
+	tests := []struct {
+		name        string
+		query       url.Values
+		ifNoneMatch string
+		wantStatus  int
+		wantCode    string
+		wantETag    string
+		wantCache   string
+	}{
+		{"缺少 Markdown", url.Values{}, "", http.StatusBadRequest, "INVALID_REQUEST", "", ""},
+		{"多个内容来源", url.Values{"markdown": {"# A"}, "doc": {"guide"}}, "", http.StatusBadRequest, "INVALID_REQUEST", "", ""},
+		{"无效 Base64", url.Values{"markdownBase64": {"%%%"}}, "", http.StatusBadRequest, "INVALID_REQUEST", "", ""},
+		{"无效主题", url.Values{"markdown": {"# A"}, "theme": {"neon"}}, "", http.StatusBadRequest, "INVALID_REQUEST", "", ""},
+		{"文档不存在", url.Values{"doc": {"missing"}}, "", http.StatusNotFound, "DOCUMENT_NOT_FOUND", "", ""},
+		{"目录之外的文档", url.Values{"doc": {"../secret.md"}}, "", http.StatusNotFound, "DOCUMENT_NOT_FOUND", "", ""},
+		{"ETag 匹配", url.Values{"markdown": {"# Hello"}}, helloTag, http.StatusNotModified, "", helloTag, "public, max-age=604800"},
+		{"Base64 内容 ETag 匹配", url.Values{"markdownBase64": {base64.RawURLEncoding.EncodeToString([]byte("# Hello"))}},
+			`W/"other", ` + helloTag, http.StatusNotModified, "", helloTag, "public, max-age=604800"},
+		{"文档 ETag 匹配", url.Values{"doc": {"guide"}, "theme": {"dark"}},
+			etag("# Guide", func(opts *converter.ConvertOptions) { opts.Theme = "dark" }),
+			http.StatusNotModified, "", etag("# Guide", func(opts *converter.ConvertOptions) { opts.Theme = "dark" }), "public, no-cache"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/render?"+tt.query.Encode(), nil)
+			if tt.ifNoneMatch != "" {
+				c.Request.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+
+			RenderHandler(c)
+			c.Writer.WriteHeaderNow()
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode != "" && !strings.Contains(w.Body.String(), `"code":"`+tt.wantCode+`"`) {
+				t.Errorf("body = %s, want error code %s", w.Body.String(), tt.wantCode)
+			}
+			if got := w.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", got, tt.wantETag)
+			}
+			if got := w.Header().Get("Cache-Control"); got != tt.wantCache {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.wantCache)
+			}
+		})
+	}
+
+	if helloTag == etag("# Hello", func(opts *converter.ConvertOptions) { opts.Width = 800 }) {
+		t.Error("renderETag() should change with options")
+	}
+}
+
+// TestSignedRender 测试启用签名时的 GET /api/render 和签名接口
+func TestSignedRender(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	signer, err := signing.NewSigner([]byte(strings.Repeat("s", signing.MinKeyLength)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetRenderSigner(signer)
+	defer SetRenderSigner(nil)
+
+	// 通过签名接口生成 URL
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	body := `{"params": {"markdown": "# Hello", "theme": "dark"}, "expiresIn": 60}`
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/render/sign", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	SignRenderHandler(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("SignRenderHandler status = %d, body = %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			URL string `json:"url"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	signedURL, _ := url.Parse(resp.Data.URL)
+
+	opts := converter.DefaultConvertOptions()
+	opts.Theme = "dark"
+	etag, _ := renderETag([]byte("# Hello"), opts)
+
+	expired := signer.Sign(renderPath, url.Values{"markdown": {"# Hello"}}, time.Now().Add(-time.Minute))
+	tampered := signedURL.Query()
+	tampered.Set("theme", "light")
+
+	tests := []struct {
+		name       string
+		rawQuery   string
+		wantStatus int
+		wantCode   string
+	}{
+		{"未签名", "markdown=%23%20Hello", http.StatusForbidden, "SIGNATURE_REQUIRED"},
+		{"已过期", expired.Encode(), http.StatusForbidden, "SIGNATURE_EXPIRED"},
+		{"修改选项", tampered.Encode(), http.StatusForbidden, "INVALID_SIGNATURE"},
+		{"有效签名", signedURL.RawQuery, http.StatusNotModified, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/render?"+tt.rawQuery, nil)
+			c.Request.Header.Set("If-None-Match", etag)
+
+			RenderHandler(c)
+			c.Writer.WriteHeaderNow()
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode != "" && !strings.Contains(w.Body.String(), `"code":"`+tt.wantCode+`"`) {
+				t.Errorf("body = %s, want error code %s", w.Body.String(), tt.wantCode)
+			}
+		})
+	}
+
+	// 缓存时间不超过签名有效期
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/render?"+signedURL.RawQuery, nil)
+	c.Request.Header.Set("If-None-Match", etag)
+	RenderHandler(c)
+	var maxAge int
+	if _, err := fmt.Sscanf(w.Header().Get("Cache-Control"), "public, max-age=%d", &maxAge); err != nil || maxAge > 60 {
+		t.Errorf("Cache-Control = %q, want max-age <= 60", w.Header().Get("Cache-Control"))
+	}
+
+	// 签名接口拒绝无法渲染的参数
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/render/sign", strings.NewReader(`{"params": {"theme": "dark"}}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	SignRenderHandler(c)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("SignRenderHandler without markdown status = %d, want 400", w.Code)
+	}
+}
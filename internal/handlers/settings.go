@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Cshiyuan/Gomarkdown2image/internal/config"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/converter"
+)
+
+// 请求大小限制 (字节,启动时配置)
+var (
+	maxMarkdownSize int64 = config.MaxMarkdownSize
+	maxUploadSize   int64 = config.MaxFileUploadSize
+)
+
+// SetSizeLimits 设置 Markdown 内容和上传文件的最大字节数
+//
+// 应在启动服务之前调用。
+func SetSizeLimits(markdown, upload int) {
+	maxMarkdownSize = int64(markdown)
+	maxUploadSize = int64(upload)
+}
+
+// defaultOptions 请求未指定时使用的转换选项 (启动时配置,为 nil 时使用 converter.DefaultConvertOptions)
+var defaultOptions *converter.ConvertOptions
+
+// SetDefaultOptions 设置请求未指定时使用的转换选项
+//
+// 应在启动服务之前调用。
+func SetDefaultOptions(opts *converter.ConvertOptions) {
+	defaultOptions = opts
+}
+
+// newDefaultOptions 返回本次请求使用的默认转换选项副本
+func newDefaultOptions() *converter.ConvertOptions {
+	if defaultOptions == nil {
+		return converter.DefaultConvertOptions()
+	}
+	opts := *defaultOptions
+	opts.AIPromptData = maps.Clone(defaultOptions.AIPromptData)
+	if opts.AIPromptData == nil {
+		opts.AIPromptData = make(map[string]interface{})
+	}
+	return &opts
+}
+
+// sizeLimitDetails 返回大小限制的错误说明 (如 "最大支持 10 MB")
+func sizeLimitDetails(limit int64) string {
+	if limit >= 1<<20 && limit%(1<<20) == 0 {
+		return fmt.Sprintf("最大支持 %d MB", limit>>20)
+	}
+	if limit >= 1<<10 && limit%(1<<10) == 0 {
+		return fmt.Sprintf("最大支持 %d KB", limit>>10)
+	}
+	return fmt.Sprintf("最大支持 %d 字节", limit)
+}
+
+// AIPolicy 服务端 AI 配置和客户端密钥策略
+type AIPolicy struct {
+	Profiles        map[string]config.AIProfile // 命名的 AI 配置 (请求通过 aiProfile 引用)
+	DefaultProfile  string                      // AI 模式请求未指定 aiProfile 和任何 AI 连接选项时使用的配置
+	AllowClientKeys bool                        // 是否允许请求携带 aiApiKey
+}
+
+// aiPolicy 服务端 AI 配置 (启动时配置,默认允许客户端密钥)
+var aiPolicy = AIPolicy{AllowClientKeys: true}
+
+// SetAIPolicy 设置服务端 AI 配置和客户端密钥策略
+//
+// 应在启动服务之前调用。
+func SetAIPolicy(p AIPolicy) {
+	aiPolicy = p
+}
+
+// applyAIProfile 检查客户端密钥策略,并将请求引用 (或默认) 的 AI 配置写入转换选项
+//
+// 使用服务端配置时不允许同时指定提供器、模型、密钥或端点,避免服务端密钥被发送到客户端指定的端点。
+func applyAIProfile(params RequestParams, opts *converter.ConvertOptions) (int, *APIError) {
+	if params.GetAIAPIKey() != "" && !aiPolicy.AllowClientKeys {
+		return http.StatusForbidden, &APIError{
+			Code:    "CLIENT_AI_KEY_FORBIDDEN",
+			Message: "服务端不接受客户端提供的 AI 密钥",
+			Details: "请改用 aiProfile 引用服务端 AI 配置",
+		}
+	}
+
+	clientOptions := params.GetAIProvider() != "" || params.GetAIModel() != "" ||
+		params.GetAIAPIKey() != "" || params.GetAIEndpoint() != ""
+	name := params.GetAIProfile()
+	switch {
+	case name == "" && (opts.ParserMode != "ai" || aiPolicy.DefaultProfile == "" || clientOptions):
+		return http.StatusOK, nil
+	case name == "":
+		name = aiPolicy.DefaultProfile
+	case clientOptions:
+		return http.StatusBadRequest, &APIError{
+			Code:    "INVALID_OPTIONS",
+			Message: "转换选项验证失败",
+			Details: "aiProfile 不能与 aiProvider、aiModel、aiApiKey 或 aiEndpoint 同时使用",
+		}
+	}
+
+	profile, ok := aiPolicy.Profiles[name]
+	if !ok {
+		return http.StatusBadRequest, &APIError{
+			Code:    "UNKNOWN_AI_PROFILE",
+			Message: "AI 配置不存在",
+			Details: fmt.Sprintf("可用的配置: %s", strings.Join(slices.Sorted(maps.Keys(aiPolicy.Profiles)), ", ")),
+		}
+	}
+	opts.AIProvider = profile.Provider
+	opts.AIModel = profile.Model
+	opts.AIAPIKey = profile.APIKey
+	if profile.Endpoint != "" {
+		opts.AIEndpoint = profile.Endpoint
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Cshiyuan/Gomarkdown2image/internal/config"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/converter"
+)
+
+// TestServerSettings 测试服务配置的默认选项和大小限制
+func TestServerSettings(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	defaults := converter.DefaultConvertOptions()
+	defaults.Theme = "dark"
+	defaults.Width = 800
+	SetDefaultOptions(defaults)
+	SetSizeLimits(10, 10)
+	defer func() {
+		SetDefaultOptions(nil)
+		SetSizeLimits(config.MaxMarkdownSize, config.MaxFileUploadSize)
+	}()
+
+	opts := buildConvertOptions(&ConvertRequest{Markdown: "# Test", Width: 1000})
+	if opts.Theme != "dark" || opts.Width != 1000 {
+		t.Errorf("theme = %s, width = %d, want dark and 1000", opts.Theme, opts.Width)
+	}
+	opts.AIPromptData["k"] = "v"
+	if len(defaults.AIPromptData) != 0 {
+		t.Error("request options should not share AIPromptData with the defaults")
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/convert", strings.NewReader(`{"markdown": "# More than ten bytes"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	ConvertHandler(c)
+
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "CONTENT_TOO_LARGE") {
+		t.Fatalf("status = %d, body = %s, want CONTENT_TOO_LARGE", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "最大支持 10 字节") {
+		t.Errorf("body = %s, want size limit details", w.Body.String())
+	}
+}
+
+// TestAIProfiles 测试服务端 AI 配置和客户端密钥策略
+func TestAIProfiles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	SetAIPolicy(AIPolicy{
+		Profiles: map[string]config.AIProfile{
+			"fast":  {Provider: "gemini", Model: "gemini-2.0-flash", APIKey: "server-key"},
+			"local": {Provider: "ollama", Model: "llama3.2", Endpoint: "http://ollama:11434"},
+		},
+		DefaultProfile: "fast",
+	})
+	defer SetAIPolicy(AIPolicy{AllowClientKeys: true})
+
+	tests := []struct {
+		name         string
+		req          ConvertRequest
+		wantStatus   int
+		wantCode     string
+		wantProvider string
+		wantKey      string
+		wantEndpoint string
+	}{
+		{"引用配置", ConvertRequest{ParserMode: "ai", AIProfile: "local"}, http.StatusOK, "", "ollama", "", "http://ollama:11434"},
+		{"默认配置", ConvertRequest{ParserMode: "ai"}, http.StatusOK, "", "gemini", "server-key", "http://localhost:11434"},
+		{"传统模式不使用默认配置", ConvertRequest{}, http.StatusOK, "", "gemini", "", "http://localhost:11434"},
+		{"客户端指定提供器时不使用默认配置", ConvertRequest{ParserMode: "ai", AIProvider: "ollama"}, http.StatusOK, "", "ollama", "", "http://localhost:11434"},
+		{"禁止客户端密钥", ConvertRequest{ParserMode: "ai", AIAPIKey: "client-key"}, http.StatusForbidden, "CLIENT_AI_KEY_FORBIDDEN", "", "", ""},
+		{"配置与端点同时使用", ConvertRequest{ParserMode: "ai", AIProfile: "fast", AIEndpoint: "https://evil.example.com"}, http.StatusBadRequest, "INVALID_OPTIONS", "", "", ""},
+		{"配置不存在", ConvertRequest{ParserMode: "ai", AIProfile: "missing"}, http.StatusBadRequest, "UNKNOWN_AI_PROFILE", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := buildConvertOptions(&tt.req)
+			status, apiErr := applyAIProfile(&tt.req, opts)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (error: %+v)", status, tt.wantStatus, apiErr)
+			}
+			if tt.wantCode != "" {
+				if apiErr == nil || apiErr.Code != tt.wantCode {
+					t.Errorf("error = %+v, want code %s", apiErr, tt.wantCode)
+				}
+				return
+			}
+			if opts.AIProvider != tt.wantProvider || opts.AIAPIKey != tt.wantKey || opts.AIEndpoint != tt.wantEndpoint {
+				t.Errorf("provider = %s, key = %q, endpoint = %s, want %s, %q, %s",
+					opts.AIProvider, opts.AIAPIKey, opts.AIEndpoint, tt.wantProvider, tt.wantKey, tt.wantEndpoint)
+			}
+		})
+	}
+
+	// 转换端点在创建转换器之前拒绝客户端密钥
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/convert", strings.NewReader(`{"markdown": "# A", "parserMode": "ai", "aiApiKey": "client-key"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	ConvertHandler(c)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "CLIENT_AI_KEY_FORBIDDEN") {
+		t.Errorf("status = %d, body = %s, want CLIENT_AI_KEY_FORBIDDEN", w.Code, w.Body.String())
+	}
+}
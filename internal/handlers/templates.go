@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/parser"
+)
+
+// documentTemplates 服务端配置的文档模板 (启动时加载,之后只读)
+var documentTemplates map[string]*template.Template
+
+// LoadDocumentTemplates 从目录加载文档模板,请求通过 template 字段按名称引用
+//
+// 应在启动服务之前调用。
+//
+// 返回:
+//   - []string: 已加载的模板名称
+//   - error: 读取或解析错误(如有)
+func LoadDocumentTemplates(dir string) ([]string, error) {
+	templates, err := parser.LoadDocumentTemplateDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	documentTemplates = templates
+	return documentTemplateNames(), nil
+}
+
+// documentTemplateNames 返回已加载的模板名称 (已排序)
+func documentTemplateNames() []string {
+	names := make([]string, 0, len(documentTemplates))
+	for name := range documentTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateTemplateName 验证请求引用的文档模板是否已配置
+func validateTemplateName(name string) *APIError {
+	if name == "" {
+		return nil
+	}
+	if _, ok := documentTemplates[name]; !ok {
+		return &APIError{
+			Code:    "INVALID_TEMPLATE",
+			Message: "文档模板不存在",
+			Details: fmt.Sprintf("unknown template: %s (available: %s)", name, strings.Join(documentTemplateNames(), ", ")),
+		}
+	}
+	return nil
+}
@@ -6,13 +6,42 @@ type RequestParams interface {
 	GetTitle() string
 	GetTheme() string
 	GetCustomCSS() string
+	GetTemplate() string
+	GetTOC() bool
+	GetTOCDepth() int
+	GetExtensions() []string
+	GetHeader() string
+	GetFooter() string
+	GetLogo() string
+	GetWatermarkText() string
+	GetWatermarkImage() string
+	GetWatermarkPosition() string
+	GetWatermarkOpacity() float64
+	GetWatermarkRotation() *int
+	GetWatermarkTile() bool
 	GetWidth() int
 	GetFontSize() int
 	GetFontFamily() string
+	GetTransparent() bool
+	GetLang() string
+	GetDir() string
+	GetLayout() string
+	GetCardPreset() string
+	GetCardLogo() string
+	GetSnippetLanguage() string
+	GetSnippetStyle() string
+	GetSnippetTitle() string
+	GetSnippetWindow() *bool
+	GetSnippetHighlight() string
+	GetSnippetBackground() string
+	GetSlideAspect() string
 	GetImageFormat() string
 	GetImageQuality() int
 	GetDevicePixelRatio() float64
+	GetSelector() string
+	GetSection() string
 	GetParserMode() string
+	GetAIProfile() string
 	GetAIProvider() string
 	GetAIModel() string
 	GetAIAPIKey() string
@@ -27,20 +56,63 @@ type ConvertRequest struct {
 	Markdown string `json:"markdown" binding:"required"`
 
 	// HTML 模板选项
-	Title      string `json:"title,omitempty"`                                      // 页面标题
-	Theme      string `json:"theme,omitempty" binding:"omitempty,oneof=light dark"` // 主题: light/dark
-	CustomCSS  string `json:"customCss,omitempty"`                                  // 自定义 CSS
-	Width      int    `json:"width,omitempty" binding:"omitempty,min=200,max=4000"` // 页面宽度
-	FontSize   int    `json:"fontSize,omitempty" binding:"omitempty,min=8,max=72"`  // 字体大小
-	FontFamily string `json:"fontFamily,omitempty"`                                 // 字体族
+	Title       string `json:"title,omitempty"`                                      // 页面标题
+	Theme       string `json:"theme,omitempty" binding:"omitempty,oneof=light dark"` // 主题: light/dark
+	CustomCSS   string `json:"customCss,omitempty"`                                  // 自定义 CSS
+	Template    string `json:"template,omitempty"`                                   // 文档模板名称 (服务端 TEMPLATE_DIR 中的模板)
+	Width       int    `json:"width,omitempty" binding:"omitempty,min=200,max=4000"` // 页面宽度
+	FontSize    int    `json:"fontSize,omitempty" binding:"omitempty,min=8,max=72"`  // 字体大小
+	FontFamily  string `json:"fontFamily,omitempty"`                                 // 字体族
+	Transparent bool   `json:"transparent,omitempty"`                                // 透明背景 (仅 png/webp)
+	Lang        string `json:"lang,omitempty"`                                       // 文档语言 (BCP 47,如 en、ar)
+	Dir         string `json:"dir,omitempty" binding:"omitempty,oneof=auto ltr rtl"` // 文字方向: auto/ltr/rtl
+
+	// 目录选项 (内容中的 [TOC] 标记总是替换为目录)
+	TOC      bool `json:"toc,omitempty"`                                      // 无 [TOC] 标记时在开头插入目录
+	TOCDepth int  `json:"tocDepth,omitempty" binding:"omitempty,min=1,max=6"` // 目录最大标题级别 (默认 3)
+
+	// Markdown 扩展 (如 ["footnote", "deflist"],"-heading-ids" 禁用默认扩展)
+	Extensions []string `json:"extensions,omitempty"`
+
+	// 页眉页脚 (支持 {{title}}、{{date}}、{{author}}、{{url}}、{{page}}、{{pages}} 占位符)
+	Header string `json:"header,omitempty"` // 页眉 (纯文本或 HTML 片段)
+	Footer string `json:"footer,omitempty"` // 页脚 (纯文本或 HTML 片段)
+	Logo   string `json:"logo,omitempty"`   // 页眉 Logo 图片 URL
+
+	// 水印选项
+	WatermarkText     string  `json:"watermarkText,omitempty"`                                                                                  // 文字水印
+	WatermarkImage    string  `json:"watermarkImage,omitempty"`                                                                                 // 图片水印 URL
+	WatermarkPosition string  `json:"watermarkPosition,omitempty" binding:"omitempty,oneof=center top-left top-right bottom-left bottom-right"` // 水印位置
+	WatermarkOpacity  float64 `json:"watermarkOpacity,omitempty" binding:"omitempty,min=0,max=1"`                                               // 不透明度 (0-1)
+	WatermarkRotation *int    `json:"watermarkRotation,omitempty" binding:"omitempty,min=-360,max=360"`                                         // 旋转角度 (默认 -30)
+	WatermarkTile     bool    `json:"watermarkTile,omitempty"`                                                                                  // 平铺
+
+	// 布局选项
+	Layout     string `json:"layout,omitempty" binding:"omitempty,oneof=document card snippet slides"` // 布局模式
+	CardPreset string `json:"cardPreset,omitempty" binding:"omitempty,oneof=og twitter square"`        // 社交卡片尺寸预设
+	CardLogo   string `json:"cardLogo,omitempty"`                                                      // 社交卡片 Logo URL
+
+	// 代码片段选项 (layout=snippet)
+	SnippetLanguage   string `json:"snippetLanguage,omitempty"`   // 代码语言
+	SnippetStyle      string `json:"snippetStyle,omitempty"`      // Chroma 样式
+	SnippetTitle      string `json:"snippetTitle,omitempty"`      // 窗口标题
+	SnippetWindow     *bool  `json:"snippetWindow,omitempty"`     // 是否显示窗口标题栏 (默认 true)
+	SnippetHighlight  string `json:"snippetHighlight,omitempty"`  // 高亮行, 如 "1,3-5"
+	SnippetBackground string `json:"snippetBackground,omitempty"` // 背景颜色或渐变 CSS
+
+	// 幻灯片选项 (layout=slides, 图片格式时返回 ZIP)
+	SlideAspect string `json:"slideAspect,omitempty" binding:"omitempty,oneof=16:9 4:3"` // 幻灯片宽高比
 
 	// 图像渲染选项
-	ImageFormat      string  `json:"imageFormat,omitempty" binding:"omitempty,oneof=png jpeg webp"` // 图片格式
-	ImageQuality     int     `json:"imageQuality,omitempty" binding:"omitempty,min=1,max=100"`      // 图片质量 (1-100)
-	DevicePixelRatio float64 `json:"devicePixelRatio,omitempty" binding:"omitempty,min=0.5,max=4"`  // 设备像素比
+	ImageFormat      string  `json:"imageFormat,omitempty" binding:"omitempty,oneof=png jpeg webp pdf"` // 图片格式
+	ImageQuality     int     `json:"imageQuality,omitempty" binding:"omitempty,min=1,max=100"`          // 图片质量 (1-100)
+	DevicePixelRatio float64 `json:"devicePixelRatio,omitempty" binding:"omitempty,min=0.5,max=4"`      // 设备像素比
+	Selector         string  `json:"selector,omitempty"`                                                // 元素截图 CSS 选择器
+	Section          string  `json:"section,omitempty"`                                                 // 章节截图标题锚点 ID
 
 	// AI 增强选项 (新增)
 	ParserMode       string `json:"parserMode,omitempty" binding:"omitempty,oneof=traditional ai"` // 解析器模式
+	AIProfile        string `json:"aiProfile,omitempty"`                                           // 服务端 AI 配置名称 (替代以下提供器、模型、密钥和端点)
 	AIProvider       string `json:"aiProvider,omitempty" binding:"omitempty,oneof=gemini ollama"`  // AI 提供器
 	AIModel          string `json:"aiModel,omitempty"`                                             // AI 模型名称
 	AIAPIKey         string `json:"aiApiKey,omitempty"`                                            // AI API 密钥
@@ -53,19 +125,55 @@ type ConvertRequest struct {
 type UploadRequest struct {
 	// 所有字段都通过表单 (multipart/form-data) 提交
 	// 文件字段名: "file"
-	Title      string `form:"title"`
-	Theme      string `form:"theme" binding:"omitempty,oneof=light dark"`
-	Width      int    `form:"width" binding:"omitempty,min=200,max=4000"`
-	FontSize   int    `form:"fontSize" binding:"omitempty,min=8,max=72"`
-	FontFamily string `form:"fontFamily"`
-	CustomCSS  string `form:"customCss"`
-
-	ImageFormat      string  `form:"imageFormat" binding:"omitempty,oneof=png jpeg webp"`
+	Title       string `form:"title"`
+	Theme       string `form:"theme" binding:"omitempty,oneof=light dark"`
+	Width       int    `form:"width" binding:"omitempty,min=200,max=4000"`
+	FontSize    int    `form:"fontSize" binding:"omitempty,min=8,max=72"`
+	FontFamily  string `form:"fontFamily"`
+	CustomCSS   string `form:"customCss"`
+	Template    string `form:"template"`
+	Transparent bool   `form:"transparent"`
+	Lang        string `form:"lang"`
+	Dir         string `form:"dir" binding:"omitempty,oneof=auto ltr rtl"`
+
+	TOC      bool `form:"toc"`
+	TOCDepth int  `form:"tocDepth" binding:"omitempty,min=1,max=6"`
+
+	Extensions []string `form:"extensions"` // 可重复或逗号分隔
+
+	Header string `form:"header"`
+	Footer string `form:"footer"`
+	Logo   string `form:"logo"`
+
+	WatermarkText     string  `form:"watermarkText"`
+	WatermarkImage    string  `form:"watermarkImage"`
+	WatermarkPosition string  `form:"watermarkPosition" binding:"omitempty,oneof=center top-left top-right bottom-left bottom-right"`
+	WatermarkOpacity  float64 `form:"watermarkOpacity" binding:"omitempty,min=0,max=1"`
+	WatermarkRotation *int    `form:"watermarkRotation" binding:"omitempty,min=-360,max=360"`
+	WatermarkTile     bool    `form:"watermarkTile"`
+
+	Layout     string `form:"layout" binding:"omitempty,oneof=document card snippet slides"`
+	CardPreset string `form:"cardPreset" binding:"omitempty,oneof=og twitter square"`
+	CardLogo   string `form:"cardLogo"`
+
+	SnippetLanguage   string `form:"snippetLanguage"`
+	SnippetStyle      string `form:"snippetStyle"`
+	SnippetTitle      string `form:"snippetTitle"`
+	SnippetWindow     *bool  `form:"snippetWindow"`
+	SnippetHighlight  string `form:"snippetHighlight"`
+	SnippetBackground string `form:"snippetBackground"`
+
+	SlideAspect string `form:"slideAspect" binding:"omitempty,oneof=16:9 4:3"`
+
+	ImageFormat      string  `form:"imageFormat" binding:"omitempty,oneof=png jpeg webp pdf"`
 	ImageQuality     int     `form:"imageQuality" binding:"omitempty,min=1,max=100"`
 	DevicePixelRatio float64 `form:"devicePixelRatio" binding:"omitempty,min=0.5,max=4"`
+	Selector         string  `form:"selector"`
+	Section          string  `form:"section"`
 
 	// AI 增强选项 (新增)
 	ParserMode       string `form:"parserMode" binding:"omitempty,oneof=traditional ai"`
+	AIProfile        string `form:"aiProfile"`
 	AIProvider       string `form:"aiProvider" binding:"omitempty,oneof=gemini ollama"`
 	AIModel          string `form:"aiModel"`
 	AIAPIKey         string `form:"aiApiKey"`
@@ -84,9 +192,10 @@ type APIResponse struct {
 
 // APIError API 错误详情
 type APIError struct {
-	Code    string `json:"code"`              // 错误代码
-	Message string `json:"message"`           // 错误消息
-	Details string `json:"details,omitempty"` // 错误详情
+	Code      string `json:"code"`                // 错误代码
+	Message   string `json:"message"`             // 错误消息
+	Details   string `json:"details,omitempty"`   // 错误详情
+	RequestID string `json:"requestId,omitempty"` // 请求 ID (与 X-Request-ID 响应头相同)
 }
 
 // ConvertResponse 转换成功时的响应数据
@@ -100,14 +209,43 @@ type ConvertResponse struct {
 
 func (r *ConvertRequest) GetTitle() string             { return r.Title }
 func (r *ConvertRequest) GetTheme() string             { return r.Theme }
+func (r *ConvertRequest) GetHeader() string            { return r.Header }
+func (r *ConvertRequest) GetFooter() string            { return r.Footer }
+func (r *ConvertRequest) GetWatermarkText() string     { return r.WatermarkText }
+func (r *ConvertRequest) GetWatermarkImage() string    { return r.WatermarkImage }
+func (r *ConvertRequest) GetWatermarkPosition() string { return r.WatermarkPosition }
+func (r *ConvertRequest) GetWatermarkOpacity() float64 { return r.WatermarkOpacity }
+func (r *ConvertRequest) GetWatermarkRotation() *int   { return r.WatermarkRotation }
+func (r *ConvertRequest) GetWatermarkTile() bool       { return r.WatermarkTile }
+func (r *ConvertRequest) GetLogo() string              { return r.Logo }
+func (r *ConvertRequest) GetTOC() bool                 { return r.TOC }
+func (r *ConvertRequest) GetTOCDepth() int             { return r.TOCDepth }
+func (r *ConvertRequest) GetExtensions() []string      { return r.Extensions }
+func (r *ConvertRequest) GetTemplate() string          { return r.Template }
 func (r *ConvertRequest) GetCustomCSS() string         { return r.CustomCSS }
 func (r *ConvertRequest) GetWidth() int                { return r.Width }
 func (r *ConvertRequest) GetFontSize() int             { return r.FontSize }
 func (r *ConvertRequest) GetFontFamily() string        { return r.FontFamily }
+func (r *ConvertRequest) GetTransparent() bool         { return r.Transparent }
+func (r *ConvertRequest) GetLang() string              { return r.Lang }
+func (r *ConvertRequest) GetDir() string               { return r.Dir }
+func (r *ConvertRequest) GetLayout() string            { return r.Layout }
+func (r *ConvertRequest) GetCardPreset() string        { return r.CardPreset }
+func (r *ConvertRequest) GetCardLogo() string          { return r.CardLogo }
+func (r *ConvertRequest) GetSnippetLanguage() string   { return r.SnippetLanguage }
+func (r *ConvertRequest) GetSnippetStyle() string      { return r.SnippetStyle }
+func (r *ConvertRequest) GetSnippetTitle() string      { return r.SnippetTitle }
+func (r *ConvertRequest) GetSnippetWindow() *bool      { return r.SnippetWindow }
+func (r *ConvertRequest) GetSnippetHighlight() string  { return r.SnippetHighlight }
+func (r *ConvertRequest) GetSnippetBackground() string { return r.SnippetBackground }
+func (r *ConvertRequest) GetSlideAspect() string       { return r.SlideAspect }
 func (r *ConvertRequest) GetImageFormat() string       { return r.ImageFormat }
 func (r *ConvertRequest) GetImageQuality() int         { return r.ImageQuality }
 func (r *ConvertRequest) GetDevicePixelRatio() float64 { return r.DevicePixelRatio }
+func (r *ConvertRequest) GetSelector() string          { return r.Selector }
+func (r *ConvertRequest) GetSection() string           { return r.Section }
 func (r *ConvertRequest) GetParserMode() string        { return r.ParserMode }
+func (r *ConvertRequest) GetAIProfile() string         { return r.AIProfile }
 func (r *ConvertRequest) GetAIProvider() string        { return r.AIProvider }
 func (r *ConvertRequest) GetAIModel() string           { return r.AIModel }
 func (r *ConvertRequest) GetAIAPIKey() string          { return r.AIAPIKey }
@@ -119,17 +257,64 @@ func (r *ConvertRequest) GetAICustomPrompt() string    { return r.AICustomPrompt
 
 func (r *UploadRequest) GetTitle() string             { return r.Title }
 func (r *UploadRequest) GetTheme() string             { return r.Theme }
+func (r *UploadRequest) GetHeader() string            { return r.Header }
+func (r *UploadRequest) GetFooter() string            { return r.Footer }
+func (r *UploadRequest) GetWatermarkText() string     { return r.WatermarkText }
+func (r *UploadRequest) GetWatermarkImage() string    { return r.WatermarkImage }
+func (r *UploadRequest) GetWatermarkPosition() string { return r.WatermarkPosition }
+func (r *UploadRequest) GetWatermarkOpacity() float64 { return r.WatermarkOpacity }
+func (r *UploadRequest) GetWatermarkRotation() *int   { return r.WatermarkRotation }
+func (r *UploadRequest) GetWatermarkTile() bool       { return r.WatermarkTile }
+func (r *UploadRequest) GetLogo() string              { return r.Logo }
+func (r *UploadRequest) GetTOC() bool                 { return r.TOC }
+func (r *UploadRequest) GetTOCDepth() int             { return r.TOCDepth }
+func (r *UploadRequest) GetExtensions() []string      { return r.Extensions }
+func (r *UploadRequest) GetTemplate() string          { return r.Template }
 func (r *UploadRequest) GetCustomCSS() string         { return r.CustomCSS }
 func (r *UploadRequest) GetWidth() int                { return r.Width }
 func (r *UploadRequest) GetFontSize() int             { return r.FontSize }
 func (r *UploadRequest) GetFontFamily() string        { return r.FontFamily }
+func (r *UploadRequest) GetTransparent() bool         { return r.Transparent }
+func (r *UploadRequest) GetLang() string              { return r.Lang }
+func (r *UploadRequest) GetDir() string               { return r.Dir }
+func (r *UploadRequest) GetLayout() string            { return r.Layout }
+func (r *UploadRequest) GetCardPreset() string        { return r.CardPreset }
+func (r *UploadRequest) GetCardLogo() string          { return r.CardLogo }
+func (r *UploadRequest) GetSnippetLanguage() string   { return r.SnippetLanguage }
+func (r *UploadRequest) GetSnippetStyle() string      { return r.SnippetStyle }
+func (r *UploadRequest) GetSnippetTitle() string      { return r.SnippetTitle }
+func (r *UploadRequest) GetSnippetWindow() *bool      { return r.SnippetWindow }
+func (r *UploadRequest) GetSnippetHighlight() string  { return r.SnippetHighlight }
+func (r *UploadRequest) GetSnippetBackground() string { return r.SnippetBackground }
+func (r *UploadRequest) GetSlideAspect() string       { return r.SlideAspect }
 func (r *UploadRequest) GetImageFormat() string       { return r.ImageFormat }
 func (r *UploadRequest) GetImageQuality() int         { return r.ImageQuality }
 func (r *UploadRequest) GetDevicePixelRatio() float64 { return r.DevicePixelRatio }
+func (r *UploadRequest) GetSelector() string          { return r.Selector }
+func (r *UploadRequest) GetSection() string           { return r.Section }
 func (r *UploadRequest) GetParserMode() string        { return r.ParserMode }
+func (r *UploadRequest) GetAIProfile() string         { return r.AIProfile }
 func (r *UploadRequest) GetAIProvider() string        { return r.AIProvider }
 func (r *UploadRequest) GetAIModel() string           { return r.AIModel }
 func (r *UploadRequest) GetAIAPIKey() string          { return r.AIAPIKey }
 func (r *UploadRequest) GetAIEndpoint() string        { return r.AIEndpoint }
 func (r *UploadRequest) GetAIPromptTemplate() string  { return r.AIPromptTemplate }
 func (r *UploadRequest) GetAICustomPrompt() string    { return r.AICustomPrompt }
+
+// RenderRequest 表示 GET /api/render 端点的查询参数
+//
+// Markdown 内容三选一: markdown (原文)、markdownBase64 (URL 安全的 Base64) 或 doc (CONTENT_DIR 中的文档);
+// 其余转换选项与 /api/upload 的表单字段相同。
+type RenderRequest struct {
+	UploadRequest
+
+	Markdown       string `form:"markdown"`
+	MarkdownBase64 string `form:"markdownBase64"`
+	Doc            string `form:"doc"`
+}
+
+// SignRenderRequest 表示 /api/render/sign 端点的请求体
+type SignRenderRequest struct {
+	Params    map[string]string `json:"params" binding:"required"`                                  // GET /api/render 的查询参数 (内容引用和转换选项)
+	ExpiresIn int               `json:"expiresIn,omitempty" binding:"omitempty,min=1,max=31536000"` // 有效期 (秒,默认 1 天,最长 1 年)
+}
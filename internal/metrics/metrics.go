@@ -0,0 +1,167 @@
+// Package metrics 提供 API 服务的 Prometheus 指标
+//
+// Metrics 同时实现 converter.Observer 和 ai.Observer,
+// 注册后统计转换阶段耗时、AI 调用的 token 用量和错误。
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/ai"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 指标名前缀
+const namespace = "markdown2image"
+
+// durationBuckets 耗时直方图的分桶 (秒),覆盖 AI 调用和大文档渲染
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// ConcurrencyStats 并发转换数统计 (由并发限制器提供)
+type ConcurrencyStats interface {
+	InFlight() int // 正在处理的转换数 (每个转换占用一个浏览器)
+	Waiting() int  // 排队等待的请求数
+	Capacity() int // 同时处理的转换数上限
+}
+
+// Metrics API 服务指标
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	stageDuration   *prometheus.HistogramVec
+	outputBytes     *prometheus.HistogramVec
+	aiRequests      *prometheus.CounterVec
+	aiTokens        *prometheus.CounterVec
+	aiErrors        *prometheus.CounterVec
+}
+
+// New 创建指标并注册到独立的 Registry (包含 Go 运行时和进程指标)
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP 请求数 (按路由、方法和状态码)",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP 请求耗时 (秒)",
+			Buckets:   durationBuckets,
+		}, []string{"route", "method", "status"}),
+		stageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "conversion_stage_duration_seconds",
+			Help:      "转换各阶段耗时 (秒): parse, ai, wrap, render",
+			Buckets:   durationBuckets,
+		}, []string{"stage"}),
+		outputBytes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "conversion_output_bytes",
+			Help:      "转换输出大小 (字节,按格式)",
+			Buckets:   prometheus.ExponentialBuckets(16<<10, 4, 8), // 16KB - 256MB
+		}, []string{"format"}),
+		aiRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "ai_requests_total",
+			Help:      "AI 调用次数 (按提供器)",
+		}, []string{"provider"}),
+		aiTokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "ai_tokens_total",
+			Help:      "AI 调用使用的 token 数 (按提供器)",
+		}, []string{"provider"}),
+		aiErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "ai_errors_total",
+			Help:      "AI 调用失败次数 (按提供器和错误类型)",
+		}, []string{"provider", "type"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.stageDuration,
+		m.outputBytes,
+		m.aiRequests,
+		m.aiTokens,
+		m.aiErrors,
+	)
+	return m
+}
+
+// Handler 返回 /metrics 端点的 HTTP 处理器
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveRequest 记录一次 HTTP 请求
+func (m *Metrics) ObserveRequest(route, method string, status int, elapsed time.Duration) {
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(route, method, code).Inc()
+	m.requestDuration.WithLabelValues(route, method, code).Observe(elapsed.Seconds())
+}
+
+// ObserveOutput 记录一次转换的输出大小
+func (m *Metrics) ObserveOutput(format string, size int) {
+	m.outputBytes.WithLabelValues(format).Observe(float64(size))
+}
+
+// ObserveStage 实现 converter.Observer 接口
+func (m *Metrics) ObserveStage(stage string, elapsed time.Duration) {
+	m.stageDuration.WithLabelValues(stage).Observe(elapsed.Seconds())
+}
+
+// ObserveGenerate 实现 ai.Observer 接口 (耗时记录为 ai 阶段)
+func (m *Metrics) ObserveGenerate(provider string, resp *ai.GenerateResponse, err error, elapsed time.Duration) {
+	m.aiRequests.WithLabelValues(provider).Inc()
+	m.stageDuration.WithLabelValues("ai").Observe(elapsed.Seconds())
+	if err != nil {
+		m.aiErrors.WithLabelValues(provider, string(ai.ErrorTypeOf(err))).Inc()
+		return
+	}
+	if resp != nil && resp.TokensUsed > 0 {
+		m.aiTokens.WithLabelValues(provider).Add(float64(resp.TokensUsed))
+	}
+}
+
+// RegisterCache 注册渲染缓存指标 (采集时读取 Stats)
+func (m *Metrics) RegisterCache(c cache.Cache) {
+	stat := func(name, help string, kind prometheus.ValueType, value func(cache.Stats) float64) prometheus.Collector {
+		opts := prometheus.Opts{Namespace: namespace, Name: name, Help: help}
+		if kind == prometheus.CounterValue {
+			return prometheus.NewCounterFunc(prometheus.CounterOpts(opts), func() float64 { return value(c.Stats()) })
+		}
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts(opts), func() float64 { return value(c.Stats()) })
+	}
+	m.registry.MustRegister(
+		stat("cache_hits_total", "渲染缓存命中次数", prometheus.CounterValue, func(s cache.Stats) float64 { return float64(s.Hits) }),
+		stat("cache_misses_total", "渲染缓存未命中次数", prometheus.CounterValue, func(s cache.Stats) float64 { return float64(s.Misses) }),
+		stat("cache_entries", "渲染缓存条目数", prometheus.GaugeValue, func(s cache.Stats) float64 { return float64(s.Entries) }),
+		stat("cache_bytes", "渲染缓存占用字节数", prometheus.GaugeValue, func(s cache.Stats) float64 { return float64(s.Bytes) }),
+		stat("cache_max_bytes", "渲染缓存容量 (字节,0 表示不限制)", prometheus.GaugeValue, func(s cache.Stats) float64 { return float64(s.MaxBytes) }),
+	)
+}
+
+// RegisterConcurrency 注册并发转换数指标 (浏览器使用情况)
+func (m *Metrics) RegisterConcurrency(s ConcurrencyStats) {
+	gauge := func(name, help string, value func() int) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{Namespace: namespace, Name: name, Help: help},
+			func() float64 { return float64(value()) })
+	}
+	m.registry.MustRegister(
+		gauge("browsers_in_use", "正在进行的转换数 (每个转换占用一个浏览器)", s.InFlight),
+		gauge("browsers_capacity", "同时进行的转换数上限", s.Capacity),
+		gauge("conversion_queue_waiting", "排队等待转换的请求数", s.Waiting),
+	)
+}
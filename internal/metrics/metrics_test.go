@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/ai"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/cache"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakeConcurrency 固定值的并发统计
+type fakeConcurrency struct{}
+
+func (fakeConcurrency) InFlight() int { return 3 }
+func (fakeConcurrency) Waiting() int  { return 2 }
+func (fakeConcurrency) Capacity() int { return 4 }
+
+// TestMetrics 测试指标记录和导出
+func TestMetrics(t *testing.T) {
+	m := New()
+
+	m.ObserveRequest("/api/convert", "POST", 200, 120*time.Millisecond)
+	m.ObserveRequest("/api/convert", "POST", 200, 80*time.Millisecond)
+	m.ObserveRequest("/api/convert", "POST", 429, time.Millisecond)
+	m.ObserveOutput("png", 200<<10)
+	m.ObserveStage("render", 500*time.Millisecond)
+
+	m.ObserveGenerate("gemini", &ai.GenerateResponse{TokensUsed: 150}, nil, time.Second)
+	m.ObserveGenerate("gemini", &ai.GenerateResponse{TokensUsed: 50}, nil, time.Second)
+	m.ObserveGenerate("gemini", nil, ai.NewError(ai.ErrorTypeRateLimit, "quota", nil), time.Second)
+	m.ObserveGenerate("ollama", nil, io.EOF, time.Second)
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"成功请求数", testutil.ToFloat64(m.requests.WithLabelValues("/api/convert", "POST", "200")), 2},
+		{"限流请求数", testutil.ToFloat64(m.requests.WithLabelValues("/api/convert", "POST", "429")), 1},
+		{"AI 调用次数", testutil.ToFloat64(m.aiRequests.WithLabelValues("gemini")), 3},
+		{"AI token 用量", testutil.ToFloat64(m.aiTokens.WithLabelValues("gemini")), 200},
+		{"AI 限流错误", testutil.ToFloat64(m.aiErrors.WithLabelValues("gemini", "rate_limit")), 1},
+		{"非 AI 错误", testutil.ToFloat64(m.aiErrors.WithLabelValues("ollama", "unknown")), 1},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	// 缓存和并发指标在采集时读取
+	c := cache.NewMemory(1 << 20)
+	_ = c.Set(strings.Repeat("a", 64), []byte("data"))
+	c.Get(strings.Repeat("a", 64))
+	c.Get(strings.Repeat("b", 64))
+	m.RegisterCache(c)
+	m.RegisterConcurrency(fakeConcurrency{})
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		`markdown2image_http_request_duration_seconds_count{method="POST",route="/api/convert",status="200"} 2`,
+		`markdown2image_conversion_stage_duration_seconds_count{stage="ai"} 4`,
+		`markdown2image_conversion_stage_duration_seconds_count{stage="render"} 1`,
+		`markdown2image_conversion_output_bytes_count{format="png"} 1`,
+		`markdown2image_cache_hits_total 1`,
+		`markdown2image_cache_misses_total 1`,
+		`markdown2image_cache_entries 1`,
+		`markdown2image_browsers_in_use 3`,
+		`markdown2image_browsers_capacity 4`,
+		`markdown2image_conversion_queue_waiting 2`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package signing
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigner(t *testing.T) {
+	if _, err := NewSigner([]byte("short")); err == nil {
+		t.Fatal("NewSigner() should reject short keys")
+	}
+
+	signer, err := NewSigner([]byte(strings.Repeat("k", MinKeyLength)))
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	query := url.Values{"markdown": {"# Hello"}, "theme": {"dark"}, "extensions": {"footnote", "cjk"}}
+	signed := signer.Sign("/api/render", query, now.Add(time.Hour))
+	if query.Get(ParamSignature) != "" {
+		t.Error("Sign() should not modify the input query")
+	}
+
+	tampered := func(modify func(q url.Values)) url.Values {
+		q, _ := url.ParseQuery(signed.Encode())
+		modify(q)
+		return q
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		query url.Values
+		now   time.Time
+		want  error
+	}{
+		{"有效签名", "/api/render", signed, now, nil},
+		{"参数顺序无关", "/api/render", tampered(func(q url.Values) {}), now, nil},
+		{"缺少签名", "/api/render", query, now, ErrMissingSignature},
+		{"修改内容", "/api/render", tampered(func(q url.Values) { q.Set("markdown", "# Evil") }), now, ErrInvalidSignature},
+		{"增加选项", "/api/render", tampered(func(q url.Values) { q.Set("width", "4000") }), now, ErrInvalidSignature},
+		{"修改重复参数顺序", "/api/render", tampered(func(q url.Values) { q["extensions"] = []string{"cjk", "footnote"} }), now, ErrInvalidSignature},
+		{"延长过期时间", "/api/render", tampered(func(q url.Values) { q.Set(ParamExpires, "9999999999") }), now, ErrInvalidSignature},
+		{"其他路径", "/api/other", signed, now, ErrInvalidSignature},
+		{"已过期", "/api/render", signed, now.Add(2 * time.Hour), ErrExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := signer.Verify(tt.path, tt.query, tt.now); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Package tracing 配置 API 服务的 OpenTelemetry 链路追踪
+//
+// span 由 handlers.Tracing 中间件、converter 和 parser 包通过全局 TracerProvider 创建;
+// 本包根据 OTEL_* 环境变量设置全局 TracerProvider (OTLP/HTTP 导出) 和 traceparent 传播。
+package tracing
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// ServiceName 默认服务名 (可通过 OTEL_SERVICE_NAME 覆盖)
+const ServiceName = "markdown2image-api"
+
+// Enabled 是否配置了 OTLP 端点 (OTEL_EXPORTER_OTLP_ENDPOINT 或 OTEL_EXPORTER_OTLP_TRACES_ENDPOINT)
+//
+// OTEL_SDK_DISABLED=true 时总是返回 false。
+func Enabled() bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return false
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Setup 配置全局 traceparent 传播,启用时创建 OTLP/HTTP 导出器并设置全局 TracerProvider
+//
+// 导出器的端点、请求头、超时等由 OTEL_EXPORTER_OTLP_* 环境变量配置,
+// 采样由 OTEL_TRACES_SAMPLER 配置 (默认跟随上游,无上游时全部采样)。
+// 返回的 shutdown 在退出前调用,导出剩余的 span;未启用时为空操作。
+func Setup(ctx context.Context, version string) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if !Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := newResource(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := NewProvider(sdktrace.NewBatchSpanProcessor(exporter), res)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// NewProvider 创建使用指定 span 处理器的 TracerProvider
+//
+// 测试中可配合 tracetest.NewInMemoryExporter 和 sdktrace.NewSimpleSpanProcessor 使用。
+func NewProvider(processor sdktrace.SpanProcessor, res *resource.Resource) *sdktrace.TracerProvider {
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithSpanProcessor(processor)}
+	if res != nil {
+		opts = append(opts, sdktrace.WithResource(res))
+	}
+	return sdktrace.NewTracerProvider(opts...)
+}
+
+// newResource 创建服务资源 (OTEL_SERVICE_NAME 和 OTEL_RESOURCE_ATTRIBUTES 优先)
+func newResource(ctx context.Context, version string) (*resource.Resource, error) {
+	return resource.New(ctx,
+		resource.WithAttributes(
+			attribute.String("service.name", ServiceName),
+			attribute.String("service.version", version),
+		),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+}
@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+// TestEnabled 测试根据环境变量判断是否启用导出
+func TestEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		traces   string
+		disabled string
+		want     bool
+	}{
+		{"未配置端点", "", "", "", false},
+		{"通用端点", "http://collector:4318", "", "", true},
+		{"traces 端点", "", "http://collector:4318/v1/traces", "", true},
+		{"SDK 已禁用", "http://collector:4318", "", "true", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", tt.endpoint)
+			t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", tt.traces)
+			t.Setenv("OTEL_SDK_DISABLED", tt.disabled)
+			if got := Enabled(); got != tt.want {
+				t.Errorf("Enabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSetupDisabled 测试未启用时只配置 traceparent 传播
+func TestSetupDisabled(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+
+	shutdown, err := Setup(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown() error = %v", err)
+	}
+
+	fields := otel.GetTextMapPropagator().Fields()
+	found := false
+	for _, f := range fields {
+		if f == "traceparent" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("propagator fields = %v, want traceparent", fields)
+	}
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"archive/zip"
+	"bytes"
 	"fmt"
 	"strings"
 
@@ -17,8 +19,10 @@ func ParseImageFormat(format string) (renderer.ImageFormat, error) {
 		return renderer.FormatJPEG, nil
 	case "webp":
 		return renderer.FormatWebP, nil
+	case "pdf":
+		return renderer.FormatPDF, nil
 	default:
-		return "", fmt.Errorf("不支持的图片格式: %s (支持: png, jpeg, webp)", format)
+		return "", fmt.Errorf("不支持的图片格式: %s (支持: png, jpeg, webp, pdf)", format)
 	}
 }
 
@@ -38,7 +42,37 @@ func GetContentType(format renderer.ImageFormat) string {
 		return "image/jpeg"
 	case renderer.FormatWebP:
 		return "image/webp"
+	case renderer.FormatPDF:
+		return "application/pdf"
 	default:
 		return "image/png"
 	}
 }
+
+// ZipPages 将多页输出 (如幻灯片图片) 打包为 ZIP 归档
+//
+// 文件名为 "<prefix>-01.<format>"、"<prefix>-02.<format>" 等。
+func ZipPages(pages [][]byte, prefix string, format renderer.ImageFormat) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	for i, page := range pages {
+		w, err := archive.Create(PageFileName(prefix, i, format))
+		if err != nil {
+			return nil, fmt.Errorf("创建归档文件失败: %w", err)
+		}
+		if _, err := w.Write(page); err != nil {
+			return nil, fmt.Errorf("写入归档文件失败: %w", err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("关闭归档失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// PageFileName 返回多页输出中第 index 页 (从 0 开始) 的文件名
+func PageFileName(prefix string, index int, format renderer.ImageFormat) string {
+	return fmt.Sprintf("%s-%02d.%s", prefix, index+1, format)
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"archive/zip"
+	"bytes"
 	"testing"
 
 	"github.com/Cshiyuan/Gomarkdown2image/pkg/renderer"
@@ -61,6 +63,12 @@ func TestParseImageFormat(t *testing.T) {
 			want:    renderer.FormatPNG,
 			wantErr: false,
 		},
+		{
+			name:    "pdf",
+			input:   "pdf",
+			want:    renderer.FormatPDF,
+			wantErr: false,
+		},
 		{
 			name:    "不支持的格式",
 			input:   "gif",
@@ -159,6 +167,11 @@ func TestGetContentType(t *testing.T) {
 			format: renderer.FormatWebP,
 			want:   "image/webp",
 		},
+		{
+			name:   "PDF格式",
+			format: renderer.FormatPDF,
+			want:   "application/pdf",
+		},
 		{
 			name:   "未知格式默认PNG",
 			format: "unknown",
@@ -181,6 +194,28 @@ func TestGetContentType(t *testing.T) {
 	}
 }
 
+func TestZipPages(t *testing.T) {
+	pages := [][]byte{[]byte("first"), []byte("second")}
+
+	data, err := ZipPages(pages, "slide", renderer.FormatPNG)
+	if err != nil {
+		t.Fatalf("ZipPages() error = %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+	if len(archive.File) != len(pages) {
+		t.Fatalf("ZipPages() files = %d, want %d", len(archive.File), len(pages))
+	}
+	for i, want := range []string{"slide-01.png", "slide-02.png"} {
+		if archive.File[i].Name != want {
+			t.Errorf("ZipPages() file[%d] = %s, want %s", i, archive.File[i].Name, want)
+		}
+	}
+}
+
 // 基准测试
 func BenchmarkParseImageFormat(b *testing.B) {
 	for i := 0; i < b.N; i++ {
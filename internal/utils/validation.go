@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/Cshiyuan/Gomarkdown2image/internal/config"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/renderer"
 )
 
 // ValidateQuality 验证图片质量参数
@@ -50,6 +51,14 @@ func ValidateTheme(theme string) error {
 	return fmt.Errorf("无效的主题: %s (支持: light, dark)", theme)
 }
 
+// ValidateTransparentFormat 验证透明背景与图片格式是否兼容 (JPEG 不支持 alpha 通道)
+func ValidateTransparentFormat(format renderer.ImageFormat, transparent bool) error {
+	if transparent && format == renderer.FormatJPEG {
+		return fmt.Errorf("透明背景仅支持 png 和 webp 格式")
+	}
+	return nil
+}
+
 // ValidateCustomCSS 验证自定义 CSS，防止 XSS 注入
 func ValidateCustomCSS(css string) error {
 	if css == "" {
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/Cshiyuan/Gomarkdown2image/internal/config"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/renderer"
 )
 
 func TestValidateQuality(t *testing.T) {
@@ -136,6 +137,29 @@ func TestValidateTheme(t *testing.T) {
 	}
 }
 
+func TestValidateTransparentFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      renderer.ImageFormat
+		transparent bool
+		wantErr     bool
+	}{
+		{"PNG 透明", renderer.FormatPNG, true, false},
+		{"WebP 透明", renderer.FormatWebP, true, false},
+		{"JPEG 透明", renderer.FormatJPEG, true, true},
+		{"JPEG 不透明", renderer.FormatJPEG, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTransparentFormat(tt.format, tt.transparent)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTransparentFormat(%s, %v) error = %v, wantErr %v", tt.format, tt.transparent, err, tt.wantErr)
+			}
+		})
+	}
+}
+
 // 基准测试
 func BenchmarkValidateQuality(b *testing.B) {
 	for i := 0; i < b.N; i++ {
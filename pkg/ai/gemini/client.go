@@ -95,7 +95,9 @@ func (p *Provider) Generate(ctx context.Context, req *ai.GenerateRequest) (*ai.G
 	// 生成内容
 	resp, err := model.GenerateContent(ctx, genai.Text(req.Prompt))
 	if err != nil {
-		return nil, p.handleError(err)
+		aiErr := p.handleError(err)
+		ai.Logger(ctx).Debug("gemini generate failed", "model", p.config.Model, "error", aiErr)
+		return nil, aiErr
 	}
 
 	// 提取响应内容
@@ -128,6 +130,7 @@ func (p *Provider) Generate(ctx context.Context, req *ai.GenerateRequest) (*ai.G
 		response.Metadata["candidates_tokens"] = fmt.Sprintf("%d", resp.UsageMetadata.CandidatesTokenCount)
 	}
 	response.Metadata["model"] = p.config.Model
+	ai.Logger(ctx).Debug("gemini generate", "model", p.config.Model, "tokens", response.TokensUsed, "finish_reason", response.FinishReason)
 
 	return response, nil
 }
@@ -227,6 +230,14 @@ func (p *Provider) Name() string {
 	return "gemini"
 }
 
+// Check 实现 ai.Checker 接口: 读取模型信息,检查 API Key 有效且模型可用
+func (p *Provider) Check(ctx context.Context) error {
+	if _, err := p.client.GenerativeModel(p.config.Model).Info(ctx); err != nil {
+		return p.handleError(err)
+	}
+	return nil
+}
+
 // Close 关闭连接和清理资源
 func (p *Provider) Close() error {
 	if p.client != nil {
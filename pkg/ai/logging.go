@@ -0,0 +1,22 @@
+package ai
+
+import (
+	"context"
+	"log/slog"
+)
+
+// loggerKey context 中保存日志记录器的键
+type loggerKey struct{}
+
+// WithLogger 返回携带日志记录器的 context (如带有请求 ID 的记录器)
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// Logger 返回 context 中的日志记录器,未设置时返回 slog.Default()
+func Logger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok && logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,38 @@
+package ai
+
+import (
+	"errors"
+	"time"
+)
+
+// Observer AI 调用观察者 (用于统计调用耗时、token 用量和错误)
+type Observer interface {
+	// ObserveGenerate 在每次 Generate 调用结束后调用,失败时 resp 为 nil
+	ObserveGenerate(provider string, resp *GenerateResponse, err error, elapsed time.Duration)
+}
+
+// observer 当前的观察者 (启动时设置)
+var observer Observer
+
+// SetObserver 设置 AI 调用观察者,nil 表示不观察
+//
+// 应在启动时调用,不能与 AI 调用并发。
+func SetObserver(o Observer) {
+	observer = o
+}
+
+// ObserveGenerate 通知观察者一次 Generate 调用的结果
+func ObserveGenerate(provider string, resp *GenerateResponse, err error, elapsed time.Duration) {
+	if observer != nil {
+		observer.ObserveGenerate(provider, resp, err, elapsed)
+	}
+}
+
+// ErrorTypeOf 返回错误的类型,非 AI 错误返回 ErrorTypeUnknown
+func ErrorTypeOf(err error) ErrorType {
+	var aiErr *Error
+	if errors.As(err, &aiErr) {
+		return aiErr.Type
+	}
+	return ErrorTypeUnknown
+}
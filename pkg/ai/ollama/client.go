@@ -99,7 +99,9 @@ func (p *Provider) Generate(ctx context.Context, req *ai.GenerateRequest) (*ai.G
 	})
 
 	if err != nil {
-		return nil, p.handleError(err)
+		aiErr := p.handleError(err)
+		ai.Logger(ctx).Debug("ollama generate failed", "model", p.config.Model, "error", aiErr)
+		return nil, aiErr
 	}
 
 	// 构建响应
@@ -111,6 +113,7 @@ func (p *Provider) Generate(ctx context.Context, req *ai.GenerateRequest) (*ai.G
 	}
 
 	response.Metadata["model"] = p.config.Model
+	ai.Logger(ctx).Debug("ollama generate", "model", p.config.Model, "tokens", response.TokensUsed)
 
 	return response, nil
 }
@@ -200,6 +203,14 @@ func (p *Provider) Name() string {
 	return "ollama"
 }
 
+// Check 实现 ai.Checker 接口: 检查 Ollama 服务可达且模型已下载
+func (p *Provider) Check(ctx context.Context) error {
+	if _, err := p.client.Show(ctx, &api.ShowRequest{Model: p.config.Model}); err != nil {
+		return p.handleError(err)
+	}
+	return nil
+}
+
 // Close 关闭连接和清理资源
 func (p *Provider) Close() error {
 	// Ollama 客户端无需显式关闭
@@ -39,6 +39,12 @@ type Provider interface {
 	Close() error
 }
 
+// Checker 可检查服务可用性的提供器 (可选接口,用于就绪检查)
+type Checker interface {
+	// Check 检查服务可达、凭据有效且配置的模型可用 (不调用生成接口)
+	Check(ctx context.Context) error
+}
+
 // ValidateConfig 验证 AI 配置是否有效
 func ValidateConfig(cfg *Config) error {
 	if cfg == nil {
@@ -0,0 +1,69 @@
+// Package cache 提供渲染结果缓存
+//
+// 缓存以内容哈希为键 (见 converter.CacheKey),值为渲染结果的字节数组。
+// 提供内存 LRU 和磁盘目录两种后端,均可并发使用。
+package cache
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Cache 渲染结果缓存接口
+type Cache interface {
+	// Get 读取缓存,未命中时返回 false
+	Get(key string) ([]byte, bool)
+
+	// Set 写入缓存 (超过容量时淘汰最久未使用的条目)
+	Set(key string, data []byte) error
+
+	// Stats 返回命中率和容量统计
+	Stats() Stats
+}
+
+// Stats 缓存统计
+type Stats struct {
+	Backend  string `json:"backend"`  // 后端类型: memory, disk
+	Hits     int64  `json:"hits"`     // 命中次数
+	Misses   int64  `json:"misses"`   // 未命中次数
+	Entries  int64  `json:"entries"`  // 条目数
+	Bytes    int64  `json:"bytes"`    // 已用字节数
+	MaxBytes int64  `json:"maxBytes"` // 容量上限 (0 表示不限制)
+}
+
+// HitRatio 返回命中率 (0-1),无请求时返回 0
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// counters 命中/未命中计数器
+type counters struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// record 记录一次查询结果
+func (c *counters) record(hit bool) {
+	if hit {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+}
+
+// validKey 检查缓存键是否为十六进制哈希 (磁盘后端直接用作文件名)
+func validKey(key string) error {
+	if len(key) < 8 {
+		return fmt.Errorf("invalid cache key: %q", key)
+	}
+	for _, r := range key {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return fmt.Errorf("invalid cache key: %q", key)
+		}
+	}
+	return nil
+}
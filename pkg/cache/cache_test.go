@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testKey 生成测试用的十六进制缓存键
+func testKey(c string) string {
+	return strings.Repeat(c, 64)
+}
+
+func TestMemory(t *testing.T) {
+	m := NewMemory(10)
+
+	if _, ok := m.Get(testKey("a")); ok {
+		t.Fatal("Get() on empty cache should miss")
+	}
+	_ = m.Set(testKey("a"), []byte("aaaa"))
+	_ = m.Set(testKey("b"), []byte("bbbb"))
+
+	// 访问 a 后,写入 c 应淘汰最久未使用的 b
+	if data, ok := m.Get(testKey("a")); !ok || string(data) != "aaaa" {
+		t.Fatalf("Get(a) = %q, %v", data, ok)
+	}
+	_ = m.Set(testKey("c"), []byte("cccc"))
+	if _, ok := m.Get(testKey("b")); ok {
+		t.Error("b should be evicted")
+	}
+	if _, ok := m.Get(testKey("a")); !ok {
+		t.Error("a should be kept")
+	}
+
+	// 超过容量的单个结果不缓存
+	_ = m.Set(testKey("d"), bytes.Repeat([]byte("d"), 11))
+	if _, ok := m.Get(testKey("d")); ok {
+		t.Error("oversized entry should not be cached")
+	}
+
+	stats := m.Stats()
+	want := Stats{Backend: "memory", Hits: 2, Misses: 3, Entries: 2, Bytes: 8, MaxBytes: 10}
+	if stats != want {
+		t.Errorf("Stats() = %+v, want %+v", stats, want)
+	}
+	if ratio := stats.HitRatio(); ratio != 0.4 {
+		t.Errorf("HitRatio() = %v, want 0.4", ratio)
+	}
+}
+
+func TestDisk(t *testing.T) {
+	dir := t.TempDir()
+	d, err := NewDisk(dir, 10)
+	if err != nil {
+		t.Fatalf("NewDisk() error = %v", err)
+	}
+
+	if err := d.Set("../../etc/passwd", []byte("x")); err == nil {
+		t.Error("Set() should reject non-hex keys")
+	}
+
+	_ = d.Set(testKey("a"), []byte("aaaa"))
+	_ = d.Set(testKey("b"), []byte("bbbb"))
+	// 确保 a 的访问时间晚于 b
+	time.Sleep(10 * time.Millisecond)
+	if data, ok := d.Get(testKey("a")); !ok || string(data) != "aaaa" {
+		t.Fatalf("Get(a) = %q, %v", data, ok)
+	}
+	_ = d.Set(testKey("c"), []byte("cccc"))
+	if _, ok := d.Get(testKey("b")); ok {
+		t.Error("b should be evicted")
+	}
+
+	// 重新打开目录时恢复索引
+	reopened, err := NewDisk(dir, 10)
+	if err != nil {
+		t.Fatalf("NewDisk() error = %v", err)
+	}
+	if stats := reopened.Stats(); stats.Entries != 2 || stats.Bytes != 8 {
+		t.Errorf("reopened Stats() = %+v, want 2 entries, 8 bytes", stats)
+	}
+	if data, ok := reopened.Get(testKey("c")); !ok || string(data) != "cccc" {
+		t.Errorf("reopened Get(c) = %q, %v", data, ok)
+	}
+}
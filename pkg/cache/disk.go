@@ -0,0 +1,171 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Disk 磁盘目录缓存,每个条目保存为 <dir>/<键前两位>/<键> 文件
+//
+// 文件的修改时间记录最近访问时间,超过容量时删除最久未访问的文件。
+// 多个进程可以共享同一目录 (写入使用临时文件 + 重命名)。
+type Disk struct {
+	counters
+
+	dir      string
+	maxBytes int64
+
+	mu    sync.Mutex
+	bytes int64
+	files map[string]diskEntry
+}
+
+// diskEntry 磁盘条目索引
+type diskEntry struct {
+	size     int64
+	accessed time.Time
+}
+
+// NewDisk 创建磁盘缓存,目录不存在时自动创建
+//
+// maxBytes 为 0 时不限制容量。启动时扫描目录建立索引。
+func NewDisk(dir string, maxBytes int64) (*Disk, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	d := &Disk{dir: dir, maxBytes: maxBytes, files: make(map[string]diskEntry)}
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || validKey(entry.Name()) != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		d.files[entry.Name()] = diskEntry{size: info.Size(), accessed: info.ModTime()}
+		d.bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan cache directory: %w", err)
+	}
+	return d, nil
+}
+
+// path 返回缓存条目的文件路径
+func (d *Disk) path(key string) string {
+	return filepath.Join(d.dir, key[:2], key)
+}
+
+// Get 实现 Cache 接口
+func (d *Disk) Get(key string) ([]byte, bool) {
+	if validKey(key) != nil {
+		d.record(false)
+		return nil, false
+	}
+
+	data, err := os.ReadFile(d.path(key))
+	d.record(err == nil)
+	if err != nil {
+		return nil, false
+	}
+
+	// 更新访问时间 (用于淘汰)
+	now := time.Now()
+	_ = os.Chtimes(d.path(key), now, now)
+	d.mu.Lock()
+	if _, ok := d.files[key]; !ok {
+		// 其他进程写入的条目
+		d.bytes += int64(len(data))
+	}
+	d.files[key] = diskEntry{size: int64(len(data)), accessed: now}
+	d.mu.Unlock()
+	return data, true
+}
+
+// Set 实现 Cache 接口
+func (d *Disk) Set(key string, data []byte) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	size := int64(len(data))
+	if d.maxBytes > 0 && size > d.maxBytes {
+		return nil
+	}
+
+	path := d.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if old, ok := d.files[key]; ok {
+		d.bytes -= old.size
+	}
+	d.files[key] = diskEntry{size: size, accessed: time.Now()}
+	d.bytes += size
+	d.evict()
+	return nil
+}
+
+// evict 删除最久未访问的文件直到不超过容量 (调用方持有锁)
+func (d *Disk) evict() {
+	if d.maxBytes <= 0 || d.bytes <= d.maxBytes {
+		return
+	}
+
+	keys := make([]string, 0, len(d.files))
+	for key := range d.files {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return d.files[keys[i]].accessed.Before(d.files[keys[j]].accessed)
+	})
+	for _, key := range keys {
+		if d.bytes <= d.maxBytes {
+			break
+		}
+		if err := os.Remove(d.path(key)); err != nil && !os.IsNotExist(err) {
+			continue
+		}
+		d.bytes -= d.files[key].size
+		delete(d.files, key)
+	}
+}
+
+// Stats 实现 Cache 接口
+func (d *Disk) Stats() Stats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return Stats{
+		Backend:  "disk",
+		Hits:     d.hits.Load(),
+		Misses:   d.misses.Load(),
+		Entries:  int64(len(d.files)),
+		Bytes:    d.bytes,
+		MaxBytes: d.maxBytes,
+	}
+}
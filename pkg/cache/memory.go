@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Memory 内存 LRU 缓存,按字节数限制容量
+type Memory struct {
+	counters
+
+	mu       sync.Mutex
+	maxBytes int64
+	bytes    int64
+	order    *list.List // 最近使用的条目在前
+	entries  map[string]*list.Element
+}
+
+// memoryEntry LRU 链表节点
+type memoryEntry struct {
+	key  string
+	data []byte
+}
+
+// NewMemory 创建内存 LRU 缓存
+//
+// maxBytes 为缓存数据的总字节数上限,必须大于 0;超过上限的单个结果不会被缓存。
+func NewMemory(maxBytes int64) *Memory {
+	return &Memory{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get 实现 Cache 接口
+func (m *Memory) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.entries[key]
+	m.record(ok)
+	if !ok {
+		return nil, false
+	}
+	m.order.MoveToFront(elem)
+	return elem.Value.(*memoryEntry).data, true
+}
+
+// Set 实现 Cache 接口
+func (m *Memory) Set(key string, data []byte) error {
+	size := int64(len(data))
+	if size > m.maxBytes {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.entries[key]; ok {
+		m.bytes -= int64(len(elem.Value.(*memoryEntry).data))
+		m.order.Remove(elem)
+	}
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, data: data})
+	m.bytes += size
+
+	// 淘汰最久未使用的条目
+	for m.bytes > m.maxBytes {
+		oldest := m.order.Back()
+		entry := oldest.Value.(*memoryEntry)
+		m.order.Remove(oldest)
+		delete(m.entries, entry.key)
+		m.bytes -= int64(len(entry.data))
+	}
+	return nil
+}
+
+// Stats 实现 Cache 接口
+func (m *Memory) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return Stats{
+		Backend:  "memory",
+		Hits:     m.hits.Load(),
+		Misses:   m.misses.Load(),
+		Entries:  int64(len(m.entries)),
+		Bytes:    m.bytes,
+		MaxBytes: m.maxBytes,
+	}
+}
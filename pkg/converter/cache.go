@@ -0,0 +1,236 @@
+package converter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/cache"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/fonts"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CacheVersion 渲染缓存版本
+//
+// 主题样式或模板变化导致相同输入的输出不同时递增;
+// 带 VCS 信息构建的程序还会以提交哈希区分版本。
+const CacheVersion = "1"
+
+// 缓存键的结果类型 (见 CacheKey)
+const (
+	CacheKindResult = "result" // Convert / ConvertWithResult 的结果
+	CacheKindSlides = "slides" // ConvertSlides 的逐页图片
+)
+
+// buildRevision 程序的构建版本 (提交哈希或模块版本)
+var buildRevision = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	revision := info.Main.Version
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			if setting.Value == "true" {
+				revision += "+dirty"
+			}
+		}
+	}
+	return revision
+})
+
+// CacheKey 计算渲染结果的缓存键 (SHA-256 十六进制)
+//
+// 键包含 Markdown 内容、全部转换选项、文档模板内容、已注册字体和程序版本;
+// 页眉页脚使用 {{date}} 占位符时还包含当天日期。
+func CacheKey(kind string, markdown []byte, opts *ConvertOptions) (string, error) {
+	// 文档模板无法序列化,单独计算摘要
+	plain := *opts
+	plain.DocumentTemplate = nil
+	optsJSON, err := json.Marshal(&plain)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode options: %w", err)
+	}
+
+	var document strings.Builder
+	if opts.DocumentTemplate != nil {
+		templates := opts.DocumentTemplate.Templates()
+		sort.Slice(templates, func(i, j int) bool { return templates[i].Name() < templates[j].Name() })
+		for _, t := range templates {
+			document.WriteString(t.Name())
+			if t.Tree != nil && t.Tree.Root != nil {
+				document.WriteString(t.Tree.Root.String())
+			}
+		}
+	}
+
+	var date string
+	if strings.Contains(opts.Header+opts.Footer, "{{date}}") {
+		date = time.Now().Format("2006-01-02")
+	}
+
+	h := sha256.New()
+	for _, part := range [][]byte{
+		[]byte(CacheVersion + "/" + buildRevision()),
+		[]byte(kind),
+		optsJSON,
+		[]byte(document.String()),
+		[]byte(fonts.Default.Fingerprint()),
+		[]byte(date),
+		markdown,
+	} {
+		// 写入长度前缀,避免不同字段拼接后产生相同输入
+		fmt.Fprintf(h, "%d:", len(part))
+		h.Write(part)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cachingConverter 带渲染缓存的转换器
+type cachingConverter struct {
+	cache        cache.Cache
+	newConverter func() (Converter, error)
+
+	mu    sync.Mutex
+	inner Converter
+}
+
+// NewCachingConverter 创建带渲染缓存的转换器
+//
+// newConverter 在第一次缓存未命中时调用 (例如 NewConverter),
+// 全部命中时不会启动浏览器。缓存读写失败不影响转换。
+func NewCachingConverter(c cache.Cache, newConverter func() (Converter, error)) Converter {
+	return &cachingConverter{cache: c, newConverter: newConverter}
+}
+
+// converter 返回实际执行转换的转换器 (按需创建)
+func (c *cachingConverter) converter() (Converter, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.inner == nil {
+		inner, err := c.newConverter()
+		if err != nil {
+			return nil, err
+		}
+		c.inner = inner
+	}
+	return c.inner, nil
+}
+
+// Convert 实现 Converter 接口
+func (c *cachingConverter) Convert(markdown []byte, opts *ConvertOptions) ([]byte, error) {
+	result, err := c.ConvertWithResult(markdown, opts)
+	if err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// ConvertWithResult 实现 Converter 接口
+func (c *cachingConverter) ConvertWithResult(markdown []byte, opts *ConvertOptions) (*ConvertResult, error) {
+	if opts == nil {
+		opts = DefaultConvertOptions()
+	}
+
+	var result ConvertResult
+	key, hit := c.lookup(CacheKindResult, markdown, opts, &result)
+	if hit {
+		opts.logger().Debug("render cache hit", "kind", CacheKindResult)
+		trace.SpanFromContext(opts.context()).AddEvent("render cache hit", trace.WithAttributes(attribute.String("cache.kind", CacheKindResult)))
+		return &result, nil
+	}
+
+	inner, err := c.converter()
+	if err != nil {
+		return nil, err
+	}
+	converted, err := inner.ConvertWithResult(markdown, opts)
+	if err != nil {
+		return nil, err
+	}
+	c.store(key, converted)
+	return converted, nil
+}
+
+// ConvertSlides 实现 Converter 接口
+func (c *cachingConverter) ConvertSlides(markdown []byte, opts *ConvertOptions) ([][]byte, error) {
+	if opts == nil {
+		opts = DefaultConvertOptions()
+	}
+
+	var pages [][]byte
+	key, hit := c.lookup(CacheKindSlides, markdown, opts, &pages)
+	if hit {
+		opts.logger().Debug("render cache hit", "kind", CacheKindSlides)
+		trace.SpanFromContext(opts.context()).AddEvent("render cache hit", trace.WithAttributes(attribute.String("cache.kind", CacheKindSlides)))
+		return pages, nil
+	}
+
+	inner, err := c.converter()
+	if err != nil {
+		return nil, err
+	}
+	pages, err = inner.ConvertSlides(markdown, opts)
+	if err != nil {
+		return nil, err
+	}
+	c.store(key, pages)
+	return pages, nil
+}
+
+// ConvertFile 实现 Converter 接口
+func (c *cachingConverter) ConvertFile(inputPath string, outputPath string, opts *ConvertOptions) error {
+	return convertFile(c, inputPath, outputPath, opts)
+}
+
+// Close 实现 Converter 接口
+func (c *cachingConverter) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.inner == nil {
+		return nil
+	}
+	return c.inner.Close()
+}
+
+// lookup 计算缓存键并读取缓存,命中时将结果解码到 value
+//
+// 键计算失败时返回空键 (不缓存本次结果)。
+func (c *cachingConverter) lookup(kind string, markdown []byte, opts *ConvertOptions, value any) (string, bool) {
+	key, err := CacheKey(kind, markdown, opts)
+	if err != nil {
+		return "", false
+	}
+	data, ok := c.cache.Get(key)
+	if !ok {
+		return key, false
+	}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(value); err != nil {
+		return key, false
+	}
+	return key, true
+}
+
+// store 编码并写入缓存
+func (c *cachingConverter) store(key string, value any) {
+	if key == "" {
+		return
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		return
+	}
+	_ = c.cache.Set(key, buf.Bytes())
+}
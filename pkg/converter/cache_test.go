@@ -0,0 +1,165 @@
+package converter
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/cache"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/parser"
+)
+
+// fakeConverter 记录调用次数的转换器 (不启动浏览器)
+type fakeConverter struct {
+	calls  int
+	closed bool
+}
+
+func (f *fakeConverter) Convert(markdown []byte, opts *ConvertOptions) ([]byte, error) {
+	result, err := f.ConvertWithResult(markdown, opts)
+	if err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+func (f *fakeConverter) ConvertWithResult(markdown []byte, opts *ConvertOptions) (*ConvertResult, error) {
+	f.calls++
+	return &ConvertResult{
+		Data:    append([]byte(opts.Theme+":"), markdown...),
+		Outline: []parser.Heading{{Level: 1, Text: "Title", ID: "title"}},
+	}, nil
+}
+
+func (f *fakeConverter) ConvertSlides(markdown []byte, opts *ConvertOptions) ([][]byte, error) {
+	f.calls++
+	return [][]byte{[]byte("slide-1"), []byte("slide-2")}, nil
+}
+
+func (f *fakeConverter) ConvertFile(inputPath string, outputPath string, opts *ConvertOptions) error {
+	return convertFile(f, inputPath, outputPath, opts)
+}
+
+func (f *fakeConverter) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestCachingConverter(t *testing.T) {
+	store := cache.NewMemory(1 << 20)
+	inner := &fakeConverter{}
+	created := 0
+	conv := NewCachingConverter(store, func() (Converter, error) {
+		created++
+		return inner, nil
+	})
+
+	markdown := []byte("# Title")
+	opts := DefaultConvertOptions()
+	for i := 0; i < 2; i++ {
+		result, err := conv.ConvertWithResult(markdown, opts)
+		if err != nil {
+			t.Fatalf("ConvertWithResult() error = %v", err)
+		}
+		if string(result.Data) != "light:# Title" || len(result.Outline) != 1 || result.Outline[0].ID != "title" {
+			t.Errorf("ConvertWithResult() = %+v", result)
+		}
+	}
+
+	// 不同选项不命中
+	dark := DefaultConvertOptions()
+	dark.Theme = "dark"
+	if data, _ := conv.Convert(markdown, dark); string(data) != "dark:# Title" {
+		t.Errorf("Convert(dark) = %q", data)
+	}
+
+	for i := 0; i < 2; i++ {
+		pages, err := conv.ConvertSlides(markdown, opts)
+		if err != nil || len(pages) != 2 || string(pages[1]) != "slide-2" {
+			t.Errorf("ConvertSlides() = %q, %v", pages, err)
+		}
+	}
+
+	if inner.calls != 3 {
+		t.Errorf("inner converter calls = %d, want 3", inner.calls)
+	}
+	if created != 1 {
+		t.Errorf("inner converter created %d times, want 1", created)
+	}
+	if stats := store.Stats(); stats.Hits != 2 || stats.Misses != 3 {
+		t.Errorf("Stats() = %+v, want 2 hits, 3 misses", stats)
+	}
+
+	if err := conv.Close(); err != nil || !inner.closed {
+		t.Errorf("Close() error = %v, closed = %v", err, inner.closed)
+	}
+}
+
+func TestCachingConverterAllHits(t *testing.T) {
+	store := cache.NewMemory(1 << 20)
+	first := NewCachingConverter(store, func() (Converter, error) { return &fakeConverter{}, nil })
+	if _, err := first.Convert([]byte("hello"), nil); err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	// 全部命中时不创建转换器 (不启动浏览器)
+	second := NewCachingConverter(store, func() (Converter, error) {
+		t.Fatal("converter should not be created on cache hit")
+		return nil, nil
+	})
+	if data, err := second.Convert([]byte("hello"), nil); err != nil || string(data) != "light:hello" {
+		t.Errorf("Convert() = %q, %v", data, err)
+	}
+	if err := second.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	markdown := []byte("# Title")
+	base := DefaultConvertOptions()
+	baseKey, err := CacheKey(CacheKindResult, markdown, base)
+	if err != nil {
+		t.Fatalf("CacheKey() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		kind     string
+		markdown string
+		modify   func(opts *ConvertOptions)
+		same     bool
+	}{
+		{"相同输入", CacheKindResult, "# Title", func(opts *ConvertOptions) {}, true},
+		{"不同内容", CacheKindResult, "# Other", func(opts *ConvertOptions) {}, false},
+		{"不同结果类型", CacheKindSlides, "# Title", func(opts *ConvertOptions) {}, false},
+		{"不同主题", CacheKindResult, "# Title", func(opts *ConvertOptions) { opts.Theme = "dark" }, false},
+		{"文档模板", CacheKindResult, "# Title", func(opts *ConvertOptions) {
+			opts.DocumentTemplate = template.Must(template.New("doc").Parse("{{.Content}}"))
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultConvertOptions()
+			tt.modify(opts)
+			key, err := CacheKey(tt.kind, []byte(tt.markdown), opts)
+			if err != nil {
+				t.Fatalf("CacheKey() error = %v", err)
+			}
+			if (key == baseKey) != tt.same {
+				t.Errorf("CacheKey() same = %v, want %v", key == baseKey, tt.same)
+			}
+		})
+	}
+
+	// 模板内容变化时键也变化
+	a := DefaultConvertOptions()
+	a.DocumentTemplate = template.Must(template.New("doc").Parse("<main>{{.Content}}</main>"))
+	b := DefaultConvertOptions()
+	b.DocumentTemplate = template.Must(template.New("doc").Parse("<article>{{.Content}}</article>"))
+	keyA, _ := CacheKey(CacheKindResult, markdown, a)
+	keyB, _ := CacheKey(CacheKindResult, markdown, b)
+	if keyA == keyB {
+		t.Error("CacheKey() should change with template content")
+	}
+}
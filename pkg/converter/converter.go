@@ -4,12 +4,18 @@
 package converter
 
 import (
+	"context"
 	"fmt"
+	"html/template"
+	"log/slog"
 	"os"
+	"sync"
 
 	"github.com/Cshiyuan/Gomarkdown2image/pkg/ai"
 	"github.com/Cshiyuan/Gomarkdown2image/pkg/parser"
 	"github.com/Cshiyuan/Gomarkdown2image/pkg/renderer"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Converter Markdown 到图片转换器接口
@@ -17,6 +23,13 @@ type Converter interface {
 	// Convert 将 Markdown 转换为图片
 	Convert(markdown []byte, opts *ConvertOptions) ([]byte, error)
 
+	// ConvertWithResult 将 Markdown 转换为图片,并返回文档大纲等附加信息
+	ConvertWithResult(markdown []byte, opts *ConvertOptions) (*ConvertResult, error)
+
+	// ConvertSlides 将 Markdown 幻灯片渲染为每页一张图片
+	// (ImageFormat 为 pdf 时返回仅包含一个多页 PDF 的切片)
+	ConvertSlides(markdown []byte, opts *ConvertOptions) ([][]byte, error)
+
 	// ConvertFile 转换 Markdown 文件为图片文件
 	ConvertFile(inputPath string, outputPath string, opts *ConvertOptions) error
 
@@ -24,21 +37,88 @@ type Converter interface {
 	Close() error
 }
 
+// 布局模式
+const (
+	// LayoutDocument 文档布局 (默认): 渲染完整的 Markdown 文档
+	LayoutDocument = "document"
+
+	// LayoutCard 社交卡片布局: 使用 front matter 渲染固定尺寸的 Open Graph 图片
+	LayoutCard = "card"
+
+	// LayoutSnippet 代码片段布局: 将源代码渲染为带窗口框的高亮代码图片
+	LayoutSnippet = "snippet"
+
+	// LayoutSlides 幻灯片布局: 按 "---" 拆分为固定宽高比的幻灯片
+	LayoutSlides = "slides"
+)
+
+// ConvertResult 转换结果
+type ConvertResult struct {
+	Data    []byte           // 图片 (或 PDF) 字节数组
+	Outline []parser.Heading // 文档标题大纲 (仅文档布局)
+}
+
 // ConvertOptions 转换选项
 type ConvertOptions struct {
 	// HTML 模板选项
-	Title      string // 页面标题
-	Theme      string // 主题 (light, dark)
-	CustomCSS  string // 自定义 CSS
-	Width      int    // 页面宽度
-	FontSize   int    // 字体大小
-	FontFamily string // 字体族
+	Title       string // 页面标题
+	Theme       string // 主题 (light, dark)
+	CustomCSS   string // 自定义 CSS
+	Width       int    // 页面宽度
+	FontSize    int    // 字体大小
+	FontFamily  string // 字体族
+	Transparent bool   // 透明背景 (仅 PNG/WebP)
+	Lang        string // 文档语言 (BCP 47,为空时使用 front matter 的 lang,默认 zh-CN)
+	Dir         string // 文字方向: auto (默认,根据语言和内容检测), ltr, rtl; 为空时使用 front matter 的 dir
+
+	// DocumentTemplate 自定义 html/template 文档模板 (为空时使用默认模板,见 parser.DocumentData)
+	DocumentTemplate *template.Template
+
+	// 目录选项 (文档布局): 内容中的 [TOC] 标记段落总是替换为目录
+	TOC      bool // 无 [TOC] 标记时在文档开头插入目录
+	TOCDepth int  // 目录包含的最大标题级别 (1-6, 默认 3)
+
+	// Extensions 启用的 Markdown 扩展 (见 parser.AvailableExtensions),
+	// 在默认扩展基础上追加,"-名称" 表示禁用; 使用目录或章节截图时总是启用 heading-ids
+	Extensions []string
+
+	// 页眉页脚 (文档和幻灯片布局): 纯文本或 HTML 片段,
+	// 支持 {{title}}、{{date}}、{{author}}、{{url}}、{{page}}、{{pages}} 占位符
+	Header    string // 页眉
+	Footer    string // 页脚
+	BrandLogo string // 页眉 Logo 图片 URL (http(s) 或 data:image/)
+
+	// 水印选项 (所有布局,以 CSS 覆盖层绘制)
+	WatermarkText     string  // 文字水印
+	WatermarkImage    string  // 图片水印 URL (http(s) 或 data:image/,优先于文字)
+	WatermarkPosition string  // 位置: center (默认), top-left, top-right, bottom-left, bottom-right
+	WatermarkOpacity  float64 // 不透明度 0-1 (默认 0.15)
+	WatermarkRotation int     // 旋转角度 (默认 -30)
+	WatermarkTile     bool    // 平铺 (忽略位置)
+
+	// 布局选项
+	Layout     string // 布局模式: "document" (默认), "card", "snippet", "slides"
+	CardPreset string // 社交卡片尺寸预设: "og" (默认), "twitter", "square"
+	CardLogo   string // 社交卡片 Logo URL (覆盖 front matter 中的 logo)
+
+	// 代码片段选项 (Layout = "snippet")
+	SnippetLanguage   string // 代码语言 (为空时从围栏代码块或内容自动识别)
+	SnippetStyle      string // Chroma 样式名称 (默认 monokai)
+	SnippetTitle      string // 窗口标题 (可选)
+	SnippetWindow     bool   // 是否显示窗口标题栏
+	SnippetHighlight  string // 高亮行, 如 "1,3-5"
+	SnippetBackground string // 背景颜色或渐变 CSS (默认紫色渐变)
+
+	// 幻灯片选项 (Layout = "slides")
+	SlideAspect string // 幻灯片宽高比: "16:9" (默认) 或 "4:3" (front matter 的 aspect 优先)
 
 	// 渲染选项
 	ImageFormat      renderer.ImageFormat // 图片格式
 	ImageQuality     int                  // 图片质量
 	FullPage         bool                 // 全页截图
 	DevicePixelRatio float64              // 设备像素比
+	Selector         string               // 元素截图 CSS 选择器 (可选)
+	Section          string               // 章节截图标题锚点 ID (可选)
 
 	// AI 增强选项 (新增)
 	ParserMode       string                 // 解析器模式: "traditional" (默认) 或 "ai"
@@ -49,6 +129,12 @@ type ConvertOptions struct {
 	AIPromptTemplate string                 // 提示词模板: "enhance", "translate", "format" 等
 	AICustomPrompt   string                 // 自定义提示词 (覆盖模板)
 	AIPromptData     map[string]interface{} // 提示词模板数据
+
+	// Logger 日志记录器 (如带有请求 ID 的记录器,为 nil 时使用 slog.Default(),不影响输出)
+	Logger *slog.Logger `json:"-"`
+
+	// Context 链路追踪 context (转换的 span 以其中的 span 为父级;取消时中止进行中的 AI 调用,不中止渲染)
+	Context context.Context `json:"-"`
 }
 
 // DefaultConvertOptions 返回默认转换选项
@@ -59,10 +145,20 @@ func DefaultConvertOptions() *ConvertOptions {
 		Width:            1200,
 		FontSize:         16,
 		FontFamily:       "Arial, sans-serif",
+		Layout:           LayoutDocument,
+		CardPreset:       parser.DefaultCardPreset,
+		SnippetStyle:     parser.DefaultCodeStyle,
+		SnippetWindow:    true,
+		SlideAspect:      parser.DefaultSlideAspect,
+		TOCDepth:         parser.DefaultTOCDepth,
 		ImageFormat:      renderer.FormatPNG,
 		ImageQuality:     90,
 		FullPage:         true,
 		DevicePixelRatio: 1.0,
+		// 水印默认值
+		WatermarkPosition: parser.WatermarkCenter,
+		WatermarkOpacity:  parser.DefaultWatermarkOpacity,
+		WatermarkRotation: parser.DefaultWatermarkRotation,
 		// AI 默认值
 		ParserMode:       "traditional", // 默认使用传统模式
 		AIProvider:       "gemini",
@@ -77,6 +173,11 @@ func DefaultConvertOptions() *ConvertOptions {
 type DefaultConverter struct {
 	parser   parser.Parser
 	renderer renderer.Renderer
+
+	// lifetime 在 Close 时取消,用于中止进行中的 AI 调用 (见 lifetimeContext)
+	lifetimeOnce sync.Once
+	lifetime     context.Context
+	stop         context.CancelFunc
 }
 
 // NewConverter 创建新的转换器
@@ -103,8 +204,9 @@ func NewConverter() (Converter, error) {
 // Convert 将 Markdown 字节数组转换为图片字节数组
 //
 // 工作流程:
+//  0. 分离 front matter;卡片和代码片段布局直接渲染各自的模板
 //  1. 根据 ParserMode 创建对应的 Parser (传统/AI)
-//  2. Markdown → HTML (使用 Parser)
+//  2. Markdown → HTML (使用 Parser,收集标题大纲并插入目录)
 //  3. HTML → 完整 HTML 文档 (应用模板)
 //  4. HTML 文档 → 图片 (使用 Renderer)
 //
@@ -116,50 +218,83 @@ func NewConverter() (Converter, error) {
 //   - []byte: 图片字节数组
 //   - error: 转换错误(如有)
 func (c *DefaultConverter) Convert(markdown []byte, opts *ConvertOptions) ([]byte, error) {
+	result, err := c.ConvertWithResult(markdown, opts)
+	if err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// ConvertWithResult 将 Markdown 转换为图片,并返回文档大纲
+//
+// 流程与 Convert 相同;文档布局会收集标题大纲,并按 TOC 选项插入目录。
+func (c *DefaultConverter) ConvertWithResult(markdown []byte, opts *ConvertOptions) (result *ConvertResult, err error) {
 	if opts == nil {
 		opts = DefaultConvertOptions()
 	}
 
-	// 步骤 1: 根据 ParserMode 创建 Parser
-	var currentParser parser.Parser
-	var err error
-
-	if opts.ParserMode == "ai" {
-		// 创建 AI Parser
-		currentParser, err = c.createAIParser(opts)
+	ctx, span := tracer.Start(opts.context(), "Converter.Convert", trace.WithAttributes(convertAttributes(markdown, opts)...))
+	defer func() { endSpan(span, err) }()
+
+	// 步骤 0: 分离 front matter 并按布局分派
+	frontMatter, body := parser.ExtractFrontMatter(markdown)
+
+	switch opts.Layout {
+	case "", LayoutDocument:
+		// 文档布局,继续以下流程
+	case LayoutCard:
+		return newResult(c.convertCard(ctx, frontMatter, opts))
+	case LayoutSnippet:
+		// 源代码可能以 "---" 开头 (如 YAML),使用原始输入
+		return newResult(c.convertSnippet(ctx, markdown, opts))
+	case LayoutSlides:
+		// 单个输出只能是多页 PDF,逐页图片请使用 ConvertSlides
+		if opts.ImageFormat != renderer.FormatPDF {
+			return nil, fmt.Errorf("slides layout renders one image per slide, use ConvertSlides or pdf format")
+		}
+		slideOpts := *opts
+		slideOpts.Context = ctx
+		pages, err := c.ConvertSlides(markdown, &slideOpts)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create AI parser: %w", err)
+			return nil, err
 		}
+		return &ConvertResult{Data: pages[0]}, nil
+	default:
+		return nil, fmt.Errorf("unsupported layout: %s", opts.Layout)
+	}
 
-		// 如果是 AI Parser Provider,需要在完成后关闭
-		if closer, ok := currentParser.(interface{ Close() error }); ok {
-			defer closer.Close()
-		}
-	} else {
-		// 使用传统 Parser
-		currentParser = c.parser
+	// 步骤 1-2: 创建 Parser,解析 Markdown → HTML,收集标题大纲并插入目录
+	// (AI Parser 在解析阶段内创建,AI 调用的 span 以解析阶段为父级)
+	st := startStage(ctx, opts, StageParse, attribute.String("parser.mode", opts.ParserMode))
+	currentParser, release, err := c.resolveParser(st.ctx, opts)
+	if err != nil {
+		st.fail(err)
+		return nil, err
 	}
+	defer release()
 
-	// 步骤 2: 解析 Markdown → HTML
-	htmlContent, err := currentParser.Parse(markdown)
+	htmlContent, outline, err := parseWithOutline(currentParser, body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse markdown: %w", err)
+		err = fmt.Errorf("failed to parse markdown: %w", err)
+		st.fail(err)
+		return nil, err
 	}
+	toc := parser.RenderTOC(outline, opts.TOCDepth)
+	htmlContent = parser.InsertTOC(htmlContent, toc, opts.TOC)
+	st.end()
 
 	// 步骤 3: 包装为完整的 HTML 文档
-	tmpl := &parser.HTMLTemplate{
-		Title:      opts.Title,
-		Theme:      opts.Theme,
-		CustomCSS:  opts.CustomCSS,
-		Width:      opts.Width,
-		FontSize:   opts.FontSize,
-		FontFamily: opts.FontFamily,
-	}
+	st = startStage(ctx, opts, StageWrap)
+	tmpl := newHTMLTemplate(opts, frontMatter)
+	tmpl.TOC = toc
 
 	fullHTML, err := parser.WrapHTML(string(htmlContent), tmpl)
 	if err != nil {
-		return nil, fmt.Errorf("failed to wrap HTML: %w", err)
+		err = fmt.Errorf("failed to wrap HTML: %w", err)
+		st.fail(err)
+		return nil, err
 	}
+	st.end()
 
 	// 步骤 4: 渲染 HTML → 图片
 	renderOpts := &renderer.RenderOptions{
@@ -169,18 +304,421 @@ func (c *DefaultConverter) Convert(markdown []byte, opts *ConvertOptions) ([]byt
 		Quality:          opts.ImageQuality,
 		FullPage:         opts.FullPage,
 		DevicePixelRatio: opts.DevicePixelRatio,
+		Selector:         opts.Selector,
+		Section:          opts.Section,
+		Transparent:      opts.Transparent,
+		Logger:           opts.logger(),
 	}
 
+	st = startStage(ctx, opts, StageRender, renderAttributes(renderOpts)...)
 	imageData, err := c.renderer.RenderToImage(fullHTML, renderOpts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to render image: %w", err)
+		err = fmt.Errorf("failed to render image: %w", err)
+		st.fail(err)
+		return nil, err
+	}
+	st.end()
+
+	return &ConvertResult{Data: imageData, Outline: outline}, nil
+}
+
+// newResult 将单一输出包装为转换结果
+func newResult(data []byte, err error) (*ConvertResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &ConvertResult{Data: data}, nil
+}
+
+// convertAttributes 返回转换 span 的属性
+func convertAttributes(markdown []byte, opts *ConvertOptions) []attribute.KeyValue {
+	layout := opts.Layout
+	if layout == "" {
+		layout = LayoutDocument
+	}
+	return []attribute.KeyValue{
+		attribute.String("convert.layout", layout),
+		attribute.String("convert.format", string(opts.ImageFormat)),
+		attribute.String("parser.mode", opts.ParserMode),
+		attribute.Int("markdown.size", len(markdown)),
+	}
+}
+
+// renderAttributes 返回渲染阶段 span 的属性
+func renderAttributes(opts *renderer.RenderOptions) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("render.format", string(opts.Format)),
+		attribute.Int("render.width", opts.Width),
+		attribute.Int("render.height", opts.Height),
+		attribute.Float64("render.device_pixel_ratio", opts.DevicePixelRatio),
+	}
+}
+
+// parseWithOutline 解析 Markdown,Parser 支持时同时返回标题大纲
+func parseWithOutline(p parser.Parser, markdown []byte) ([]byte, []parser.Heading, error) {
+	if op, ok := p.(parser.OutlineParser); ok {
+		return op.ParseWithOutline(markdown)
+	}
+	html, err := p.Parse(markdown)
+	return html, nil, err
+}
+
+// resolveParser 根据 ParserMode 返回本次转换使用的 Parser
+//
+// 返回的 release 函数用于释放 AI Parser 持有的资源,调用方应在转换结束后调用。
+// ctx 为 AI 调用 span 的父级。
+func (c *DefaultConverter) resolveParser(ctx context.Context, opts *ConvertOptions) (parser.Parser, func(), error) {
+	if opts.ParserMode != "ai" {
+		// 使用传统 Parser (默认扩展时复用转换器自带的 Parser)
+		if len(opts.Extensions) == 0 {
+			return c.parser, func() {}, nil
+		}
+		p, err := parser.CachedGoldmarkParser(opts.markdownExtensions())
+		if err != nil {
+			return nil, nil, err
+		}
+		return p, func() {}, nil
+	}
+
+	// 创建 AI Parser: AI 调用在 ctx 取消或转换器关闭时中止
+	aiCtx, cancel := context.WithCancel(ctx)
+	stopAfter := context.AfterFunc(c.lifetimeContext(), cancel)
+	aiParser, err := c.createAIParser(aiCtx, opts)
+	if err != nil {
+		stopAfter()
+		cancel()
+		return nil, nil, fmt.Errorf("failed to create AI parser: %w", err)
+	}
+
+	// 如果是 AI Parser Provider,需要在完成后关闭
+	release := func() {
+		stopAfter()
+		cancel()
+	}
+	if closer, ok := aiParser.(interface{ Close() error }); ok {
+		release = func() {
+			stopAfter()
+			cancel()
+			_ = closer.Close()
+		}
+	}
+	return aiParser, release, nil
+}
+
+// markdownExtensions 返回本次转换启用的 Markdown 扩展 (目录和章节截图依赖标题锚点 ID)
+func (opts *ConvertOptions) markdownExtensions() []string {
+	if opts.TOC || opts.Section != "" {
+		return append(append([]string{}, opts.Extensions...), parser.ExtHeadingIDs)
+	}
+	return opts.Extensions
+}
+
+// newHTMLTemplate 根据转换选项和 front matter 构建 HTML 模板配置
+func newHTMLTemplate(opts *ConvertOptions, fm parser.FrontMatter) *parser.HTMLTemplate {
+	return &parser.HTMLTemplate{
+		Title:       opts.Title,
+		Theme:       opts.Theme,
+		CustomCSS:   opts.CustomCSS,
+		Width:       opts.Width,
+		FontSize:    opts.FontSize,
+		FontFamily:  opts.FontFamily,
+		Lang:        opts.lang(fm),
+		Dir:         opts.dir(fm),
+		Transparent: opts.Transparent,
+		FrontMatter: fm,
+		Header:      opts.Header,
+		Footer:      opts.Footer,
+		Logo:        opts.BrandLogo,
+		Watermark:   opts.Watermark(),
+		Document:    opts.DocumentTemplate,
+	}
+}
+
+// lang 返回文档语言: 选项优先,其次为 front matter 的 lang
+func (opts *ConvertOptions) lang(fm parser.FrontMatter) string {
+	if opts.Lang != "" {
+		return opts.Lang
+	}
+	return fm.String("lang")
+}
+
+// dir 返回文字方向: 选项优先,其次为 front matter 的 dir
+func (opts *ConvertOptions) dir(fm parser.FrontMatter) string {
+	if opts.Dir != "" && opts.Dir != parser.DirAuto {
+		return opts.Dir
+	}
+	return fm.String("dir")
+}
+
+// Watermark 根据水印选项构建水印配置,未设置文字和图片时返回 nil
+func (opts *ConvertOptions) Watermark() *parser.Watermark {
+	if opts.WatermarkText == "" && opts.WatermarkImage == "" {
+		return nil
+	}
+	return &parser.Watermark{
+		Text:     opts.WatermarkText,
+		Image:    opts.WatermarkImage,
+		Position: opts.WatermarkPosition,
+		Opacity:  opts.WatermarkOpacity,
+		Rotation: opts.WatermarkRotation,
+		Tile:     opts.WatermarkTile,
+	}
+}
+
+// ConvertSlides 将 Markdown 按 "---" 拆分为幻灯片并逐页渲染
+//
+// front matter 中的 theme、aspect、title 会覆盖对应选项;
+// HTML 注释作为演讲者备注不会出现在幻灯片中。
+//
+// 参数:
+//   - markdown: Markdown 文本字节数组
+//   - opts: 转换选项 (ImageFormat 为 pdf 时输出单个多页 PDF)
+//
+// 返回:
+//   - [][]byte: 每张幻灯片的图片 (或仅包含一个 PDF)
+//   - error: 转换错误(如有)
+func (c *DefaultConverter) ConvertSlides(markdown []byte, opts *ConvertOptions) (images [][]byte, err error) {
+	if opts == nil {
+		opts = DefaultConvertOptions()
+	}
+
+	ctx, span := tracer.Start(opts.context(), "Converter.ConvertSlides", trace.WithAttributes(convertAttributes(markdown, opts)...))
+	defer func() { endSpan(span, err) }()
+
+	frontMatter, body := parser.ExtractFrontMatter(markdown)
+
+	// front matter 设置幻灯片主题
+	deckOpts := *opts
+	if v := frontMatter.String("theme"); v != "" {
+		deckOpts.Theme = v
+	}
+	if v := frontMatter.String("aspect"); v != "" {
+		deckOpts.SlideAspect = v
+	}
+	if v := frontMatter.String("title"); v != "" {
+		deckOpts.Title = v
+	}
+
+	size, err := parser.LookupSlideAspect(deckOpts.SlideAspect)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := parser.SplitSlides(body)
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no slides found")
 	}
 
+	st := startStage(ctx, opts, StageParse,
+		attribute.String("parser.mode", deckOpts.ParserMode), attribute.Int("slides.count", len(sources)))
+	currentParser, release, err := c.resolveParser(st.ctx, &deckOpts)
+	if err != nil {
+		st.fail(err)
+		return nil, err
+	}
+	defer release()
+
+	slides := make([]string, 0, len(sources))
+	for i, source := range sources {
+		htmlContent, err := currentParser.Parse(source)
+		if err != nil {
+			err = fmt.Errorf("failed to parse slide %d: %w", i+1, err)
+			st.fail(err)
+			return nil, err
+		}
+		slides = append(slides, string(htmlContent))
+	}
+	st.end()
+
+	tmpl := newHTMLTemplate(&deckOpts, frontMatter)
+	renderOpts := &renderer.RenderOptions{
+		Width:            size.Width,
+		Height:           size.Height,
+		Format:           deckOpts.ImageFormat,
+		Quality:          deckOpts.ImageQuality,
+		FullPage:         false,
+		DevicePixelRatio: deckOpts.DevicePixelRatio,
+		Transparent:      deckOpts.Transparent,
+		Logger:           deckOpts.logger(),
+	}
+
+	// 多页 PDF: 所有幻灯片放在同一文档中,按 CSS 分页打印
+	if deckOpts.ImageFormat == renderer.FormatPDF {
+		st := startStage(ctx, opts, StageWrap)
+		deckHTML, err := parser.WrapSlidesHTML(slides, tmpl, size)
+		if err != nil {
+			err = fmt.Errorf("failed to wrap slides HTML: %w", err)
+			st.fail(err)
+			return nil, err
+		}
+		st.end()
+
+		st = startStage(ctx, opts, StageRender, renderAttributes(renderOpts)...)
+		pdf, err := c.renderer.RenderToPDF(deckHTML, renderOpts)
+		if err != nil {
+			err = fmt.Errorf("failed to render slides PDF: %w", err)
+			st.fail(err)
+			return nil, err
+		}
+		st.end()
+		return [][]byte{pdf}, nil
+	}
+
+	// 逐页截图
+	images = make([][]byte, 0, len(slides))
+	for i, slide := range slides {
+		page := attribute.Int("slide.number", i+1)
+		st := startStage(ctx, opts, StageWrap, page)
+		slideHTML, err := parser.WrapSlideHTML(slide, i+1, len(slides), tmpl, size)
+		if err != nil {
+			err = fmt.Errorf("failed to wrap slide %d: %w", i+1, err)
+			st.fail(err)
+			return nil, err
+		}
+		st.end()
+
+		st = startStage(ctx, opts, StageRender, append(renderAttributes(renderOpts), page)...)
+		imageData, err := c.renderer.RenderToImage(slideHTML, renderOpts)
+		if err != nil {
+			err = fmt.Errorf("failed to render slide %d: %w", i+1, err)
+			st.fail(err)
+			return nil, err
+		}
+		st.end()
+		images = append(images, imageData)
+	}
+
+	return images, nil
+}
+
+// convertCard 使用 front matter 渲染社交卡片图片
+//
+// 标题缺省时使用 opts.Title,Logo 以 opts.CardLogo 优先。
+func (c *DefaultConverter) convertCard(ctx context.Context, fm parser.FrontMatter, opts *ConvertOptions) ([]byte, error) {
+	size, err := parser.LookupCardPreset(opts.CardPreset)
+	if err != nil {
+		return nil, err
+	}
+
+	title := fm.String("title")
+	if title == "" {
+		title = opts.Title
+	}
+	logo := opts.CardLogo
+	if logo == "" {
+		logo = fm.String("logo")
+	}
+
+	st := startStage(ctx, opts, StageWrap)
+	cardHTML, err := parser.WrapCardHTML(&parser.CardTemplate{
+		Title:       title,
+		Description: fm.String("description"),
+		Author:      fm.String("author"),
+		Date:        fm.String("date"),
+		Logo:        logo,
+		Theme:       opts.Theme,
+		FontFamily:  opts.FontFamily,
+		Lang:        opts.lang(fm),
+		Dir:         opts.dir(fm),
+		Transparent: opts.Transparent,
+		Size:        size,
+		Watermark:   opts.Watermark(),
+	})
+	if err != nil {
+		err = fmt.Errorf("failed to wrap card HTML: %w", err)
+		st.fail(err)
+		return nil, err
+	}
+	st.end()
+
+	// 卡片为固定尺寸,使用视口截图
+	renderOpts := &renderer.RenderOptions{
+		Width:            size.Width,
+		Height:           size.Height,
+		Format:           opts.ImageFormat,
+		Quality:          opts.ImageQuality,
+		FullPage:         false,
+		DevicePixelRatio: opts.DevicePixelRatio,
+		Transparent:      opts.Transparent,
+		Logger:           opts.logger(),
+	}
+
+	st = startStage(ctx, opts, StageRender, renderAttributes(renderOpts)...)
+	imageData, err := c.renderer.RenderToImage(cardHTML, renderOpts)
+	if err != nil {
+		err = fmt.Errorf("failed to render card: %w", err)
+		st.fail(err)
+		return nil, err
+	}
+	st.end()
+
+	return imageData, nil
+}
+
+// convertSnippet 将源代码 (或单个围栏代码块) 渲染为代码片段图片
+//
+// 围栏代码块的语言仅在 opts.SnippetLanguage 为空时使用。
+func (c *DefaultConverter) convertSnippet(ctx context.Context, source []byte, opts *ConvertOptions) ([]byte, error) {
+	code := string(source)
+	lang := opts.SnippetLanguage
+	if fenceLang, fenced, ok := parser.ExtractFencedCode(code); ok {
+		code = fenced
+		if lang == "" {
+			lang = fenceLang
+		}
+	}
+
+	highlightLines, err := parser.ParseLineRanges(opts.SnippetHighlight)
+	if err != nil {
+		return nil, err
+	}
+
+	st := startStage(ctx, opts, StageWrap)
+	snippetHTML, err := parser.WrapSnippetHTML(code, &parser.SnippetTemplate{
+		Language:       lang,
+		Style:          opts.SnippetStyle,
+		Title:          opts.SnippetTitle,
+		Window:         opts.SnippetWindow,
+		HighlightLines: highlightLines,
+		Background:     opts.SnippetBackground,
+		FontSize:       opts.FontSize,
+		Lang:           opts.Lang,
+		Dir:            opts.Dir,
+		Transparent:    opts.Transparent,
+		Watermark:      opts.Watermark(),
+	})
+	if err != nil {
+		err = fmt.Errorf("failed to wrap snippet HTML: %w", err)
+		st.fail(err)
+		return nil, err
+	}
+	st.end()
+
+	// 截取代码片段外框,宽度随代码内容收缩
+	renderOpts := &renderer.RenderOptions{
+		Width:            opts.Width,
+		Format:           opts.ImageFormat,
+		Quality:          opts.ImageQuality,
+		DevicePixelRatio: opts.DevicePixelRatio,
+		Selector:         parser.SnippetSelector,
+		Transparent:      opts.Transparent,
+		Logger:           opts.logger(),
+	}
+
+	st = startStage(ctx, opts, StageRender, renderAttributes(renderOpts)...)
+	imageData, err := c.renderer.RenderToImage(snippetHTML, renderOpts)
+	if err != nil {
+		err = fmt.Errorf("failed to render snippet: %w", err)
+		st.fail(err)
+		return nil, err
+	}
+	st.end()
+
 	return imageData, nil
 }
 
 // createAIParser 根据配置创建 AI Parser
-func (c *DefaultConverter) createAIParser(opts *ConvertOptions) (parser.Parser, error) {
+func (c *DefaultConverter) createAIParser(ctx context.Context, opts *ConvertOptions) (parser.Parser, error) {
 	// 构建 AI 配置
 	aiConfig := &ai.Config{
 		Provider:   ai.ProviderType(opts.AIProvider),
@@ -198,6 +736,9 @@ func (c *DefaultConverter) createAIParser(opts *ConvertOptions) (parser.Parser,
 		AIPromptTemplate: opts.AIPromptTemplate,
 		AIPromptData:     opts.AIPromptData,
 		CustomPrompt:     opts.AICustomPrompt,
+		Extensions:       opts.markdownExtensions(),
+		Logger:           opts.logger(),
+		Context:          ctx,
 	}
 
 	// 创建 Provider
@@ -220,6 +761,11 @@ func (c *DefaultConverter) createAIParser(opts *ConvertOptions) (parser.Parser,
 // 返回:
 //   - error: 转换或文件操作错误(如有)
 func (c *DefaultConverter) ConvertFile(inputPath string, outputPath string, opts *ConvertOptions) error {
+	return convertFile(c, inputPath, outputPath, opts)
+}
+
+// convertFile 读取 Markdown 文件,转换后写入图片文件
+func convertFile(c Converter, inputPath string, outputPath string, opts *ConvertOptions) error {
 	// 读取 Markdown 文件
 	markdown, err := os.ReadFile(inputPath)
 	if err != nil {
@@ -241,9 +787,21 @@ func (c *DefaultConverter) ConvertFile(inputPath string, outputPath string, opts
 }
 
 // Close 关闭转换器,释放资源
+//
+// 进行中的 AI 调用会被取消。
 func (c *DefaultConverter) Close() error {
+	c.lifetimeContext()
+	c.stop()
 	if c.renderer != nil {
 		return c.renderer.Close()
 	}
 	return nil
 }
+
+// lifetimeContext 返回在转换器关闭时取消的 context
+func (c *DefaultConverter) lifetimeContext() context.Context {
+	c.lifetimeOnce.Do(func() {
+		c.lifetime, c.stop = context.WithCancel(context.Background())
+	})
+	return c.lifetime
+}
@@ -0,0 +1,166 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/parser"
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/renderer"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// fakeRenderer 返回固定内容的渲染器 (不启动浏览器)
+type fakeRenderer struct {
+	err error
+}
+
+func (f *fakeRenderer) RenderToImage(html string, opts *renderer.RenderOptions) ([]byte, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []byte("image"), nil
+}
+
+func (f *fakeRenderer) RenderToPDF(html string, opts *renderer.RenderOptions) ([]byte, error) {
+	return []byte("pdf"), f.err
+}
+
+func (f *fakeRenderer) RenderToFile(html string, outputPath string, opts *renderer.RenderOptions) error {
+	return f.err
+}
+
+func (f *fakeRenderer) Close() error {
+	return nil
+}
+
+// spanExporter 测试使用的内存 span 导出器 (全局 TracerProvider 只设置一次)
+var spanExporter = sync.OnceValue(func() *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	return exporter
+})
+
+// TestConvertTracing 测试转换各阶段的 span 及其父子关系
+func TestConvertTracing(t *testing.T) {
+	exporter := spanExporter()
+
+	// span 按结束顺序导出
+	wantSpans := []string{StageParse, StageWrap, StageRender, "Converter.Convert", "request"}
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"转换成功", nil},
+		{"渲染失败", errors.New("browser crashed")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			conv := &DefaultConverter{parser: parser.NewGoldmarkParser(), renderer: &fakeRenderer{err: tt.err}}
+
+			ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+			opts := DefaultConvertOptions()
+			opts.Context = ctx
+			_, err := conv.Convert([]byte("# Title\n\nBody"), opts)
+			parent.End()
+			if (err != nil) != (tt.err != nil) {
+				t.Fatalf("Convert() error = %v, want %v", err, tt.err)
+			}
+
+			spans := exporter.GetSpans()
+			if len(spans) != len(wantSpans) {
+				t.Fatalf("got %d spans, want %d", len(spans), len(wantSpans))
+			}
+			byName := make(map[string]tracetest.SpanStub)
+			for _, s := range spans {
+				byName[s.Name] = s
+			}
+			for i, name := range wantSpans {
+				if spans[i].Name != name {
+					t.Errorf("span %d = %q, want %q", i, spans[i].Name, name)
+				}
+			}
+
+			root := byName["Converter.Convert"]
+			if root.Parent.SpanID() != parent.SpanContext().SpanID() {
+				t.Error("Converter.Convert span is not a child of the caller span")
+			}
+			for _, stage := range []string{StageParse, StageWrap, StageRender} {
+				if byName[stage].Parent.SpanID() != root.SpanContext.SpanID() {
+					t.Errorf("%s span is not a child of Converter.Convert", stage)
+				}
+			}
+
+			wantStatus := codes.Unset
+			if tt.err != nil {
+				wantStatus = codes.Error
+			}
+			for _, name := range []string{StageRender, "Converter.Convert"} {
+				if got := byName[name].Status.Code; got != wantStatus {
+					t.Errorf("%s status = %v, want %v", name, got, wantStatus)
+				}
+			}
+		})
+	}
+}
+
+// TestCloseCancelsAICall 测试关闭转换器时中止进行中的 AI 调用
+func TestCloseCancelsAICall(t *testing.T) {
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 读完请求体后才能感知客户端断开
+		_, _ = io.Copy(io.Discard, r.Body)
+		close(started)
+		select {
+		case <-r.Context().Done():
+			close(cancelled)
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+	t.Setenv("OLLAMA_HOST", server.URL)
+
+	conv := &DefaultConverter{parser: parser.NewGoldmarkParser(), renderer: &fakeRenderer{}}
+	opts := DefaultConvertOptions()
+	opts.ParserMode = "ai"
+	opts.AIProvider = "ollama"
+	opts.AIModel = "llama3"
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := conv.Convert([]byte("# Title"), opts)
+		done <- err
+	}()
+
+	select {
+	case <-started:
+	case err := <-done:
+		t.Fatalf("Convert() returned before calling the AI provider: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("AI provider was not called")
+	}
+	if err := conv.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("AI call was not cancelled by Close()")
+	}
+	// AI 调用中止后回退到传统解析
+	if err := <-done; err != nil {
+		t.Errorf("Convert() error = %v, want fallback to traditional parsing", err)
+	}
+}
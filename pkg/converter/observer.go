@@ -0,0 +1,93 @@
+package converter
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 转换阶段 (见 Observer)
+const (
+	StageParse  = "parse"  // Markdown → HTML (AI 模式包含 AI 调用,AI 耗时另见 ai.Observer)
+	StageWrap   = "wrap"   // HTML → 完整 HTML 文档 (应用模板)
+	StageRender = "render" // HTML 文档 → 图片 (浏览器渲染)
+)
+
+// Observer 转换阶段观察者 (用于统计各阶段耗时)
+type Observer interface {
+	// ObserveStage 在每个阶段成功结束后调用
+	ObserveStage(stage string, elapsed time.Duration)
+}
+
+// observer 当前的观察者 (启动时设置)
+var observer Observer
+
+// SetObserver 设置转换阶段观察者,nil 表示不观察
+//
+// 应在启动时调用,不能与转换并发。
+func SetObserver(o Observer) {
+	observer = o
+}
+
+// tracer 转换链路追踪 (使用全局 TracerProvider,未设置时不记录)
+var tracer = otel.Tracer("github.com/Cshiyuan/Gomarkdown2image/pkg/converter")
+
+// stage 进行中的转换阶段
+type stage struct {
+	ctx   context.Context
+	span  trace.Span
+	opts  *ConvertOptions
+	name  string
+	start time.Time
+}
+
+// startStage 开始一个转换阶段 (创建 span 并开始计时)
+func startStage(ctx context.Context, opts *ConvertOptions, name string, attrs ...attribute.KeyValue) *stage {
+	ctx, span := tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	return &stage{ctx: ctx, span: span, opts: opts, name: name, start: time.Now()}
+}
+
+// end 结束阶段: 记录耗时日志并通知观察者
+func (s *stage) end() {
+	elapsed := time.Since(s.start)
+	s.span.End()
+	s.opts.logger().Info("conversion stage", "stage", s.name, "duration_ms", elapsed.Milliseconds())
+	if observer != nil {
+		observer.ObserveStage(s.name, elapsed)
+	}
+}
+
+// fail 以错误结束阶段 (失败的阶段不计入耗时统计)
+func (s *stage) fail(err error) {
+	endSpan(s.span, err)
+}
+
+// endSpan 结束 span,err 不为 nil 时记录错误
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// context 返回本次转换的 context (携带调用方的 span)
+func (opts *ConvertOptions) context() context.Context {
+	if opts.Context != nil {
+		return opts.Context
+	}
+	return context.Background()
+}
+
+// logger 返回本次转换的日志记录器
+func (opts *ConvertOptions) logger() *slog.Logger {
+	if opts.Logger != nil {
+		return opts.Logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,25 @@
+package fonts
+
+import _ "embed"
+
+// CJKFallbackFamily 内置中日韩回退字体的 font-family 名称
+//
+// 使用独立名称,避免与系统安装的 Noto Sans CJK 冲突。
+const CJKFallbackFamily = "Gomarkdown2image CJK"
+
+// notoSansCJK Noto Sans CJK 的常用字子集 (CFF,SIL OFL 1.1,见 OFL-NotoSansCJK.txt)
+//
+// 包含 GB 2312、JIS X 0208 第一水准汉字、KS X 1001 韩文音节、假名和全角标点,
+// 汉字使用简体中文字形,字重 400,见 gencjk/。
+//
+//go:embed NotoSansCJK-Subset.otf
+var notoSansCJK []byte
+
+func init() {
+	_ = Default.Register(&Font{
+		Family: CJKFallbackFamily,
+		Format: "opentype",
+		CJK:    true,
+		Data:   notoSansCJK,
+	})
+}
@@ -0,0 +1,48 @@
+package fonts
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEmbeddedCJKFont(t *testing.T) {
+	if got := Default.CJKFamily(); got != CJKFallbackFamily {
+		t.Fatalf("Default.CJKFamily() = %q, want %q", got, CJKFallbackFamily)
+	}
+
+	// 字体文件的 name 表与注册的字体族一致,且包含中日韩字形
+	f := &Font{Data: notoSansCJK}
+	if err := readFontInfo(f); err != nil {
+		t.Fatalf("readFontInfo() error = %v", err)
+	}
+	if f.Family != CJKFallbackFamily || f.Weight != 400 || f.Italic || !f.CJK {
+		t.Errorf("embedded CJK font info: family=%q weight=%d italic=%v cjk=%v", f.Family, f.Weight, f.Italic, f.CJK)
+	}
+
+	if got := Default.Stack("sans-serif", "你好"); !strings.HasSuffix(got, `"`+CJKFallbackFamily+`"`) {
+		t.Errorf("Stack() = %s, want the embedded CJK font appended", got)
+	}
+}
+
+func TestCJKFamilyPrefersLoadedFonts(t *testing.T) {
+	r := NewRegistry()
+	for _, f := range []*Font{
+		{Family: CJKFallbackFamily, Format: "opentype", CJK: true, Data: []byte("cjk")},
+		{Family: "Inter", Format: "woff2", Data: []byte("inter")},
+	} {
+		if err := r.Register(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := r.CJKFamily(); got != CJKFallbackFamily {
+		t.Errorf("CJKFamily() = %q, want %q", got, CJKFallbackFamily)
+	}
+
+	// 加载的 CJK 字体优先于内置字体 (即使名称排序在后)
+	if err := r.Register(&Font{Family: "Noto Sans SC", Format: "woff2", CJK: true, Data: []byte("sc")}); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.CJKFamily(); got != "Noto Sans SC" {
+		t.Errorf("CJKFamily() = %q, want %q", got, "Noto Sans SC")
+	}
+}
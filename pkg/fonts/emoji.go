@@ -0,0 +1,109 @@
+// Package fonts 提供字体注册表和随程序内置的字体
+//
+// 已注册的字体以 @font-face 注入 HTML,字体文件由渲染器按 URL 提供 (见 BaseURL),
+// 渲染结果不依赖服务器安装的系统字体。
+package fonts
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+// EmojiFamily 内置彩色 Emoji 字体的 font-family 名称
+//
+// 使用独立名称,避免与系统安装的 Noto Color Emoji 冲突。
+const EmojiFamily = "Gomarkdown2image Emoji"
+
+// notoColorEmoji Noto Color Emoji (CBDT 位图,SIL OFL 1.1,见 OFL.txt)
+//
+// 位图由上游的 109ppem 缩小为 64ppem、每个字形 64 色,见 gen_emoji.go。
+//
+//go:embed NotoColorEmoji.ttf
+var notoColorEmoji []byte
+
+// runeRange 闭区间码位范围
+type runeRange struct{ lo, hi rune }
+
+// emojiRanges Emoji 所在的码位区间
+//
+// 不包含数字、©、®、™ 和箭头等默认以文本样式显示的字符,
+// 这些字符仍使用正文字体。
+var emojiRanges = []runeRange{
+	{0x231A, 0x231B},
+	{0x2328, 0x2328},
+	{0x23CF, 0x23CF},
+	{0x23E9, 0x23F3},
+	{0x23F8, 0x23FA},
+	{0x24C2, 0x24C2},
+	{0x25AA, 0x25AB},
+	{0x25B6, 0x25B6},
+	{0x25C0, 0x25C0},
+	{0x25FB, 0x25FE},
+	// 杂项符号和装饰符号 (U+2600-27BF) 中只包含字体有彩色字形的字符,
+	// ✓、★ 等字符仍使用正文字体
+	{0x2600, 0x2604}, {0x260E, 0x260E}, {0x2611, 0x2611}, {0x2614, 0x2615}, {0x2618, 0x2618}, {0x261D, 0x261D},
+	{0x2620, 0x2620}, {0x2622, 0x2623}, {0x2626, 0x2626}, {0x262A, 0x262A}, {0x262E, 0x262F}, {0x2638, 0x263A},
+	{0x2640, 0x2640}, {0x2642, 0x2642}, {0x2648, 0x2653}, {0x265F, 0x2660}, {0x2663, 0x2663}, {0x2665, 0x2666},
+	{0x2668, 0x2668}, {0x267B, 0x267B}, {0x267E, 0x267F}, {0x2692, 0x2697}, {0x2699, 0x2699}, {0x269B, 0x269C},
+	{0x26A0, 0x26A1}, {0x26A7, 0x26A7}, {0x26AA, 0x26AB}, {0x26B0, 0x26B1}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5},
+	{0x26C8, 0x26C8}, {0x26CE, 0x26CF}, {0x26D1, 0x26D1}, {0x26D3, 0x26D4}, {0x26E9, 0x26EA}, {0x26F0, 0x26F5},
+	{0x26F7, 0x26FA}, {0x26FD, 0x26FD}, {0x2702, 0x2702}, {0x2705, 0x2705}, {0x2708, 0x270D}, {0x270F, 0x270F},
+	{0x2712, 0x2712}, {0x2714, 0x2714}, {0x2716, 0x2716}, {0x271D, 0x271D}, {0x2721, 0x2721}, {0x2728, 0x2728},
+	{0x2733, 0x2734}, {0x2744, 0x2744}, {0x2747, 0x2747}, {0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2763, 0x2764}, {0x2795, 0x2797}, {0x27A1, 0x27A1}, {0x27B0, 0x27B0}, {0x27BF, 0x27BF},
+	{0x2934, 0x2935},
+	{0x2B05, 0x2B07},
+	{0x2B1B, 0x2B1C},
+	{0x2B50, 0x2B50},
+	{0x2B55, 0x2B55},
+	{0x3030, 0x3030},
+	{0x303D, 0x303D},
+	{0x3297, 0x3297},
+	{0x3299, 0x3299},
+	{0x1F000, 0x1FAFF},
+}
+
+// emojiJoinerRanges 组合 Emoji 序列使用的连接符、变体选择符和标签字符
+var emojiJoinerRanges = []runeRange{
+	{0x200D, 0x200D},
+	{0x20E3, 0x20E3},
+	{0xFE0F, 0xFE0F},
+	{0xE0020, 0xE007F},
+}
+
+func init() {
+	var ranges []string
+	for _, list := range [][]runeRange{emojiRanges, emojiJoinerRanges} {
+		for _, rg := range list {
+			if rg.lo == rg.hi {
+				ranges = append(ranges, fmt.Sprintf("U+%X", rg.lo))
+			} else {
+				ranges = append(ranges, fmt.Sprintf("U+%X-%X", rg.lo, rg.hi))
+			}
+		}
+	}
+
+	// 通过 unicode-range 限定为 Emoji 码位,因此可以放在 font-family 首位而不影响普通文字
+	_ = Default.Register(&Font{
+		Family:       EmojiFamily,
+		Format:       "truetype",
+		UnicodeRange: strings.Join(ranges, ", "),
+		Data:         notoColorEmoji,
+	})
+}
+
+// ContainsEmoji 检查文本中是否包含 Emoji
+func ContainsEmoji(s string) bool {
+	for _, r := range s {
+		if r < 0x2000 {
+			continue
+		}
+		for _, rg := range emojiRanges {
+			if r >= rg.lo && r <= rg.hi {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package fonts
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestContainsEmoji(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want bool
+	}{
+		{"纯文本", "Hello 世界 123", false},
+		{"文本样式符号", "© ® ™ → #1", false},
+		{"Emoji", "发布 🚀", true},
+		{"杂项符号", "完成 ✅", true},
+		{"文本样式装饰符号", "✓ 通过 ★★★", false},
+		{"组合序列", "👨‍💻", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ContainsEmoji(tt.text); got != tt.want {
+				t.Errorf("ContainsEmoji(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbeddedEmojiFont(t *testing.T) {
+	if !Default.Has(EmojiFamily) {
+		t.Fatal("Default registry should include the bundled emoji font")
+	}
+
+	css := Default.FontFaces(`"` + EmojiFamily + `"`)
+	for _, part := range []string{
+		`font-family: "` + EmojiFamily + `"`,
+		`src: url("` + BaseURL,
+		"unicode-range: U+231A-231B,",
+		"U+1F000-1FAFF",
+		"U+200D",
+	} {
+		if !strings.Contains(css, part) {
+			t.Errorf("emoji @font-face doesn't contain %q", part)
+		}
+	}
+	if strings.Contains(css, "U+30-") || strings.Contains(css, "U+A9") {
+		t.Error("emoji @font-face should not cover digits or text-style symbols")
+	}
+}
@@ -0,0 +1,268 @@
+//go:build ignore
+
+// gen_emoji 缩小 Noto Color Emoji 的位图尺寸,生成内置的 NotoColorEmoji.ttf
+//
+// 上游字体只有一组 109ppem 的 CBDT 位图 (约 10 MB),远大于截图需要的分辨率。
+// 本工具按目标 ppem 缩放每个字形的 PNG 及其度量,其余表保持不变。
+//
+// 用法:
+//
+//	go run gen_emoji.go -ppem 64 -colors 64 -o NotoColorEmoji.ttf /path/to/upstream/NotoColorEmoji.ttf
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"math"
+	"os"
+	"sort"
+
+	"golang.org/x/image/draw"
+)
+
+func main() {
+	ppem := flag.Int("ppem", 64, "目标位图尺寸 (像素/em)")
+	colors := flag.Int("colors", 64, "每个字形的最大调色板颜色数 (2-256)")
+	output := flag.String("o", "NotoColorEmoji.ttf", "输出文件")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatal("usage: go run gen_emoji.go [-ppem 64] [-colors 64] [-o out.ttf] NotoColorEmoji.ttf")
+	}
+
+	data, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	tables, err := readTables(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := scaleBitmaps(tables, *ppem, *colors); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, writeFont(data[:4], tables), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readTables 读取 sfnt 表目录,返回表标签到表内容的映射
+func readTables(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("not a font file")
+	}
+	n := int(binary.BigEndian.Uint16(data[4:]))
+	tables := make(map[string][]byte, n)
+	for i := 0; i < n; i++ {
+		rec := data[12+16*i:]
+		offset, length := binary.BigEndian.Uint32(rec[8:]), binary.BigEndian.Uint32(rec[12:])
+		if int(offset+length) > len(data) {
+			return nil, fmt.Errorf("table %s out of range", rec[:4])
+		}
+		tables[string(rec[:4])] = bytes.Clone(data[offset : offset+length])
+	}
+	return tables, nil
+}
+
+// scaleBitmaps 将 CBDT 中的 PNG 位图缩放到目标 ppem,并更新 CBLC 中的偏移和度量
+//
+// 仅支持 Noto Color Emoji 使用的格式: 单组位图、索引子表格式 1、图像格式 17。
+func scaleBitmaps(tables map[string][]byte, ppem, colors int) error {
+	cblc, cbdt := tables["CBLC"], tables["CBDT"]
+	if cblc == nil || cbdt == nil {
+		return fmt.Errorf("font has no CBLC/CBDT tables")
+	}
+	if binary.BigEndian.Uint32(cblc[4:]) != 1 {
+		return fmt.Errorf("only fonts with a single bitmap strike are supported")
+	}
+
+	size := cblc[8:56]
+	scale := float64(ppem) / float64(size[44])
+	scaleInt8 := func(b byte) byte { return byte(int8(math.Round(float64(int8(b)) * scale))) }
+	scaleUint8 := func(b byte) byte { return byte(math.Round(float64(b) * scale)) }
+
+	// sbitLineMetrics (hori, vert): ascender, descender, widthMax, 其余字段为 0
+	for _, line := range [][]byte{size[16:28], size[28:40]} {
+		line[0], line[1], line[2] = scaleInt8(line[0]), scaleInt8(line[1]), scaleUint8(line[2])
+	}
+	size[44], size[45] = byte(ppem), byte(ppem)
+
+	out := bytes.NewBuffer(bytes.Clone(cbdt[:4]))
+	arrayOffset := int(binary.BigEndian.Uint32(size[0:]))
+	numSubtables := int(binary.BigEndian.Uint32(size[8:]))
+	for i := 0; i < numSubtables; i++ {
+		entry := cblc[arrayOffset+8*i:]
+		first, last := int(binary.BigEndian.Uint16(entry)), int(binary.BigEndian.Uint16(entry[2:]))
+		sub := cblc[arrayOffset+int(binary.BigEndian.Uint32(entry[4:])):]
+		indexFormat, imageFormat := binary.BigEndian.Uint16(sub), binary.BigEndian.Uint16(sub[2:])
+		if indexFormat != 1 || imageFormat != 17 {
+			return fmt.Errorf("unsupported index format %d / image format %d", indexFormat, imageFormat)
+		}
+
+		imageDataOffset := int(binary.BigEndian.Uint32(sub[4:]))
+		offsets := sub[8:]
+		start := out.Len()
+		binary.BigEndian.PutUint32(sub[4:], uint32(start))
+		for g := 0; g <= last-first; g++ {
+			lo := imageDataOffset + int(binary.BigEndian.Uint32(offsets[4*g:]))
+			hi := imageDataOffset + int(binary.BigEndian.Uint32(offsets[4*(g+1):]))
+			binary.BigEndian.PutUint32(offsets[4*g:], uint32(out.Len()-start))
+			if hi == lo {
+				continue // 没有位图的字形
+			}
+			glyph, err := scaleGlyph(cbdt[lo:hi], scale, colors, scaleInt8, scaleUint8)
+			if err != nil {
+				return fmt.Errorf("glyph %d: %w", first+g, err)
+			}
+			out.Write(glyph)
+		}
+		binary.BigEndian.PutUint32(offsets[4*(last-first+1):], uint32(out.Len()-start))
+	}
+
+	tables["CBDT"] = out.Bytes()
+	return nil
+}
+
+// scaleGlyph 缩放格式 17 的字形数据: smallGlyphMetrics (5 字节) + PNG 长度 (4 字节) + PNG
+func scaleGlyph(glyph []byte, scale float64, colors int, scaleInt8, scaleUint8 func(byte) byte) ([]byte, error) {
+	src, err := png.Decode(bytes.NewReader(glyph[9:]))
+	if err != nil {
+		return nil, err
+	}
+	b := src.Bounds()
+	w := max(int(math.Round(float64(b.Dx())*scale)), 1)
+	h := max(int(math.Round(float64(b.Dy())*scale)), 1)
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+
+	var img bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(&img, quantize(dst, colors)); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 9, 9+img.Len())
+	out[0], out[1] = byte(h), byte(w)
+	out[2], out[3] = scaleInt8(glyph[2]), scaleInt8(glyph[3])
+	out[4] = scaleUint8(glyph[4])
+	binary.BigEndian.PutUint32(out[5:], uint32(img.Len()))
+	return append(out, img.Bytes()...), nil
+}
+
+// quantize 使用中位切分将图像减少到 maxColors 色调色板 (与上游字体一样编码为索引色 PNG)
+func quantize(img *image.NRGBA, maxColors int) *image.Paletted {
+	counts := make(map[color.NRGBA]int)
+	for i := 0; i < len(img.Pix); i += 4 {
+		c := color.NRGBA{img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3]}
+		if c.A == 0 {
+			c = color.NRGBA{}
+		}
+		counts[c]++
+	}
+	colors := make([]color.NRGBA, 0, len(counts))
+	for c := range counts {
+		colors = append(colors, c)
+	}
+
+	boxes := [][]color.NRGBA{colors}
+	for len(boxes) < maxColors {
+		// 拆分颜色范围最大的盒子
+		best, bestChannel, bestRange := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			for ch := 0; ch < 4; ch++ {
+				lo, hi := 255, 0
+				for _, c := range box {
+					v := channel(c, ch)
+					lo, hi = min(lo, v), max(hi, v)
+				}
+				if hi-lo > bestRange {
+					best, bestChannel, bestRange = i, ch, hi-lo
+				}
+			}
+		}
+		if best < 0 {
+			break
+		}
+		box := boxes[best]
+		sort.Slice(box, func(i, j int) bool { return channel(box[i], bestChannel) < channel(box[j], bestChannel) })
+		boxes[best], boxes = box[:len(box)/2], append(boxes, box[len(box)/2:])
+	}
+
+	palette := make(color.Palette, 0, len(boxes))
+	for _, box := range boxes {
+		var r, g, b, a, n int
+		for _, c := range box {
+			w := counts[c]
+			r, g, b, a, n = r+int(c.R)*w, g+int(c.G)*w, b+int(c.B)*w, a+int(c.A)*w, n+w
+		}
+		palette = append(palette, color.NRGBA{uint8(r / n), uint8(g / n), uint8(b / n), uint8(a / n)})
+	}
+
+	dst := image.NewPaletted(img.Bounds(), palette)
+	draw.Draw(dst, dst.Bounds(), img, image.Point{}, draw.Src)
+	return dst
+}
+
+// channel 返回颜色的第 ch 个通道 (R, G, B, A)
+func channel(c color.NRGBA, ch int) int {
+	return int([4]uint8{c.R, c.G, c.B, c.A}[ch])
+}
+
+// writeFont 按标签顺序写出 sfnt 文件,重新计算表校验和与 head.checkSumAdjustment
+func writeFont(version []byte, tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	n := len(tags)
+	entrySelector := int(math.Floor(math.Log2(float64(n))))
+	searchRange := (1 << entrySelector) * 16
+
+	var buf bytes.Buffer
+	buf.Write(version)
+	binary.Write(&buf, binary.BigEndian, []uint16{uint16(n), uint16(searchRange), uint16(entrySelector), uint16(n*16 - searchRange)})
+
+	head := tables["head"]
+	binary.BigEndian.PutUint32(head[8:], 0)
+
+	offset := 12 + 16*n
+	for _, tag := range tags {
+		t := tables[tag]
+		buf.WriteString(tag)
+		binary.Write(&buf, binary.BigEndian, []uint32{checksum(t), uint32(offset), uint32(len(t))})
+		offset += (len(t) + 3) &^ 3
+	}
+	headOffset := 0
+	for _, tag := range tags {
+		if tag == "head" {
+			headOffset = buf.Len()
+		}
+		buf.Write(tables[tag])
+		buf.Write(make([]byte, (4-len(tables[tag])%4)%4))
+	}
+
+	font := buf.Bytes()
+	binary.BigEndian.PutUint32(font[headOffset+8:], 0xB1B0AFBA-checksum(font))
+	return font
+}
+
+// checksum 计算 sfnt 表校验和 (按大端 uint32 求和,末尾补零)
+func checksum(b []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(b); i += 4 {
+		var word [4]byte
+		copy(word[:], b[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
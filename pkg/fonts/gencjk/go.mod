@@ -0,0 +1,10 @@
+module github.com/Cshiyuan/Gomarkdown2image/pkg/fonts/gencjk
+
+go 1.25.1
+
+require (
+	github.com/go-text/typesetting v0.3.5
+	golang.org/x/text v0.40.0
+)
+
+require golang.org/x/image v0.23.0 // indirect
@@ -0,0 +1,739 @@
+// gencjk 从 Noto Sans CJK 可变字体生成内置的中日韩子集字体 NotoSansCJK-Subset.otf
+//
+// 上游只提供完整字体 (每种字重约 16 MB) 或可变字体,本工具:
+//   - 选取常用字符: GB 2312 汉字和符号、JIS X 0208 第一水准汉字、KS X 1001 韩文音节、假名和全角标点;
+//   - 应用 locl 特性中的简体中文 (ZHS) 字形替换,汉字以简体中文字形为准;
+//   - 将可变字体实例化为指定字重,输出不含提示信息的静态 CID 键控 CFF 字体。
+//
+// 依赖 go-text/typesetting 读取 CFF2 可变字体,因此作为独立模块,不影响主模块的依赖。
+//
+// 用法:
+//
+//	go run . -weight 400 -o ../NotoSansCJK-Subset.otf /path/to/NotoSansCJKjp-VF.otf
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"unicode/utf16"
+
+	"github.com/go-text/typesetting/font"
+	ot "github.com/go-text/typesetting/font/opentype"
+	"github.com/go-text/typesetting/font/opentype/tables"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// familyName 输出字体的字体族名称 (与 fonts.CJKFallbackFamily 一致)
+//
+// 修改后的字体不能使用保留字体名称 (Source),同时避免与系统安装的 Noto 字体冲突。
+const (
+	familyName     = "Gomarkdown2image CJK"
+	postScriptName = "Gomarkdown2imageCJK-Regular"
+)
+
+// defaultWidth 汉字的标准宽度 (字体单位),作为 CFF defaultWidthX
+const defaultWidth = 1000
+
+func main() {
+	weight := flag.Float64("weight", 400, "实例化的字重 (wght 轴)")
+	output := flag.String("o", "NotoSansCJK-Subset.otf", "输出文件")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatal("usage: go run . [-weight 400] [-o out.otf] NotoSansCJKjp-VF.otf")
+	}
+
+	data, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	ld, err := ot.NewLoader(bytes.NewReader(data))
+	if err != nil {
+		log.Fatal(err)
+	}
+	ft, err := font.NewFont(ld)
+	if err != nil {
+		log.Fatal(err)
+	}
+	face := font.NewFace(ft)
+	face.SetVariations([]font.Variation{{Tag: ot.MustNewTag("wght"), Value: float32(*weight)}})
+
+	runes, err := selectRunes()
+	if err != nil {
+		log.Fatal(err)
+	}
+	maxp, err := ld.RawTable(ot.MustNewTag("maxp"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	numGlyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+	glyphs := subset(ft, face, runes, localizedGlyphs(ft, numGlyphs, ot.MustNewTag("ZHS ")))
+
+	tables := make(map[string][]byte)
+	for _, tag := range []string{"head", "hhea", "OS/2", "post", "name"} {
+		raw, err := ld.RawTable(ot.MustNewTag(tag))
+		if err != nil {
+			log.Fatalf("table %s: %v", tag, err)
+		}
+		tables[tag] = bytes.Clone(raw)
+	}
+	if err := buildTables(tables, glyphs, int(*weight)); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, writeFont([]byte("OTTO"), tables), 0o644); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote %s: %d glyphs", *output, len(glyphs))
+}
+
+// selectRunes 返回子集包含的字符 (已排序)
+func selectRunes() ([]rune, error) {
+	set := make(map[rune]bool)
+	add := func(lo, hi rune) {
+		for r := lo; r <= hi; r++ {
+			set[r] = true
+		}
+	}
+	// 双字节编码的字符区: 区号范围 (首字节) × 0xA1-0xFE
+	addRows := func(enc encoding.Encoding, lo, hi byte) error {
+		dec := enc.NewDecoder()
+		for b1 := lo; b1 <= hi; b1++ {
+			for b2 := byte(0xA1); b2 <= 0xFE; b2++ {
+				s, err := dec.Bytes([]byte{b1, b2})
+				if err != nil {
+					return err
+				}
+				for _, r := range string(s) {
+					if r != '�' && r >= 0x80 {
+						set[r] = true
+					}
+				}
+			}
+		}
+		return nil
+	}
+
+	add(0x3000, 0x303F) // 中日韩符号和标点
+	add(0x3041, 0x30FF) // 平假名、片假名
+	add(0x31F0, 0x31FF) // 片假名语音扩展
+	add(0x3131, 0x318E) // 韩文兼容字母
+	add(0xFF01, 0xFFEF) // 全角和半角字符
+	for _, rows := range []struct {
+		enc    encoding.Encoding
+		lo, hi byte
+	}{
+		{simplifiedchinese.GBK, 0xA1, 0xA3}, // GB 2312 标点和全角字符
+		{simplifiedchinese.GBK, 0xB0, 0xF7}, // GB 2312 一级、二级汉字
+		{japanese.EUCJP, 0xB0, 0xCF},        // JIS X 0208 第一水准汉字
+		{korean.EUCKR, 0xB0, 0xC8},          // KS X 1001 韩文音节
+	} {
+		if err := addRows(rows.enc, rows.lo, rows.hi); err != nil {
+			return nil, err
+		}
+	}
+
+	runes := make([]rune, 0, len(set))
+	for r := range set {
+		runes = append(runes, r)
+	}
+	slices.Sort(runes)
+	return runes, nil
+}
+
+// localizedGlyphs 返回各文种下指定语言的 locl 特性中的单字形替换
+//
+// 全角标点位于 DFLT 等文种下,汉字位于 hani 文种下。
+func localizedGlyphs(ft *font.Font, numGlyphs int, lang ot.Tag) map[font.GID]font.GID {
+	subs := make(map[font.GID]font.GID)
+	gsub := ft.GSUB
+	for _, sc := range gsub.Scripts {
+		li := sc.FindLanguage(lang)
+		if li < 0 {
+			continue
+		}
+		for _, fi := range sc.LangSys[li].FeatureIndices {
+			feature := gsub.Features[fi]
+			if feature.Tag != ot.MustNewTag("locl") {
+				continue
+			}
+			for _, lookup := range feature.LookupListIndices {
+				for _, sub := range gsub.Lookups[lookup].Subtables {
+					single, ok := sub.(tables.SingleSubs)
+					if !ok {
+						continue
+					}
+					for gid := range numGlyphs {
+						switch data := single.Data.(type) {
+						case tables.SingleSubstData1:
+							if _, ok := data.Coverage.Index(tables.GlyphID(gid)); ok {
+								subs[font.GID(gid)] = font.GID(gid + int(data.DeltaGlyphID))
+							}
+						case tables.SingleSubstData2:
+							if i, ok := data.Coverage.Index(tables.GlyphID(gid)); ok {
+								subs[font.GID(gid)] = font.GID(data.SubstituteGlyphIDs[i])
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return subs
+}
+
+// point 字体单位下的整数坐标
+type point struct{ x, y int }
+
+// segment 轮廓线段: 直线 (1 个点) 或三次贝塞尔曲线 (3 个点)
+type segment []point
+
+// glyph 子集中的字形
+type glyph struct {
+	r        rune // 对应的字符 (.notdef 为 0)
+	advance  int
+	contours [][]segment // 每个轮廓的第一个线段为起点
+}
+
+// subset 按字符顺序提取字形,字形 0 为 .notdef
+func subset(ft *font.Font, face *font.Face, runes []rune, subs map[font.GID]font.GID) []glyph {
+	glyphs := []glyph{newGlyph(face, 0, 0)}
+	for _, r := range runes {
+		gid, ok := ft.NominalGlyph(r)
+		if !ok || gid == 0 {
+			continue
+		}
+		if local, ok := subs[gid]; ok {
+			gid = local
+		}
+		glyphs = append(glyphs, newGlyph(face, r, gid))
+	}
+	return glyphs
+}
+
+// newGlyph 读取实例化后的字形轮廓并取整坐标
+func newGlyph(face *font.Face, r rune, gid font.GID) glyph {
+	g := glyph{r: r, advance: int(math.Round(float64(face.HorizontalAdvance(gid))))}
+	outline, _ := face.GlyphDataOutline(gid)
+	round := func(p ot.SegmentPoint) point {
+		return point{int(math.Round(float64(p.X))), int(math.Round(float64(p.Y)))}
+	}
+
+	var contour []segment
+	closeContour := func() {
+		// CFF 的轮廓自动闭合,去掉回到起点的直线和零长度直线
+		if len(contour) > 1 {
+			last := contour[len(contour)-1]
+			if len(last) == 1 && last[0] == contour[0][0] {
+				contour = contour[:len(contour)-1]
+			}
+		}
+		if len(contour) > 1 {
+			g.contours = append(g.contours, contour)
+		}
+		contour = nil
+	}
+	for _, seg := range outline.Segments {
+		switch seg.Op {
+		case ot.SegmentOpMoveTo:
+			closeContour()
+			contour = []segment{{round(seg.Args[0])}}
+		case ot.SegmentOpLineTo:
+			p := round(seg.Args[0])
+			if prev := contour[len(contour)-1]; prev[len(prev)-1] != p {
+				contour = append(contour, segment{p})
+			}
+		case ot.SegmentOpQuadTo:
+			// CFF 轮廓不会产生二次曲线,按三次曲线升阶处理
+			prev := contour[len(contour)-1]
+			p0, c, p := prev[len(prev)-1], round(seg.Args[0]), round(seg.Args[1])
+			contour = append(contour, segment{
+				{p0.x + 2*(c.x-p0.x)/3, p0.y + 2*(c.y-p0.y)/3},
+				{p.x + 2*(c.x-p.x)/3, p.y + 2*(c.y-p.y)/3},
+				p,
+			})
+		case ot.SegmentOpCubeTo:
+			contour = append(contour, segment{round(seg.Args[0]), round(seg.Args[1]), round(seg.Args[2])})
+		}
+	}
+	closeContour()
+	return g
+}
+
+// bounds 返回字形轮廓的边界框 (控制点边界,空字形返回零值)
+func (g glyph) bounds() (xMin, yMin, xMax, yMax int) {
+	first := true
+	for _, contour := range g.contours {
+		for _, seg := range contour {
+			for _, p := range seg {
+				if first {
+					xMin, yMin, xMax, yMax = p.x, p.y, p.x, p.y
+					first = false
+					continue
+				}
+				xMin, yMin = min(xMin, p.x), min(yMin, p.y)
+				xMax, yMax = max(xMax, p.x), max(yMax, p.y)
+			}
+		}
+	}
+	return
+}
+
+// buildTables 生成 CFF、cmap、hmtx 和 maxp,并更新从源字体复制的 head、hhea、OS/2、post 和 name
+func buildTables(t map[string][]byte, glyphs []glyph, weight int) error {
+	n := len(glyphs)
+	hmtx := new(bytes.Buffer)
+	var xMin, yMin, xMax, yMax, advanceMax, minRSB, maxExtent int
+	minLSB := math.MaxInt
+	minRSB = math.MaxInt
+	for i, g := range glyphs {
+		gx0, gy0, gx1, gy1 := g.bounds()
+		if i == 0 || len(g.contours) > 0 {
+			if len(g.contours) > 0 {
+				minLSB, minRSB = min(minLSB, gx0), min(minRSB, g.advance-gx1)
+				maxExtent = max(maxExtent, gx1)
+			}
+			xMin, yMin, xMax, yMax = min(xMin, gx0), min(yMin, gy0), max(xMax, gx1), max(yMax, gy1)
+		}
+		advanceMax = max(advanceMax, g.advance)
+		binary.Write(hmtx, binary.BigEndian, []int16{int16(g.advance), int16(gx0)})
+	}
+	t["hmtx"] = hmtx.Bytes()
+
+	maxp := make([]byte, 6)
+	binary.BigEndian.PutUint32(maxp, 0x00005000)
+	binary.BigEndian.PutUint16(maxp[4:], uint16(n))
+	t["maxp"] = maxp
+
+	head := t["head"]
+	for i, v := range []int{xMin, yMin, xMax, yMax} {
+		binary.BigEndian.PutUint16(head[36+2*i:], uint16(int16(v)))
+	}
+
+	hhea := t["hhea"]
+	binary.BigEndian.PutUint16(hhea[10:], uint16(advanceMax))
+	binary.BigEndian.PutUint16(hhea[12:], uint16(int16(minLSB)))
+	binary.BigEndian.PutUint16(hhea[14:], uint16(int16(minRSB)))
+	binary.BigEndian.PutUint16(hhea[16:], uint16(int16(maxExtent)))
+	binary.BigEndian.PutUint16(hhea[34:], uint16(n))
+
+	os2 := t["OS/2"]
+	binary.BigEndian.PutUint16(os2[4:], uint16(weight))
+	binary.BigEndian.PutUint16(os2[62:], 0x0040)                          // fsSelection: REGULAR
+	binary.BigEndian.PutUint16(os2[64:], uint16(glyphs[1].r))             // usFirstCharIndex
+	binary.BigEndian.PutUint16(os2[66:], uint16(glyphs[len(glyphs)-1].r)) // usLastCharIndex
+
+	// post 3.0: 不包含字形名称
+	post := t["post"][:32]
+	binary.BigEndian.PutUint32(post, 0x00030000)
+	t["post"] = post
+
+	name, err := buildName(t["name"])
+	if err != nil {
+		return err
+	}
+	t["name"] = name
+	t["cmap"] = buildCmap(glyphs)
+	t["CFF "] = buildCFF(glyphs, [4]int{xMin, yMin, xMax, yMax})
+	return nil
+}
+
+// buildName 生成 name 表,保留源字体的版权和许可证信息
+func buildName(src []byte) ([]byte, error) {
+	source := make(map[uint16]string)
+	count, storage := int(binary.BigEndian.Uint16(src[2:])), int(binary.BigEndian.Uint16(src[4:]))
+	for i := 0; i < count; i++ {
+		rec := src[6+12*i:]
+		platform, enc, lang := binary.BigEndian.Uint16(rec), binary.BigEndian.Uint16(rec[2:]), binary.BigEndian.Uint16(rec[4:])
+		if platform != 3 || enc != 1 || lang != 0x409 {
+			continue
+		}
+		id, length, offset := binary.BigEndian.Uint16(rec[6:]), int(binary.BigEndian.Uint16(rec[8:])), int(binary.BigEndian.Uint16(rec[10:]))
+		raw := src[storage+offset : storage+offset+length]
+		units := make([]uint16, len(raw)/2)
+		for j := range units {
+			units[j] = binary.BigEndian.Uint16(raw[2*j:])
+		}
+		source[id] = string(utf16.Decode(units))
+	}
+	if source[0] == "" || source[13] == "" {
+		return nil, fmt.Errorf("source font has no copyright or license name records")
+	}
+
+	records := []struct {
+		id    uint16
+		value string
+	}{
+		{0, source[0]},
+		{1, familyName},
+		{2, "Regular"},
+		{3, source[5] + ";" + postScriptName},
+		{4, familyName},
+		{5, source[5]},
+		{6, postScriptName},
+		{10, "Subset of " + source[4] + " (simplified Chinese glyph forms), generated by gencjk"},
+		{13, source[13]},
+		{14, source[14]},
+	}
+
+	var strs bytes.Buffer
+	out := new(bytes.Buffer)
+	binary.Write(out, binary.BigEndian, []uint16{0, uint16(len(records)), uint16(6 + 12*len(records))})
+	for _, rec := range records {
+		var value []byte
+		for _, u := range utf16.Encode([]rune(rec.value)) {
+			value = binary.BigEndian.AppendUint16(value, u)
+		}
+		binary.Write(out, binary.BigEndian, []uint16{3, 1, 0x409, rec.id, uint16(len(value)), uint16(strs.Len())})
+		strs.Write(value)
+	}
+	out.Write(strs.Bytes())
+	return out.Bytes(), nil
+}
+
+// buildCmap 生成 cmap 表 (format 4,字符按码位顺序对应连续的字形编号)
+func buildCmap(glyphs []glyph) []byte {
+	type seg struct {
+		start, end rune
+		delta      int
+	}
+	var segs []seg
+	for gid := 1; gid < len(glyphs); gid++ {
+		r := glyphs[gid].r
+		if last := len(segs) - 1; last >= 0 && segs[last].end+1 == r {
+			segs[last].end = r
+			continue
+		}
+		segs = append(segs, seg{r, r, gid - int(r)})
+	}
+	segs = append(segs, seg{0xFFFF, 0xFFFF, 1})
+
+	segCount := len(segs)
+	entrySelector := int(math.Floor(math.Log2(float64(segCount))))
+	searchRange := 2 << entrySelector
+	sub := new(bytes.Buffer)
+	binary.Write(sub, binary.BigEndian, []uint16{4, uint16(16 + 8*segCount), 0,
+		uint16(2 * segCount), uint16(searchRange), uint16(entrySelector), uint16(2*segCount - searchRange)})
+	for _, s := range segs {
+		binary.Write(sub, binary.BigEndian, uint16(s.end))
+	}
+	binary.Write(sub, binary.BigEndian, uint16(0))
+	for _, s := range segs {
+		binary.Write(sub, binary.BigEndian, uint16(s.start))
+	}
+	for _, s := range segs {
+		binary.Write(sub, binary.BigEndian, uint16(s.delta))
+	}
+	sub.Write(make([]byte, 2*segCount)) // idRangeOffset
+
+	// Unicode (0/3) 和 Windows (3/1) 两个编码记录共用同一个子表
+	out := new(bytes.Buffer)
+	binary.Write(out, binary.BigEndian, []uint16{0, 2, 0, 3, 0, 20, 3, 1, 0, 20})
+	out.Write(sub.Bytes())
+	return out.Bytes()
+}
+
+// buildCFF 生成 CID 键控的 CFF 表 (Adobe-Identity-0,CID 与字形编号相同)
+func buildCFF(glyphs []glyph, bbox [4]int) []byte {
+	n := len(glyphs)
+	charStrings := make([][]byte, n)
+	for i, g := range glyphs {
+		charStrings[i] = encodeCharString(g)
+	}
+
+	charset := []byte{2}
+	charset = binary.BigEndian.AppendUint16(charset, 1)
+	charset = binary.BigEndian.AppendUint16(charset, uint16(n-2))
+	fdSelect := []byte{3, 0, 1, 0, 0, 0}
+	fdSelect = binary.BigEndian.AppendUint16(fdSelect, uint16(n))
+	private := dict(op{20, defaultWidth}, op{21, 0})
+
+	header := []byte{1, 0, 4, 4}
+	names := index([]byte(postScriptName))
+	strs := index([]byte("Adobe"), []byte("Identity"))
+	globalSubrs := index()
+
+	// 偏移使用固定长度编码,先以 0 生成 Top DICT 计算各部分位置
+	topDict := func(charsetOff, fdSelectOff, charStringsOff, fdArrayOff int) []byte {
+		return dict(
+			op{1230, 391, 392, 0}, // ROS: Adobe-Identity-0
+			op{1234, n},           // CIDCount
+			op{5, bbox[0], bbox[1], bbox[2], bbox[3]},
+			op{15, fixed(charsetOff)},
+			op{1237, fixed(fdSelectOff)},
+			op{17, fixed(charStringsOff)},
+			op{1236, fixed(fdArrayOff)},
+		)
+	}
+	charsetOff := len(header) + len(names) + len(index(topDict(0, 0, 0, 0))) + len(strs) + len(globalSubrs)
+	fdSelectOff := charsetOff + len(charset)
+	charStringsIndex := index(charStrings...)
+	charStringsOff := fdSelectOff + len(fdSelect)
+	fdArrayOff := charStringsOff + len(charStringsIndex)
+	fdArray := func(privateOff int) []byte {
+		return index(dict(op{18, fixed(len(private)), fixed(privateOff)}))
+	}
+	privateOff := fdArrayOff + len(fdArray(0))
+
+	var out bytes.Buffer
+	for _, part := range [][]byte{
+		header, names, index(topDict(charsetOff, fdSelectOff, charStringsOff, fdArrayOff)), strs, globalSubrs,
+		charset, fdSelect, charStringsIndex, fdArray(privateOff), private,
+	} {
+		out.Write(part)
+	}
+	return out.Bytes()
+}
+
+// fixedOperand 以固定 5 字节编码的 DICT 整数 (用于偏移量)
+type fixedOperand int
+
+func fixed(v int) fixedOperand { return fixedOperand(v) }
+
+// op DICT 运算符及操作数,运算符 12 x 记为 1200+x
+type op []any
+
+// dict 编码 CFF DICT (操作数在前,运算符在后)
+func dict(ops ...op) []byte {
+	var out []byte
+	for _, o := range ops {
+		for _, arg := range o[1:] {
+			switch v := arg.(type) {
+			case fixedOperand:
+				out = append(out, 29)
+				out = binary.BigEndian.AppendUint32(out, uint32(int32(v)))
+			case int:
+				out = appendDictInt(out, v)
+			}
+		}
+		if code := o[0].(int); code >= 1200 {
+			out = append(out, 12, byte(code-1200))
+		} else {
+			out = append(out, byte(code))
+		}
+	}
+	return out
+}
+
+// appendDictInt 按 CFF DICT 的紧凑格式编码整数
+func appendDictInt(out []byte, v int) []byte {
+	switch {
+	case v >= -107 && v <= 107:
+		return append(out, byte(v+139))
+	case v >= 108 && v <= 1131:
+		v -= 108
+		return append(out, byte(v>>8+247), byte(v))
+	case v >= -1131 && v <= -108:
+		v = -v - 108
+		return append(out, byte(v>>8+251), byte(v))
+	case v >= -32768 && v <= 32767:
+		return binary.BigEndian.AppendUint16(append(out, 28), uint16(int16(v)))
+	default:
+		return binary.BigEndian.AppendUint32(append(out, 29), uint32(int32(v)))
+	}
+}
+
+// index 编码 CFF INDEX
+func index(items ...[]byte) []byte {
+	out := binary.BigEndian.AppendUint16(nil, uint16(len(items)))
+	if len(items) == 0 {
+		return out
+	}
+	total := 1
+	for _, item := range items {
+		total += len(item)
+	}
+	offSize := 1
+	for total >= 1<<(8*offSize) {
+		offSize++
+	}
+	out = append(out, byte(offSize))
+	offset := 1
+	appendOffset := func() {
+		for i := offSize - 1; i >= 0; i-- {
+			out = append(out, byte(offset>>(8*i)))
+		}
+	}
+	appendOffset()
+	for _, item := range items {
+		offset += len(item)
+		appendOffset()
+	}
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+// Type 2 charstring 运算符
+const (
+	csVMoveTo   = 4
+	csRLineTo   = 5
+	csHLineTo   = 6
+	csVLineTo   = 7
+	csRRCurveTo = 8
+	csEndChar   = 14
+	csRMoveTo   = 21
+	csHMoveTo   = 22
+)
+
+// maxStack Type 2 charstring 参数栈的最大深度
+const maxStack = 48
+
+// encodeCharString 将字形编码为 Type 2 charstring (不含提示)
+//
+// 连续的直线和曲线合并为一个运算符,水平和垂直直线交替出现时使用 hlineto/vlineto。
+func encodeCharString(g glyph) []byte {
+	var out, args []byte
+	argCount, lastOp := 0, -1
+	flush := func() {
+		if lastOp < 0 {
+			return
+		}
+		out = append(append(out, args...), byte(lastOp))
+		args, argCount, lastOp = nil, 0, -1
+	}
+	// emit 追加一组参数,能与上一个运算符合并时不立即输出
+	emit := func(code int, mergeable bool, vals ...int) {
+		if !mergeable || code != lastOp || argCount+len(vals) > maxStack {
+			flush()
+		}
+		for _, v := range vals {
+			args = appendCharStringInt(args, v)
+		}
+		argCount += len(vals)
+		lastOp = code
+	}
+	if g.advance != defaultWidth {
+		args = appendCharStringInt(args, g.advance)
+		argCount++
+	}
+
+	var cur point
+	// lineDir 上一条水平/垂直直线的方向 (用于 hlineto/vlineto 交替合并),0 表示无
+	lineDir := 0
+	for _, contour := range g.contours {
+		start := contour[0][0]
+		dx, dy := start.x-cur.x, start.y-cur.y
+		switch {
+		case dy == 0:
+			emit(csHMoveTo, false, dx)
+		case dx == 0:
+			emit(csVMoveTo, false, dy)
+		default:
+			emit(csRMoveTo, false, dx, dy)
+		}
+		cur, lineDir = start, 0
+
+		for _, seg := range contour[1:] {
+			if len(seg) == 1 {
+				dx, dy := seg[0].x-cur.x, seg[0].y-cur.y
+				cur = seg[0]
+				switch {
+				case dy == 0 || dx == 0:
+					dir := csHLineTo
+					if dx == 0 {
+						dir = csVLineTo
+					}
+					// 与上一条方向相反的水平/垂直直线可以接在同一个运算符后面
+					if (lastOp == csHLineTo || lastOp == csVLineTo) && lineDir != 0 && lineDir != dir && argCount < maxStack {
+						args = appendCharStringInt(args, dx+dy)
+						argCount++
+					} else {
+						emit(dir, false, dx+dy)
+					}
+					lineDir = dir
+				default:
+					emit(csRLineTo, true, dx, dy)
+					lineDir = 0
+				}
+				continue
+			}
+			vals := make([]int, 0, 6)
+			for _, p := range seg {
+				vals = append(vals, p.x-cur.x, p.y-cur.y)
+				cur = p
+			}
+			emit(csRRCurveTo, true, vals...)
+			lineDir = 0
+		}
+	}
+	emit(csEndChar, false)
+	flush()
+	return out
+}
+
+// appendCharStringInt 按 Type 2 charstring 格式编码整数
+func appendCharStringInt(out []byte, v int) []byte {
+	switch {
+	case v >= -107 && v <= 107:
+		return append(out, byte(v+139))
+	case v >= 108 && v <= 1131:
+		v -= 108
+		return append(out, byte(v>>8+247), byte(v))
+	case v >= -1131 && v <= -108:
+		v = -v - 108
+		return append(out, byte(v>>8+251), byte(v))
+	default:
+		return binary.BigEndian.AppendUint16(append(out, 28), uint16(int16(v)))
+	}
+}
+
+// writeFont 按标签顺序写出 sfnt 文件,重新计算表校验和与 head.checkSumAdjustment
+func writeFont(version []byte, tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	n := len(tags)
+	entrySelector := int(math.Floor(math.Log2(float64(n))))
+	searchRange := (1 << entrySelector) * 16
+
+	var buf bytes.Buffer
+	buf.Write(version)
+	binary.Write(&buf, binary.BigEndian, []uint16{uint16(n), uint16(searchRange), uint16(entrySelector), uint16(n*16 - searchRange)})
+
+	head := tables["head"]
+	binary.BigEndian.PutUint32(head[8:], 0)
+
+	offset := 12 + 16*n
+	for _, tag := range tags {
+		t := tables[tag]
+		buf.WriteString(tag)
+		binary.Write(&buf, binary.BigEndian, []uint32{checksum(t), uint32(offset), uint32(len(t))})
+		offset += (len(t) + 3) &^ 3
+	}
+	headOffset := 0
+	for _, tag := range tags {
+		if tag == "head" {
+			headOffset = buf.Len()
+		}
+		buf.Write(tables[tag])
+		buf.Write(make([]byte, (4-len(tables[tag])%4)%4))
+	}
+
+	font := buf.Bytes()
+	binary.BigEndian.PutUint32(font[headOffset+8:], 0xB1B0AFBA-checksum(font))
+	return font
+}
+
+// checksum 计算 sfnt 表校验和 (按大端 uint32 求和,末尾补零)
+func checksum(b []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(b); i += 4 {
+		var word [4]byte
+		copy(word[:], b[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
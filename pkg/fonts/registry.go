@@ -0,0 +1,433 @@
+package fonts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+// Default 默认字体注册表 (包含内置 Emoji 字体和中日韩回退字体)
+//
+// 启动时通过 LoadDir 加载额外的字体文件,之后在渲染时只读。
+var Default = NewRegistry()
+
+// fontFormats 支持的字体文件扩展名及对应的 @font-face format
+var fontFormats = map[string]string{
+	".ttf":   "truetype",
+	".otf":   "opentype",
+	".woff":  "woff",
+	".woff2": "woff2",
+}
+
+// fontExtensions @font-face format 对应的字体文件扩展名
+var fontExtensions = map[string]string{
+	"truetype": ".ttf",
+	"opentype": ".otf",
+	"woff":     ".woff",
+	"woff2":    ".woff2",
+}
+
+// BaseURL 已注册字体的 URL 前缀
+//
+// @font-face 只引用该前缀下的字体 URL,渲染器拦截这些请求并通过 Lookup 返回字体数据,
+// 字体不会内嵌到每个 HTML 文档中。域名使用保留的 .invalid 顶级域,不会产生实际的网络请求。
+const BaseURL = "https://fonts.gomarkdown2image.invalid/"
+
+// fontMIMETypes @font-face format 对应的 MIME 类型
+var fontMIMETypes = map[string]string{
+	"truetype": "font/ttf",
+	"opentype": "font/otf",
+	"woff":     "font/woff",
+	"woff2":    "font/woff2",
+}
+
+// assets 按文件名 (内容哈希) 索引的已注册字体,供 Lookup 使用
+var assets sync.Map
+
+// cjkProbes 用于检测字体是否包含中日韩字形的字符
+var cjkProbes = []rune{'中', 'あ', '한'}
+
+// Font 已注册的字体文件
+type Font struct {
+	Family       string // CSS font-family 名称
+	Weight       int    // 字重 (100-900,0 表示 400)
+	Italic       bool   // 是否为斜体
+	Format       string // @font-face format: truetype, opentype, woff, woff2
+	UnicodeRange string // unicode-range (可选)
+	CJK          bool   // 是否包含中日韩字形 (用作 CJK 回退字体)
+	Data         []byte // 字体文件内容
+
+	name string // 注册后的文件名 (内容哈希 + 扩展名)
+}
+
+// URL 返回字体文件的 URL (注册后有效)
+func (f *Font) URL() string {
+	return BaseURL + f.name
+}
+
+// MIMEType 返回字体文件的 MIME 类型
+func (f *Font) MIMEType() string {
+	return fontMIMETypes[f.Format]
+}
+
+// FontFace 返回该字体文件的 @font-face 规则 (通过 URL 引用字体)
+func (f *Font) FontFace() string {
+	weight := f.Weight
+	if weight == 0 {
+		weight = 400
+	}
+	style := "normal"
+	if f.Italic {
+		style = "italic"
+	}
+
+	var css strings.Builder
+	fmt.Fprintf(&css, `
+        @font-face {
+            font-family: "%s";
+            src: url("%s") format("%s");
+            font-weight: %d;
+            font-style: %s;
+            font-display: block;
+`, f.Family, f.URL(), f.Format, weight, style)
+	if f.UnicodeRange != "" {
+		fmt.Fprintf(&css, "            unicode-range: %s;\n", f.UnicodeRange)
+	}
+	css.WriteString("        }\n")
+	return css.String()
+}
+
+// Lookup 根据字体 URL 查找已注册的字体 (任意注册表)
+func Lookup(url string) (*Font, bool) {
+	name, ok := strings.CutPrefix(url, BaseURL)
+	if !ok {
+		return nil, false
+	}
+	f, ok := assets.Load(name)
+	if !ok {
+		return nil, false
+	}
+	return f.(*Font), true
+}
+
+// Registry 字体注册表,按 font-family 名称 (不区分大小写) 管理字体文件
+type Registry struct {
+	mu    sync.RWMutex
+	fonts map[string][]*Font
+}
+
+// NewRegistry 创建空的字体注册表
+func NewRegistry() *Registry {
+	return &Registry{fonts: make(map[string][]*Font)}
+}
+
+// Register 注册字体文件
+//
+// 同一 font-family 可以注册多个字重和样式。
+func (r *Registry) Register(f *Font) error {
+	if f.Family == "" {
+		return fmt.Errorf("font family is required")
+	}
+	// 名称会写入 CSS 字符串,拒绝可能破坏样式表的字符
+	if strings.ContainsAny(f.Family, "\"'\\<>;{}\n") {
+		return fmt.Errorf("invalid font family name: %q", f.Family)
+	}
+	if _, ok := fontMIMETypes[f.Format]; !ok {
+		return fmt.Errorf("unsupported font format: %s", f.Format)
+	}
+	if len(f.Data) == 0 {
+		return fmt.Errorf("font %s has no data", f.Family)
+	}
+
+	// 按内容命名,字体内容不同则 URL 不同
+	sum := sha256.Sum256(f.Data)
+	f.name = hex.EncodeToString(sum[:8]) + fontExtensions[f.Format]
+	assets.Store(f.name, f)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := strings.ToLower(f.Family)
+	r.fonts[key] = append(r.fonts[key], f)
+	return nil
+}
+
+// LoadFile 从文件加载并注册字体
+//
+// TrueType/OpenType 文件从 name 表读取字体族和样式,并检测是否包含中日韩字形;
+// WOFF/WOFF2 文件按文件名 "Family-Style.woff2" 推断。
+func (r *Registry) LoadFile(path string) (*Font, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	format, ok := fontFormats[ext]
+	if !ok {
+		return nil, fmt.Errorf("unsupported font file: %s (supported: .ttf, .otf, .woff, .woff2)", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font file: %w", err)
+	}
+
+	font := &Font{Format: format, Data: data}
+	if format == "truetype" || format == "opentype" {
+		if err := readFontInfo(font); err != nil {
+			return nil, fmt.Errorf("failed to parse font file %s: %w", path, err)
+		}
+	} else {
+		family, style, _ := strings.Cut(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), "-")
+		font.Family = family
+		font.Weight, font.Italic = parseStyle(style)
+		font.CJK = isCJKName(family)
+	}
+
+	if err := r.Register(font); err != nil {
+		return nil, err
+	}
+	return font, nil
+}
+
+// LoadDir 加载目录下的所有字体文件 (.ttf, .otf, .woff, .woff2)
+//
+// 返回:
+//   - []string: 新加载的字体族名称 (已排序去重)
+//   - error: 读取或解析错误(如有)
+func (r *Registry) LoadDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font directory: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var families []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if _, ok := fontFormats[strings.ToLower(filepath.Ext(entry.Name()))]; !ok {
+			continue
+		}
+
+		font, err := r.LoadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if !seen[font.Family] {
+			seen[font.Family] = true
+			families = append(families, font.Family)
+		}
+	}
+	sort.Strings(families)
+	return families, nil
+}
+
+// Families 返回所有已注册的字体族名称 (已排序)
+func (r *Registry) Families() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	families := make([]string, 0, len(r.fonts))
+	for _, list := range r.fonts {
+		families = append(families, list[0].Family)
+	}
+	sort.Strings(families)
+	return families
+}
+
+// Has 检查字体族是否已注册
+func (r *Registry) Has(family string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.fonts[strings.ToLower(family)]
+	return ok
+}
+
+// Fingerprint 返回已注册字体的摘要 (字体族、样式和内容哈希),用于渲染缓存键
+//
+// 字体文件被替换 (即使大小相同) 时摘要随之改变,缓存的渲染结果和 ETag 不再命中。
+func (r *Registry) Fingerprint() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := make([]string, 0, len(r.fonts))
+	for _, list := range r.fonts {
+		for _, f := range list {
+			items = append(items, fmt.Sprintf("%s/%d/%t/%s", f.Family, f.Weight, f.Italic, f.name))
+		}
+	}
+	sort.Strings(items)
+	return strings.Join(items, ";")
+}
+
+// CJKFamily 返回用作中日韩回退的字体族,未注册时返回空字符串
+//
+// 优先使用加载的字体 (按名称排序的第一个),其次是内置的 CJKFallbackFamily。
+func (r *Registry) CJKFamily() string {
+	for _, family := range r.Families() {
+		if family != CJKFallbackFamily && r.isCJK(family) {
+			return family
+		}
+	}
+	if r.isCJK(CJKFallbackFamily) {
+		return CJKFallbackFamily
+	}
+	return ""
+}
+
+// isCJK 检查字体族是否包含中日韩字形
+func (r *Registry) isCJK(family string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, f := range r.fonts[strings.ToLower(family)] {
+		if f.CJK {
+			return true
+		}
+	}
+	return false
+}
+
+// Stack 根据文本内容补全 font-family 列表
+//
+//   - 包含 Emoji 时,在首位加入内置 Emoji 字体 (通过 unicode-range 仅作用于 Emoji)
+//   - 包含中日韩文字且列表中没有 CJK 字体时,在末尾加入已注册的 CJK 回退字体
+func (r *Registry) Stack(fontFamily, text string) string {
+	families := SplitFamilies(fontFamily)
+
+	if ContainsCJK(text) {
+		hasCJK := false
+		for _, family := range families {
+			if r.isCJK(family) {
+				hasCJK = true
+				break
+			}
+		}
+		if cjk := r.CJKFamily(); !hasCJK && cjk != "" {
+			fontFamily = fmt.Sprintf(`%s, "%s"`, fontFamily, cjk)
+		}
+	}
+	if ContainsEmoji(text) && r.Has(EmojiFamily) {
+		fontFamily = fmt.Sprintf(`"%s", %s`, EmojiFamily, fontFamily)
+	}
+	return fontFamily
+}
+
+// FontFaces 返回 font-family 列表中已注册字体的 @font-face 规则
+//
+// 未注册的字体族 (系统字体和 sans-serif 等通用字体族) 会被忽略。
+func (r *Registry) FontFaces(fontFamilies ...string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var css strings.Builder
+	for _, fontFamily := range fontFamilies {
+		for _, family := range SplitFamilies(fontFamily) {
+			key := strings.ToLower(family)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			for _, f := range r.fonts[key] {
+				css.WriteString(f.FontFace())
+			}
+		}
+	}
+	return css.String()
+}
+
+// SplitFamilies 将 CSS font-family 列表拆分为字体族名称 (去除引号)
+func SplitFamilies(fontFamily string) []string {
+	var families []string
+	for _, family := range strings.Split(fontFamily, ",") {
+		family = strings.Trim(strings.TrimSpace(family), `"'`)
+		if family != "" {
+			families = append(families, family)
+		}
+	}
+	return families
+}
+
+// ContainsCJK 检查文本中是否包含中日韩文字
+func ContainsCJK(s string) bool {
+	for _, r := range s {
+		if r >= 0x1100 && unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			return true
+		}
+	}
+	return false
+}
+
+// readFontInfo 从 TrueType/OpenType 字体的 name 表读取字体族、样式和 CJK 覆盖情况
+func readFontInfo(font *Font) error {
+	f, err := sfnt.Parse(font.Data)
+	if err != nil {
+		return err
+	}
+
+	var buf sfnt.Buffer
+	name := func(ids ...sfnt.NameID) string {
+		for _, id := range ids {
+			if v, err := f.Name(&buf, id); err == nil && v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	font.Family = name(sfnt.NameIDTypographicFamily, sfnt.NameIDFamily)
+	font.Weight, font.Italic = parseStyle(name(sfnt.NameIDTypographicSubfamily, sfnt.NameIDSubfamily))
+	for _, r := range cjkProbes {
+		if idx, err := f.GlyphIndex(&buf, r); err == nil && idx != 0 {
+			font.CJK = true
+			break
+		}
+	}
+	return nil
+}
+
+// fontWeights 样式名称中的字重关键字 (按匹配优先级排列)
+var fontWeights = []struct {
+	keyword string
+	weight  int
+}{
+	{"extralight", 200}, {"ultralight", 200},
+	{"semibold", 600}, {"demibold", 600},
+	{"extrabold", 800}, {"ultrabold", 800},
+	{"thin", 100}, {"hairline", 100},
+	{"light", 300},
+	{"medium", 500},
+	{"bold", 700},
+	{"black", 900}, {"heavy", 900},
+}
+
+// parseStyle 从样式名称 (如 "Bold Italic"、"SemiBold") 解析字重和斜体
+func parseStyle(style string) (weight int, italic bool) {
+	s := strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(style))
+	italic = strings.Contains(s, "italic") || strings.Contains(s, "oblique")
+	for _, w := range fontWeights {
+		if strings.Contains(s, w.keyword) {
+			return w.weight, italic
+		}
+	}
+	return 400, italic
+}
+
+// isCJKName 根据字体族名称判断是否为中日韩字体 (用于无法解析字形的 WOFF/WOFF2 文件)
+func isCJKName(family string) bool {
+	upper := strings.ToUpper(family)
+	if strings.Contains(upper, "CJK") {
+		return true
+	}
+	for _, marker := range []string{"SC", "TC", "HK", "JP", "KR"} {
+		if strings.HasSuffix(upper, marker) || strings.Contains(upper, marker+" ") {
+			return true
+		}
+	}
+	return false
+}
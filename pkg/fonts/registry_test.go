@@ -0,0 +1,148 @@
+package fonts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRegistryLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"emoji.ttf":               notoColorEmoji,
+		"Noto Sans SC-Bold.woff2": []byte("wOF2"),
+		"Inter-Italic.woff":       []byte("wOFF"),
+		"README.txt":              []byte("ignored"),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewRegistry()
+	families, err := r.LoadDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+	if got := strings.Join(families, ","); got != "Inter,Noto Color Emoji,Noto Sans SC" {
+		t.Errorf("LoadDir() = %s", got)
+	}
+
+	// TrueType 字体从 name 表读取字体族,并检测中日韩字形
+	emoji := r.fonts["noto color emoji"][0]
+	if emoji.Weight != 400 || emoji.Italic || emoji.CJK {
+		t.Errorf("emoji font info = %+v", emoji)
+	}
+
+	sc := r.fonts["noto sans sc"][0]
+	if sc.Weight != 700 || !sc.CJK || sc.Format != "woff2" {
+		t.Errorf("woff2 font info: weight=%d cjk=%v format=%s", sc.Weight, sc.CJK, sc.Format)
+	}
+	if inter := r.fonts["inter"][0]; !inter.Italic || inter.CJK {
+		t.Errorf("woff font info: italic=%v cjk=%v", inter.Italic, inter.CJK)
+	}
+	if r.CJKFamily() != "Noto Sans SC" {
+		t.Errorf("CJKFamily() = %s", r.CJKFamily())
+	}
+
+	if _, err := r.LoadFile(filepath.Join(dir, "README.txt")); err == nil {
+		t.Error("LoadFile() should reject unsupported files")
+	}
+}
+
+func TestRegistryStack(t *testing.T) {
+	r := NewRegistry()
+	for _, f := range []*Font{
+		{Family: EmojiFamily, Format: "truetype", Data: []byte("emoji")},
+		{Family: "Noto Sans SC", Format: "woff2", CJK: true, Data: []byte("sc")},
+		{Family: "Inter", Format: "woff2", Weight: 700, Data: []byte("inter")},
+	} {
+		if err := r.Register(f); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+	if err := r.Register(&Font{Family: `x"; } body {`, Format: "woff2", Data: []byte("x")}); err == nil {
+		t.Error("Register() should reject family names that break CSS")
+	}
+
+	tests := []struct {
+		name string
+		font string
+		text string
+		want string
+	}{
+		{"纯英文", "Arial, sans-serif", "Hello", "Arial, sans-serif"},
+		{"中文回退", "Arial, sans-serif", "你好", `Arial, sans-serif, "Noto Sans SC"`},
+		{"已指定 CJK 字体", `"noto sans sc", serif`, "你好", `"noto sans sc", serif`},
+		{"Emoji", "Inter", "Hi 👋", `"Gomarkdown2image Emoji", Inter`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Stack(tt.font, tt.text); got != tt.want {
+				t.Errorf("Stack() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	css := r.FontFaces(`Inter, "Noto Sans SC", sans-serif`, "'Inter'")
+	if strings.Count(css, "@font-face") != 2 {
+		t.Errorf("FontFaces() should emit one rule per registered font file, got:\n%s", css)
+	}
+	for _, part := range []string{`font-family: "Inter"`, "font-weight: 700;", BaseURL, ".woff2\") format(\"woff2\")"} {
+		if !strings.Contains(css, part) {
+			t.Errorf("FontFaces() doesn't contain %q", part)
+		}
+	}
+	if r.FontFaces("Arial, sans-serif") != "" {
+		t.Error("FontFaces() should ignore unregistered families")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	f := &Font{Family: "Lookup Test", Format: "woff2", Data: []byte("lookup")}
+	if err := NewRegistry().Register(f); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		url  string
+		want bool
+	}{
+		{"已注册字体", f.URL(), true},
+		{"未知文件", BaseURL + "missing.woff2", false},
+		{"其他域名", "https://example.com/" + strings.TrimPrefix(f.URL(), BaseURL), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Lookup(tt.url)
+			if ok != tt.want || (ok && got != f) {
+				t.Errorf("Lookup(%q) = %v, %v, want %v", tt.url, got, ok, tt.want)
+			}
+		})
+	}
+	if !strings.HasSuffix(f.URL(), ".woff2") || f.MIMEType() != "font/woff2" {
+		t.Errorf("URL() = %s, MIMEType() = %s", f.URL(), f.MIMEType())
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	fingerprint := func(data string) string {
+		r := NewRegistry()
+		if err := r.Register(&Font{Family: "Inter", Weight: 400, Format: "woff2", Data: []byte(data)}); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+		return r.Fingerprint()
+	}
+
+	// 字体文件被替换为相同大小的不同内容时摘要改变
+	a, b := fingerprint("font-v1"), fingerprint("font-v2")
+	if a == b {
+		t.Errorf("Fingerprint() = %q for fonts with different contents", a)
+	}
+	if got := fingerprint("font-v1"); got != a {
+		t.Errorf("Fingerprint() = %q, want %q for the same font", got, a)
+	}
+}
@@ -0,0 +1,293 @@
+package parser
+
+import (
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	goldmarkparser "github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// 提示块类型 (与 GitHub alerts 一致)
+const (
+	AdmonitionNote      = "note"
+	AdmonitionTip       = "tip"
+	AdmonitionImportant = "important"
+	AdmonitionWarning   = "warning"
+	AdmonitionCaution   = "caution"
+)
+
+// AdmonitionTypes 所有提示块类型 (按 CSS 生成顺序)
+var AdmonitionTypes = []string{
+	AdmonitionNote,
+	AdmonitionTip,
+	AdmonitionImportant,
+	AdmonitionWarning,
+	AdmonitionCaution,
+}
+
+// admonitionAliases 容器语法支持的类型别名
+var admonitionAliases = map[string]string{
+	"note":      AdmonitionNote,
+	"info":      AdmonitionNote,
+	"tip":       AdmonitionTip,
+	"hint":      AdmonitionTip,
+	"important": AdmonitionImportant,
+	"warning":   AdmonitionWarning,
+	"caution":   AdmonitionCaution,
+	"danger":    AdmonitionCaution,
+}
+
+// admonitionTitles 默认标题
+var admonitionTitles = map[string]string{
+	AdmonitionNote:      "Note",
+	AdmonitionTip:       "Tip",
+	AdmonitionImportant: "Important",
+	AdmonitionWarning:   "Warning",
+	AdmonitionCaution:   "Caution",
+}
+
+// admonitionIcons 标题图标 (16x16 线条 SVG,颜色跟随标题)
+var admonitionIcons = map[string]string{
+	AdmonitionNote:      `<circle cx="8" cy="8" r="6.5"/><path d="M8 7.5v4M8 4.5v.5"/>`,
+	AdmonitionTip:       `<path d="M6 12.5h4M6.5 14.5h3M8 1.5a4.5 4.5 0 0 0-2.5 8.2V11h5V9.7A4.5 4.5 0 0 0 8 1.5z"/>`,
+	AdmonitionImportant: `<path d="M2 2.5h12v8.5H7l-3 3v-3H2z"/><path d="M8 4.5v3.5M8 9.5v.5"/>`,
+	AdmonitionWarning:   `<path d="M8 1.5l6.5 12h-13z"/><path d="M8 6v3.5M8 11.5v.5"/>`,
+	AdmonitionCaution:   `<path d="M5.3 1.5h5.4l3.8 3.8v5.4l-3.8 3.8H5.3l-3.8-3.8V5.3z"/><path d="M8 4.5v4M8 10.5v.5"/>`,
+}
+
+var (
+	// alertMarkerPattern GitHub alert 标记行: [!NOTE] 可选标题
+	alertMarkerPattern = regexp.MustCompile(`^\s*\[!(?i:(note|tip|important|warning|caution))\]\s*(.*?)\s*$`)
+
+	// containerOpenPattern 容器开始行: :::tip 可选标题
+	containerOpenPattern = regexp.MustCompile(`^\s*:::\s*([A-Za-z]+)\s*(.*?)\s*$`)
+
+	// containerClosePattern 容器结束行: :::
+	containerClosePattern = regexp.MustCompile(`^\s*:::\s*$`)
+)
+
+// KindAdmonition 提示块节点类型
+var KindAdmonition = ast.NewNodeKind("Admonition")
+
+// Admonition 提示块节点 (GitHub alert 或 ::: 容器)
+type Admonition struct {
+	ast.BaseBlock
+	AlertType string // 提示块类型 (note, tip, important, warning, caution)
+	Title     string // 自定义标题 (为空时使用默认标题)
+}
+
+// NewAdmonition 创建提示块节点
+func NewAdmonition(typ, title string) *Admonition {
+	return &Admonition{AlertType: typ, Title: title}
+}
+
+// Kind 实现 ast.Node 接口
+func (n *Admonition) Kind() ast.NodeKind {
+	return KindAdmonition
+}
+
+// Dump 实现 ast.Node 接口
+func (n *Admonition) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"AlertType": n.AlertType, "Title": n.Title}, nil)
+}
+
+// Admonitions 提示块扩展
+//
+// 支持两种语法:
+//
+//	> [!NOTE] 可选标题
+//	> 内容
+//
+//	:::tip 可选标题
+//	内容
+//	:::
+var Admonitions goldmark.Extender = &admonitionExtension{}
+
+type admonitionExtension struct{}
+
+// Extend 实现 goldmark.Extender 接口
+func (e *admonitionExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		goldmarkparser.WithBlockParsers(util.Prioritized(&containerParser{}, 650)),
+		goldmarkparser.WithASTTransformers(util.Prioritized(&alertTransformer{}, 100)),
+	)
+	m.Renderer().AddOptions(
+		renderer.WithNodeRenderers(util.Prioritized(&admonitionRenderer{}, 500)),
+	)
+}
+
+// containerParser 解析 ::: 容器
+type containerParser struct{}
+
+func (p *containerParser) Trigger() []byte {
+	return []byte{':'}
+}
+
+func (p *containerParser) Open(parent ast.Node, reader text.Reader, pc goldmarkparser.Context) (ast.Node, goldmarkparser.State) {
+	line, _ := reader.PeekLine()
+	m := containerOpenPattern.FindSubmatch(line)
+	if m == nil {
+		return nil, goldmarkparser.NoChildren
+	}
+	typ, ok := admonitionAliases[strings.ToLower(string(m[1]))]
+	if !ok {
+		return nil, goldmarkparser.NoChildren
+	}
+
+	reader.AdvanceToEOL()
+	return NewAdmonition(typ, string(m[2])), goldmarkparser.HasChildren
+}
+
+func (p *containerParser) Continue(node ast.Node, reader text.Reader, pc goldmarkparser.Context) goldmarkparser.State {
+	line, _ := reader.PeekLine()
+	if containerClosePattern.Match(line) {
+		reader.AdvanceToEOL()
+		return goldmarkparser.Close
+	}
+	return goldmarkparser.Continue | goldmarkparser.HasChildren
+}
+
+func (p *containerParser) Close(node ast.Node, reader text.Reader, pc goldmarkparser.Context) {}
+
+func (p *containerParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (p *containerParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// alertTransformer 将首行为 [!TYPE] 的引用块转换为提示块
+type alertTransformer struct{}
+
+func (t *alertTransformer) Transform(doc *ast.Document, reader text.Reader, pc goldmarkparser.Context) {
+	source := reader.Source()
+
+	var quotes []*ast.Blockquote
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if bq, ok := n.(*ast.Blockquote); ok && entering {
+			quotes = append(quotes, bq)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, bq := range quotes {
+		para, ok := bq.FirstChild().(*ast.Paragraph)
+		if !ok || para.Lines().Len() == 0 {
+			continue
+		}
+		first := para.Lines().At(0)
+		m := alertMarkerPattern.FindSubmatch(first.Value(source))
+		if m == nil {
+			continue
+		}
+
+		// 移除标记行的行内节点
+		for c := para.FirstChild(); c != nil; {
+			next := c.NextSibling()
+			para.RemoveChild(para, c)
+			if txt, ok := c.(*ast.Text); ok && (txt.SoftLineBreak() || txt.HardLineBreak() || txt.Segment.Stop >= first.Stop) {
+				break
+			}
+			c = next
+		}
+		if !para.HasChildren() {
+			bq.RemoveChild(bq, para)
+		}
+
+		adm := NewAdmonition(strings.ToLower(string(m[1])), string(m[2]))
+		for c := bq.FirstChild(); c != nil; {
+			next := c.NextSibling()
+			adm.AppendChild(adm, c)
+			c = next
+		}
+		bq.Parent().ReplaceChild(bq.Parent(), bq, adm)
+	}
+}
+
+// admonitionRenderer 渲染提示块
+type admonitionRenderer struct{}
+
+func (r *admonitionRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindAdmonition, r.render)
+}
+
+func (r *admonitionRenderer) render(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*Admonition)
+	if !entering {
+		_, _ = w.WriteString("</div>\n")
+		return ast.WalkContinue, nil
+	}
+
+	title := n.Title
+	if title == "" {
+		title = admonitionTitles[n.AlertType]
+	}
+	fmt.Fprintf(w, `<div class="admonition admonition-%s">`+"\n", n.AlertType)
+	fmt.Fprintf(w,
+		`<p class="admonition-title"><svg class="admonition-icon" viewBox="0 0 16 16" width="16" height="16" fill="none" stroke="currentColor" stroke-width="1.5" stroke-linecap="round" stroke-linejoin="round" aria-hidden="true">%s</svg>%s</p>`+"\n",
+		admonitionIcons[n.AlertType], template.HTMLEscapeString(title))
+	return ast.WalkContinue, nil
+}
+
+// generateAdmonitionCSS 生成提示块样式 (按主题配色)
+func generateAdmonitionCSS(theme string) string {
+	var css strings.Builder
+	css.WriteString(`
+        .admonition {
+            margin-bottom: 16px;
+            padding: 8px 16px;
+            border-inline-start: 4px solid;
+            border-start-end-radius: 6px;
+            border-end-end-radius: 6px;
+        }
+
+        .admonition > :last-child {
+            margin-bottom: 8px;
+        }
+
+        .admonition-title {
+            display: flex;
+            align-items: center;
+            gap: 8px;
+            margin-bottom: 8px;
+            font-weight: 600;
+        }
+`)
+
+	colors := getAdmonitionColors(theme)
+	for _, typ := range AdmonitionTypes {
+		fmt.Fprintf(&css, `
+        .admonition-%[1]s { border-inline-start-color: %[2]s; background-color: %[3]s; }
+        .admonition-%[1]s .admonition-title { color: %[2]s; }
+`, typ, colors[typ][0], colors[typ][1])
+	}
+	return css.String()
+}
+
+// getAdmonitionColors 返回提示块的强调色和背景色
+func getAdmonitionColors(theme string) map[string][2]string {
+	if theme == "dark" {
+		return map[string][2]string{
+			AdmonitionNote:      {"#4493f8", "rgba(68,147,248,0.1)"},
+			AdmonitionTip:       {"#3fb950", "rgba(63,185,80,0.1)"},
+			AdmonitionImportant: {"#ab7df8", "rgba(171,125,248,0.1)"},
+			AdmonitionWarning:   {"#d29922", "rgba(210,153,34,0.1)"},
+			AdmonitionCaution:   {"#f85149", "rgba(248,81,73,0.1)"},
+		}
+	}
+	return map[string][2]string{
+		AdmonitionNote:      {"#0969da", "#ddf4ff"},
+		AdmonitionTip:       {"#1a7f37", "#dafbe1"},
+		AdmonitionImportant: {"#8250df", "#fbefff"},
+		AdmonitionWarning:   {"#9a6700", "#fff8c5"},
+		AdmonitionCaution:   {"#d1242f", "#ffebe9"},
+	}
+}
@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 页眉页脚占位符
+//
+// 占位符的值会进行 HTML 转义,页眉页脚本身可以是纯文本或 HTML 片段:
+//
+//	{{title}}  页面标题
+//	{{date}}   front matter 的 date (默认当天日期)
+//	{{author}} front matter 的 author
+//	{{url}}    front matter 的 url
+//	{{page}}   当前页码 (分页模式,否则为 1)
+//	{{pages}}  总页数 (分页模式,否则为 1)
+
+// ValidateImageURL 验证图片地址是否为允许的 URL 形式
+//
+// 仅允许网络图片 (http/https) 和内嵌图片 (data:image/),避免访问本地文件。
+func ValidateImageURL(url string) error {
+	lower := strings.ToLower(url)
+	if strings.HasPrefix(lower, "http://") ||
+		strings.HasPrefix(lower, "https://") ||
+		strings.HasPrefix(lower, "data:image/") {
+		return nil
+	}
+	return fmt.Errorf("unsupported image URL: must be http(s) or data:image/")
+}
+
+// renderBranding 生成第 page 页 (共 pages 页) 的页眉和页脚 HTML
+func renderBranding(tmpl *HTMLTemplate, page, pages int) (header, footer template.HTML, err error) {
+	if tmpl.Header == "" && tmpl.Footer == "" && tmpl.Logo == "" {
+		return "", "", nil
+	}
+
+	date := tmpl.FrontMatter.String("date")
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	}
+	replacer := strings.NewReplacer(
+		"{{title}}", template.HTMLEscapeString(tmpl.Title),
+		"{{date}}", template.HTMLEscapeString(date),
+		"{{author}}", template.HTMLEscapeString(tmpl.FrontMatter.String("author")),
+		"{{url}}", template.HTMLEscapeString(tmpl.FrontMatter.String("url")),
+		"{{page}}", strconv.Itoa(page),
+		"{{pages}}", strconv.Itoa(pages),
+	)
+
+	headerHTML := replacer.Replace(tmpl.Header)
+	if tmpl.Logo != "" {
+		if err := ValidateImageURL(tmpl.Logo); err != nil {
+			return "", "", err
+		}
+		logo := fmt.Sprintf(`<img class="page-logo" src="%s" alt="">`, template.HTMLEscapeString(tmpl.Logo))
+		headerHTML = logo + `<span class="page-header-text">` + headerHTML + "</span>"
+	}
+
+	return template.HTML(headerHTML), template.HTML(replacer.Replace(tmpl.Footer)), nil
+}
+
+// generateBrandingCSS 生成页眉页脚样式
+func generateBrandingCSS(tmpl *HTMLTemplate) string {
+	return fmt.Sprintf(`
+        .page-header, .page-footer {
+            display: flex;
+            align-items: center;
+            justify-content: space-between;
+            gap: 16px;
+            font-size: 0.85em;
+            color: %s;
+        }
+
+        .page-header {
+            margin-bottom: 24px;
+            padding-bottom: 12px;
+            border-bottom: 1px solid %s;
+        }
+
+        .page-footer {
+            margin-top: 24px;
+            padding-top: 12px;
+            border-top: 1px solid %s;
+        }
+
+        .page-logo {
+            display: inline-block;
+            height: 32px;
+            width: auto;
+            margin: 0;
+        }
+
+        .page-header-text {
+            flex: 1;
+            text-align: end;
+        }
+`,
+		getMutedColor(tmpl.Theme),
+		getBorderColor(tmpl.Theme),
+		getBorderColor(tmpl.Theme),
+	)
+}
+
+func getBorderColor(theme string) string {
+	if theme == "dark" {
+		return "#30363d"
+	}
+	return "#d0d7de"
+}
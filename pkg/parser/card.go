@@ -0,0 +1,284 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"sort"
+
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/fonts"
+)
+
+// PageSize 固定页面尺寸 (社交卡片、幻灯片等)
+type PageSize struct {
+	Width  int // 宽度 (像素)
+	Height int // 高度 (像素)
+}
+
+// CardPresets 预置的社交卡片尺寸
+var CardPresets = map[string]PageSize{
+	"og":      {Width: 1200, Height: 630},  // Open Graph (Facebook, LinkedIn, Slack 等)
+	"twitter": {Width: 1200, Height: 628},  // Twitter/X summary_large_image
+	"square":  {Width: 1080, Height: 1080}, // 正方形 (Instagram 等)
+}
+
+// DefaultCardPreset 默认社交卡片预设
+const DefaultCardPreset = "og"
+
+// LookupCardPreset 根据名称查找社交卡片尺寸
+func LookupCardPreset(name string) (PageSize, error) {
+	if name == "" {
+		name = DefaultCardPreset
+	}
+	size, ok := CardPresets[name]
+	if !ok {
+		names := make([]string, 0, len(CardPresets))
+		for n := range CardPresets {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return PageSize{}, fmt.Errorf("unknown card preset: %s (available: %v)", name, names)
+	}
+	return size, nil
+}
+
+// CardTemplate 社交卡片 (Open Graph 图片) 模板配置
+type CardTemplate struct {
+	Title       string // 标题
+	Description string // 描述
+	Author      string // 作者
+	Date        string // 日期
+	Logo        string // Logo 图片 URL (可选,支持 http(s) 和 data:image/ URI)
+	Theme       string // 主题名称 (light, dark)
+	FontFamily  string // 字体族
+	Lang        string // 语言 (默认 DefaultLang)
+	Dir         string // 文字方向: auto (默认), ltr, rtl
+	Transparent bool   // 透明背景
+	Size        PageSize
+	Watermark   *Watermark // 水印 (可选)
+}
+
+// cardData 卡片模板渲染数据
+type cardData struct {
+	*CardTemplate
+	Lang        string
+	Dir         string
+	Size        PageSize
+	LogoURL     template.URL
+	Background  template.CSS
+	TextColor   template.CSS
+	MutedColor  template.CSS
+	AccentColor template.CSS
+	FontFamily  template.CSS
+	FontFace    template.CSS
+	TitleSize   int
+	DescSize    int
+	TitleMax    int
+	DescMax     int
+	Watermark   template.HTML
+}
+
+// cardHTML 社交卡片 HTML 模板
+//
+// 标题和描述通过 data-fit 属性在页面加载时自动缩小字号,直到内容完全放入文本框。
+var cardHTML = template.Must(template.New("card").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}" dir="{{.Dir}}">
+<head>
+    <meta charset="UTF-8">
+    <title>{{.Title}}</title>
+    <style>
+        {{.FontFace}}
+        * { margin: 0; padding: 0; box-sizing: border-box; }
+
+        html, body {
+            width: {{.Size.Width}}px;
+            height: {{.Size.Height}}px;
+            overflow: hidden;
+            background: transparent;
+        }
+
+        .card {
+            position: relative;
+            display: flex;
+            flex-direction: column;
+            width: 100%;
+            height: 100%;
+            padding: 64px 72px;
+            background: {{.Background}};
+            color: {{.TextColor}};
+            font-family: {{.FontFamily}};
+            border-top: 12px solid {{.AccentColor}};
+        }
+
+        .card-logo {
+            height: 56px;
+            margin-bottom: 32px;
+        }
+
+        .card-logo img {
+            height: 100%;
+            width: auto;
+        }
+
+        .card-body {
+            flex: 1;
+            display: flex;
+            flex-direction: column;
+            justify-content: center;
+            overflow: hidden;
+        }
+
+        .card-title {
+            font-size: {{.TitleSize}}px;
+            font-weight: 700;
+            line-height: 1.2;
+            max-height: {{.TitleMax}}px;
+            overflow: hidden;
+            word-break: break-word;
+        }
+
+        .card-description {
+            margin-top: 24px;
+            font-size: {{.DescSize}}px;
+            line-height: 1.4;
+            max-height: {{.DescMax}}px;
+            overflow: hidden;
+            color: {{.MutedColor}};
+            word-break: break-word;
+        }
+
+        .card-footer {
+            display: flex;
+            justify-content: space-between;
+            margin-top: 32px;
+            font-size: 28px;
+            color: {{.MutedColor}};
+        }
+
+        .card-author { font-weight: 600; color: {{.TextColor}}; }
+    </style>
+</head>
+<body>
+    <div class="card">
+        {{- if .LogoURL}}
+        <div class="card-logo"><img src="{{.LogoURL}}" alt=""></div>
+        {{- end}}
+        <div class="card-body">
+            <h1 class="card-title" data-fit>{{.Title}}</h1>
+            {{- if .Description}}
+            <p class="card-description" data-fit>{{.Description}}</p>
+            {{- end}}
+        </div>
+        {{- if or .Author .Date}}
+        <div class="card-footer">
+            <span class="card-author">{{.Author}}</span>
+            <span class="card-date">{{.Date}}</span>
+        </div>
+        {{- end}}
+        {{.Watermark}}
+    </div>
+    <script>
+        document.querySelectorAll('[data-fit]').forEach(function (el) {
+            var size = parseFloat(getComputedStyle(el).fontSize);
+            while (el.scrollHeight > el.clientHeight && size > 16) {
+                size -= 2;
+                el.style.fontSize = size + 'px';
+            }
+        });
+    </script>
+</body>
+</html>`))
+
+// WrapCardHTML 生成社交卡片的完整 HTML 文档
+//
+// 参数:
+//   - card: 卡片模板配置
+//
+// 返回:
+//   - string: 完整的 HTML 文档
+//   - error: 模板渲染错误(如有)
+func WrapCardHTML(card *CardTemplate) (string, error) {
+	if card == nil {
+		return "", fmt.Errorf("card template cannot be nil")
+	}
+	size := card.Size
+	if size.Width <= 0 || size.Height <= 0 {
+		size = CardPresets[DefaultCardPreset]
+	}
+
+	fontFamily := card.FontFamily
+	if fontFamily == "" {
+		fontFamily = DefaultTemplate().FontFamily
+	}
+
+	background := getContainerColor(card.Theme)
+	if card.Transparent {
+		background = "transparent"
+	}
+
+	// Logo 仅允许网络图片或内嵌图片,避免访问本地文件
+	var logoURL template.URL
+	if card.Logo != "" {
+		if err := ValidateImageURL(card.Logo); err != nil {
+			return "", err
+		}
+		logoURL = template.URL(card.Logo)
+	}
+
+	lang := card.Lang
+	if lang == "" {
+		lang = DefaultLang
+	}
+
+	// 注入已注册字体 (含 Emoji 和中日韩回退字体)
+	text := card.Title + card.Description + card.Author + card.Date
+	fontFamily = fonts.Default.Stack(fontFamily, text)
+	fontFace := fonts.Default.FontFaces(fontFamily)
+
+	watermark, err := renderWatermark(card.Watermark, card.Theme)
+	if err != nil {
+		return "", err
+	}
+
+	// 按卡片高度分配标题和描述的文本框
+	contentHeight := size.Height - 128
+	data := &cardData{
+		CardTemplate: card,
+		Lang:         lang,
+		Dir:          ResolveDir(card.Dir, lang, text),
+		Size:         size,
+		LogoURL:      logoURL,
+		Background:   template.CSS(background),
+		TextColor:    template.CSS(getTextColor(card.Theme)),
+		MutedColor:   template.CSS(getMutedColor(card.Theme)),
+		AccentColor:  template.CSS(getAccentColor(card.Theme)),
+		FontFamily:   template.CSS(fontFamily),
+		FontFace:     template.CSS(fontFace),
+		TitleSize:    size.Width / 16,
+		DescSize:     size.Width / 36,
+		TitleMax:     contentHeight * 45 / 100,
+		DescMax:      contentHeight * 22 / 100,
+		Watermark:    watermark,
+	}
+
+	var buf bytes.Buffer
+	if err := cardHTML.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render card template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+func getMutedColor(theme string) string {
+	if theme == "dark" {
+		return "#8b949e"
+	}
+	return "#57606a"
+}
+
+func getAccentColor(theme string) string {
+	if theme == "dark" {
+		return "#58a6ff"
+	}
+	return "#0969da"
+}
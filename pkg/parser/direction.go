@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/language"
+)
+
+// 文字方向
+const (
+	DirAuto = "auto" // 根据语言和内容检测 (默认)
+	DirLTR  = "ltr"  // 从左到右
+	DirRTL  = "rtl"  // 从右到左
+)
+
+// rtlScripts 从右到左书写的文字
+var rtlScripts = []*unicode.RangeTable{
+	unicode.Arabic,
+	unicode.Hebrew,
+	unicode.Syriac,
+	unicode.Thaana,
+	unicode.Nko,
+	unicode.Samaritan,
+	unicode.Mandaic,
+}
+
+// rtlLanguages 使用从右到左文字的语言 (主语言子标签)
+var rtlLanguages = map[string]bool{
+	"ar": true, "arc": true, "ckb": true, "dv": true, "fa": true, "he": true,
+	"iw": true, "ks": true, "ps": true, "sd": true, "ug": true, "ur": true, "yi": true,
+}
+
+// 检测文字方向时忽略的代码块和 HTML 标签
+var (
+	preBlockPattern = regexp.MustCompile(`(?is)<pre[\s>].*?</pre>`)
+	htmlTagPattern  = regexp.MustCompile(`<[^>]*>`)
+)
+
+// ValidateLang 校验 BCP 47 语言标签 (如 zh-CN、en、ar-EG)
+func ValidateLang(lang string) error {
+	if _, err := language.Parse(lang); err != nil {
+		return fmt.Errorf("invalid language tag: %s", lang)
+	}
+	return nil
+}
+
+// ValidateDir 校验文字方向 (空值视为 auto)
+func ValidateDir(dir string) error {
+	switch dir {
+	case "", DirAuto, DirLTR, DirRTL:
+		return nil
+	}
+	return fmt.Errorf("invalid text direction: %s (available: auto, ltr, rtl)", dir)
+}
+
+// IsRTLLang 检查语言是否使用从右到左的文字
+func IsRTLLang(lang string) bool {
+	primary, _, _ := strings.Cut(strings.ReplaceAll(lang, "_", "-"), "-")
+	return rtlLanguages[strings.ToLower(primary)]
+}
+
+// DetectDir 根据文本中的强方向字符检测文字方向
+//
+// 从右到左的字母多于从左到右的字母时返回 DirRTL,否则返回 DirLTR。
+// 传入 HTML 时忽略标签和代码块。
+func DetectDir(text string) string {
+	text = htmlTagPattern.ReplaceAllString(preBlockPattern.ReplaceAllString(text, ""), "")
+
+	rtl, ltr := 0, 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if unicode.In(r, rtlScripts...) {
+			rtl++
+		} else {
+			ltr++
+		}
+	}
+	if rtl > ltr {
+		return DirRTL
+	}
+	return DirLTR
+}
+
+// ResolveDir 计算最终的文字方向
+//
+// 显式指定 ltr/rtl 时直接使用;auto 或空值时,
+// 从右到左的语言使用 rtl,否则根据内容检测。
+func ResolveDir(dir, lang, text string) string {
+	switch dir {
+	case DirLTR, DirRTL:
+		return dir
+	}
+	if IsRTLLang(lang) {
+		return DirRTL
+	}
+	return DetectDir(text)
+}
@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/yuin/goldmark"
+	emoji "github.com/yuin/goldmark-emoji"
+	"github.com/yuin/goldmark/extension"
+	goldmarkparser "github.com/yuin/goldmark/parser"
+)
+
+// 可选的 Markdown 扩展名称
+const (
+	ExtFootnote       = "footnote"    // 脚注 ([^1])
+	ExtDefinitionList = "deflist"     // 定义列表 (术语\n: 定义)
+	ExtAttributes     = "attributes"  // 标题属性 ({#id .class})
+	ExtCJK            = "cjk"         // 中日韩换行优化 (软换行不插入空格)
+	ExtEmoji          = "emoji"       // Emoji 短代码 (:smile:)
+	ExtHeadingIDs     = "heading-ids" // 标题锚点 ID (目录和章节截图依赖)
+	ExtWikilink       = "wikilink"    // Wiki 链接 ([[页面|文本]])
+)
+
+// AvailableExtensions 所有可选扩展 (按名称排序)
+var AvailableExtensions = []string{
+	ExtAttributes,
+	ExtCJK,
+	ExtDefinitionList,
+	ExtEmoji,
+	ExtFootnote,
+	ExtHeadingIDs,
+	ExtWikilink,
+}
+
+// DefaultExtensions 默认启用的扩展
+var DefaultExtensions = []string{ExtEmoji, ExtHeadingIDs}
+
+// ResolveExtensions 计算最终启用的扩展集合
+//
+// names 中的每一项可以是逗号分隔的列表:
+//   - "footnote": 在默认扩展基础上启用
+//   - "-heading-ids": 禁用默认扩展
+//
+// 返回:
+//   - []string: 去重并排序后的扩展名称
+//   - error: 未知扩展名称
+func ResolveExtensions(names []string) ([]string, error) {
+	enabled := make(map[string]bool, len(AvailableExtensions))
+	for _, name := range DefaultExtensions {
+		enabled[name] = true
+	}
+
+	for _, item := range names {
+		for _, name := range strings.Split(item, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			on := !strings.HasPrefix(name, "-")
+			name = strings.TrimPrefix(name, "-")
+			if !isAvailableExtension(name) {
+				return nil, fmt.Errorf("unknown markdown extension: %s (available: %v)", name, AvailableExtensions)
+			}
+			enabled[name] = on
+		}
+	}
+
+	result := make([]string, 0, len(enabled))
+	for name, on := range enabled {
+		if on {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// isAvailableExtension 检查扩展名称是否有效
+func isAvailableExtension(name string) bool {
+	for _, ext := range AvailableExtensions {
+		if ext == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parserCache 按扩展集合缓存的解析器 (goldmark 实例可并发使用)
+var parserCache sync.Map
+
+// CachedGoldmarkParser 返回启用指定扩展的解析器
+//
+// 扩展名称规则同 ResolveExtensions,相同的扩展集合共享同一个解析器实例。
+func CachedGoldmarkParser(names []string) (*GoldmarkParser, error) {
+	exts, err := ResolveExtensions(names)
+	if err != nil {
+		return nil, err
+	}
+
+	key := strings.Join(exts, ",")
+	if p, ok := parserCache.Load(key); ok {
+		return p.(*GoldmarkParser), nil
+	}
+	p, _ := parserCache.LoadOrStore(key, newGoldmarkParser(exts))
+	return p.(*GoldmarkParser), nil
+}
+
+// newGoldmarkParser 创建启用指定扩展的解析器 (exts 须已通过 ResolveExtensions 校验)
+func newGoldmarkParser(exts []string) *GoldmarkParser {
+	var extenders []goldmark.Extender
+	var parserOpts []goldmarkparser.Option
+
+	for _, name := range exts {
+		switch name {
+		case ExtFootnote:
+			extenders = append(extenders, extension.Footnote)
+		case ExtDefinitionList:
+			extenders = append(extenders, extension.DefinitionList)
+		case ExtAttributes:
+			parserOpts = append(parserOpts, goldmarkparser.WithAttribute())
+		case ExtCJK:
+			extenders = append(extenders, extension.CJK)
+		case ExtEmoji:
+			extenders = append(extenders, emoji.New(emoji.WithRenderingMethod(emoji.Unicode)))
+		case ExtHeadingIDs:
+			parserOpts = append(parserOpts, goldmarkparser.WithAutoHeadingID())
+		case ExtWikilink:
+			extenders = append(extenders, Wikilinks)
+		}
+	}
+
+	return &GoldmarkParser{md: newGoldmark(extenders, parserOpts)}
+}
@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+// FrontMatter Markdown 文档头部的 YAML 元数据
+//
+// 示例:
+//
+//	---
+//	title: 发布说明
+//	author: Alice
+//	date: 2024-01-01
+//	---
+type FrontMatter map[string]interface{}
+
+// String 以字符串形式返回指定字段,字段不存在时返回空字符串
+func (fm FrontMatter) String(key string) string {
+	v, ok := fm[key]
+	if !ok || v == nil {
+		return ""
+	}
+
+	switch val := v.(type) {
+	case string:
+		return val
+	case time.Time:
+		return val.Format("2006-01-02")
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// ExtractFrontMatter 从 Markdown 中分离 YAML front matter
+//
+// front matter 必须位于文档开头,以单独一行的 "---" 开始和结束,
+// 且内容必须是 YAML 映射。不满足条件时视为普通 Markdown 原样返回,
+// 以免误伤以分隔线开头的文档。
+//
+// 返回:
+//   - FrontMatter: 解析出的元数据 (无 front matter 时为 nil)
+//   - []byte: 去除 front matter 后的 Markdown 正文
+func ExtractFrontMatter(markdown []byte) (FrontMatter, []byte) {
+	const delimiter = "---"
+
+	content := bytes.TrimPrefix(markdown, []byte("\ufeff"))
+	firstLine, rest, found := bytes.Cut(content, []byte("\n"))
+	if !found || string(bytes.TrimRight(firstLine, " \t\r")) != delimiter {
+		return nil, markdown
+	}
+
+	// 查找结束分隔符
+	offset := 0
+	for offset <= len(rest) {
+		line, next, more := bytes.Cut(rest[offset:], []byte("\n"))
+		if string(bytes.TrimRight(line, " \t\r")) == delimiter {
+			var fm FrontMatter
+			if err := yaml.Unmarshal(rest[:offset], &fm); err != nil {
+				return nil, markdown
+			}
+			if fm == nil {
+				fm = FrontMatter{}
+			}
+			if !more {
+				return fm, nil
+			}
+			return fm, next
+		}
+		if !more {
+			break
+		}
+		offset += len(line) + 1
+	}
+
+	return nil, markdown
+}
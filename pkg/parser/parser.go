@@ -11,9 +11,14 @@ import (
 	"github.com/yuin/goldmark"
 	highlighting "github.com/yuin/goldmark-highlighting/v2"
 	"github.com/yuin/goldmark/extension"
+	goldmarkparser "github.com/yuin/goldmark/parser"
 	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
 )
 
+// DefaultCodeStyle 默认代码高亮主题 (Chroma 样式名称)
+const DefaultCodeStyle = "monokai"
+
 // Parser Markdown 解析器接口
 type Parser interface {
 	// Parse 将 Markdown 转换为 HTML
@@ -25,33 +30,45 @@ type GoldmarkParser struct {
 	md goldmark.Markdown
 }
 
-// NewGoldmarkParser 创建新的 Goldmark 解析器
+// NewGoldmarkParser 创建新的 Goldmark 解析器 (启用 DefaultExtensions)
 //
 // 特性:
 //   - 支持 CommonMark 标准
 //   - 支持 GFM 扩展 (表格、删除线、自动链接等)
 //   - 支持代码语法高亮 (使用 Chroma)
+//   - 支持 GitHub alerts (> [!NOTE]) 和 ::: 容器提示块
+//   - 支持 Emoji 短代码 (:rocket: → 🚀)
+//   - 自动为标题生成锚点 ID (用于章节截图)
+//
+// 需要脚注、定义列表等可选扩展时使用 CachedGoldmarkParser。
 func NewGoldmarkParser() *GoldmarkParser {
-	md := goldmark.New(
-		goldmark.WithExtensions(
-			extension.GFM,         // GitHub Flavored Markdown
-			extension.Typographer, // 智能标点符号
-			highlighting.NewHighlighting(
-				highlighting.WithStyle("monokai"), // 代码高亮主题
-				highlighting.WithFormatOptions(
-					html.WithLineNumbers(true), // 显示行号
-					html.WithClasses(true),     // 使用 CSS 类
-				),
+	return newGoldmarkParser(DefaultExtensions)
+}
+
+// newGoldmark 创建 goldmark 实例,在基础扩展之上追加可选扩展和解析选项
+func newGoldmark(extenders []goldmark.Extender, parserOpts []goldmarkparser.Option) goldmark.Markdown {
+	base := []goldmark.Extender{
+		extension.GFM,         // GitHub Flavored Markdown
+		extension.Typographer, // 智能标点符号
+		Admonitions,           // GitHub alerts 和 ::: 提示块
+		highlighting.NewHighlighting(
+			highlighting.WithStyle(DefaultCodeStyle), // 代码高亮主题
+			highlighting.WithFormatOptions(
+				html.WithLineNumbers(true), // 显示行号
+				html.WithClasses(true),     // 使用 CSS 类
 			),
 		),
+	}
+
+	return goldmark.New(
+		goldmark.WithExtensions(append(base, extenders...)...),
+		goldmark.WithParserOptions(parserOpts...),
 		goldmark.WithRendererOptions(
 			goldmarkhtml.WithHardWraps(), // 硬换行
 			goldmarkhtml.WithXHTML(),     // 使用 XHTML 标签
 			goldmarkhtml.WithUnsafe(),    // 允许原始 HTML
 		),
 	)
-
-	return &GoldmarkParser{md: md}
 }
 
 // Parse 将 Markdown 文本转换为 HTML
@@ -63,13 +80,27 @@ func NewGoldmarkParser() *GoldmarkParser {
 //   - []byte: HTML 字节数组
 //   - error: 转换错误(如有)
 func (p *GoldmarkParser) Parse(markdown []byte) ([]byte, error) {
-	var buf bytes.Buffer
+	html, _, err := p.ParseWithOutline(markdown)
+	return html, err
+}
+
+// ParseWithOutline 将 Markdown 文本转换为 HTML,同时收集标题大纲
+//
+// 标题锚点 ID 保留中文等 Unicode 字母,同一文档内重复时追加数字后缀。
+//
+// 返回:
+//   - []byte: HTML 字节数组
+//   - []Heading: 按文档顺序排列的标题大纲
+//   - error: 转换错误(如有)
+func (p *GoldmarkParser) ParseWithOutline(markdown []byte) ([]byte, []Heading, error) {
+	doc := p.md.Parser().Parse(text.NewReader(markdown), goldmarkparser.WithContext(newParseContext()))
 
-	if err := p.md.Convert(markdown, &buf); err != nil {
-		return nil, fmt.Errorf("failed to parse markdown: %w", err)
+	var buf bytes.Buffer
+	if err := p.md.Renderer().Render(&buf, markdown, doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse markdown: %w", err)
 	}
 
-	return buf.Bytes(), nil
+	return buf.Bytes(), collectOutline(doc, markdown), nil
 }
 
 // ParseToString 将 Markdown 文本转换为 HTML 字符串
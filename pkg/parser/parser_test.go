@@ -1,6 +1,9 @@
 package parser
 
 import (
+	"html/template"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -96,6 +99,12 @@ func TestParse(t *testing.T) {
 			want:    `<input`,
 			wantErr: false,
 		},
+		{
+			name:    "标题锚点ID",
+			input:   "## Getting Started",
+			want:    `<h2 id="getting-started">`,
+			wantErr: false,
+		},
 		{
 			name:    "空输入",
 			input:   "",
@@ -146,7 +155,7 @@ func TestWrapHTML(t *testing.T) {
 			},
 			wantParts: []string{
 				"<!DOCTYPE html>",
-				`<html lang="zh-CN">`,
+				`<html lang="zh-CN" dir="ltr">`,
 				"<title>Test</title>",
 				"<p>Hello</p>",
 				"</html>",
@@ -180,6 +189,115 @@ func TestWrapHTML(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:    "自定义CSS独立样式标签",
+			content: "<p>Styled</p>",
+			template: &HTMLTemplate{
+				Title:     "Styled",
+				CustomCSS: "p { margin: 0; }",
+			},
+			wantParts: []string{
+				"<style>\n        p { margin: 0; }\n    </style>",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "文档语言",
+			content: "<p>Hello</p>",
+			template: &HTMLTemplate{
+				Title: "English",
+				Lang:  "en",
+			},
+			wantParts: []string{
+				`<html lang="en" dir="ltr">`,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "根据内容检测从右到左",
+			content: "<p>مرحبا بالعالم <code>main.go</code></p><pre><code>package main</code></pre>",
+			template: &HTMLTemplate{
+				Title: "عربي",
+				Lang:  "ar",
+			},
+			wantParts: []string{
+				`<html lang="ar" dir="rtl">`,
+				"padding-inline-start: 2em;",
+				"border-inline-start: 4px solid #dfe2e5;",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "显式指定文字方向",
+			content: "<p>שלום עולם</p>",
+			template: &HTMLTemplate{
+				Title: "Hebrew",
+				Lang:  "he",
+				Dir:   DirLTR,
+			},
+			wantParts: []string{
+				`<html lang="he" dir="ltr">`,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "自定义文档模板",
+			content: "<p>Body</p>",
+			template: &HTMLTemplate{
+				Title:       "A & B",
+				Theme:       "dark",
+				FrontMatter: FrontMatter{"author": "Alice"},
+				Document: template.Must(ParseDocumentTemplate("custom",
+					`<main data-theme="{{.Options.Theme}}"><h1>{{.Title}}</h1><i>{{.FrontMatter.author}}</i>{{.Content}}</main>`)),
+			},
+			wantParts: []string{
+				`<main data-theme="dark">`,
+				"<h1>A &amp; B</h1>",
+				"<i>Alice</i>",
+				"<p>Body</p>",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "页眉页脚占位符",
+			content: "<p>Body</p>",
+			template: &HTMLTemplate{
+				Title:       "R&D",
+				Header:      "{{title}}",
+				Footer:      "<b>{{author}}</b> · {{date}} · {{page}}/{{pages}}",
+				Logo:        "https://example.com/logo.png",
+				FrontMatter: FrontMatter{"author": "<Alice>", "date": "2024-01-01"},
+			},
+			wantParts: []string{
+				`<header class="page-header"><img class="page-logo" src="https://example.com/logo.png" alt="">`,
+				"R&amp;D",
+				`<footer class="page-footer"><b>&lt;Alice&gt;</b> · 2024-01-01 · 1/1</footer>`,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "非法Logo",
+			content: "<p>Body</p>",
+			template: &HTMLTemplate{
+				Title: "Logo",
+				Logo:  "file:///etc/passwd",
+			},
+			wantErr: true,
+		},
+		{
+			name:    "透明背景",
+			content: "<p>Overlay</p>",
+			template: &HTMLTemplate{
+				Title:       "Transparent",
+				Theme:       "dark",
+				Transparent: true,
+			},
+			wantParts: []string{
+				"background-color: transparent;",
+				"box-shadow: none;",
+			},
+			wantErr: false,
+		},
 		{
 			name:    "空内容",
 			content: "",
@@ -210,6 +328,35 @@ func TestWrapHTML(t *testing.T) {
 	}
 }
 
+func TestLoadDocumentTemplateDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"report.html": "<html>{{.Content}}</html>",
+		"plain.tmpl":  "{{.Title}}",
+		"notes.txt":   "ignored",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	templates, err := LoadDocumentTemplateDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDocumentTemplateDir() error = %v", err)
+	}
+	if len(templates) != 2 || templates["report"] == nil || templates["plain"] == nil {
+		t.Errorf("LoadDocumentTemplateDir() = %v, want report and plain", templates)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.html"), []byte("{{.Content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDocumentTemplateDir(dir); err == nil {
+		t.Error("LoadDocumentTemplateDir() should reject invalid template")
+	}
+}
+
 func TestDefaultTemplate(t *testing.T) {
 	tmpl := DefaultTemplate()
 
@@ -230,6 +377,562 @@ func TestDefaultTemplate(t *testing.T) {
 	}
 }
 
+func TestExtractFrontMatter(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantTitle string
+		wantBody  string
+		wantFM    bool
+	}{
+		{
+			name:      "标准front matter",
+			input:     "---\ntitle: Hello\nauthor: Alice\n---\n# Body\n",
+			wantTitle: "Hello",
+			wantBody:  "# Body\n",
+			wantFM:    true,
+		},
+		{
+			name:     "无front matter",
+			input:    "# Body\n",
+			wantBody: "# Body\n",
+			wantFM:   false,
+		},
+		{
+			name:     "未闭合",
+			input:    "---\ntitle: Hello\n# Body\n",
+			wantBody: "---\ntitle: Hello\n# Body\n",
+			wantFM:   false,
+		},
+		{
+			name:     "非映射内容",
+			input:    "---\nJust a paragraph\n---\nMore\n",
+			wantBody: "---\nJust a paragraph\n---\nMore\n",
+			wantFM:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm, body := ExtractFrontMatter([]byte(tt.input))
+			if (fm != nil) != tt.wantFM {
+				t.Fatalf("ExtractFrontMatter() front matter = %v, want present %v", fm, tt.wantFM)
+			}
+			if got := fm.String("title"); got != tt.wantTitle {
+				t.Errorf("FrontMatter.String(title) = %q, want %q", got, tt.wantTitle)
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("ExtractFrontMatter() body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestWrapCardHTML(t *testing.T) {
+	size, err := LookupCardPreset("og")
+	if err != nil {
+		t.Fatalf("LookupCardPreset(og) error = %v", err)
+	}
+	if size.Width != 1200 || size.Height != 630 {
+		t.Errorf("LookupCardPreset(og) = %v, want 1200x630", size)
+	}
+	if _, err := LookupCardPreset("unknown"); err == nil {
+		t.Error("LookupCardPreset(unknown) should return error")
+	}
+
+	got, err := WrapCardHTML(&CardTemplate{
+		Title:       "Release <v2>",
+		Description: "What's new",
+		Author:      "Alice",
+		Date:        "2024-01-01",
+		Logo:        "https://example.com/logo.png",
+		Theme:       "dark",
+		Size:        size,
+	})
+	if err != nil {
+		t.Fatalf("WrapCardHTML() error = %v", err)
+	}
+	for _, part := range []string{
+		"width: 1200px;",
+		"height: 630px;",
+		"Release &lt;v2&gt;",
+		`<img src="https://example.com/logo.png"`,
+		"Alice",
+		"data-fit",
+	} {
+		if !strings.Contains(got, part) {
+			t.Errorf("WrapCardHTML() output doesn't contain expected part: %s", part)
+		}
+	}
+
+	if _, err := WrapCardHTML(&CardTemplate{Title: "x", Logo: "/etc/passwd"}); err == nil {
+		t.Error("WrapCardHTML() should reject local logo path")
+	}
+}
+
+func TestWrapSnippetHTML(t *testing.T) {
+	got, err := WrapSnippetHTML("package main\n\nfunc main() {}\n", &SnippetTemplate{
+		Language:       "go",
+		Title:          "main.go",
+		Window:         true,
+		HighlightLines: [][2]int{{3, 3}},
+	})
+	if err != nil {
+		t.Fatalf("WrapSnippetHTML() error = %v", err)
+	}
+	for _, part := range []string{
+		`class="snippet"`,
+		`class="titlebar"`,
+		"main.go",
+		`class="chroma"`,
+		".chroma .hl",
+		DefaultSnippetBackground,
+		`<html lang="zh-CN" dir="ltr">`,
+	} {
+		if !strings.Contains(got, part) {
+			t.Errorf("WrapSnippetHTML() output doesn't contain expected part: %s", part)
+		}
+	}
+
+	// 从右到左的语言只影响标题,代码保持从左到右
+	got, err = WrapSnippetHTML("print(1)", &SnippetTemplate{Title: "مثال", Lang: "ar", Window: true})
+	if err != nil {
+		t.Fatalf("WrapSnippetHTML() error = %v", err)
+	}
+	for _, part := range []string{`<html lang="ar" dir="rtl">`, "direction: ltr;"} {
+		if !strings.Contains(got, part) {
+			t.Errorf("WrapSnippetHTML() RTL output doesn't contain expected part: %s", part)
+		}
+	}
+
+	if _, err := WrapSnippetHTML("x", &SnippetTemplate{Style: "no-such-style"}); err == nil {
+		t.Error("WrapSnippetHTML() should reject unknown style")
+	}
+}
+
+func TestExtractFencedCode(t *testing.T) {
+	lang, code, ok := ExtractFencedCode("```go title=main.go\nfmt.Println(1)\n```\n")
+	if !ok || lang != "go" || code != "fmt.Println(1)" {
+		t.Errorf("ExtractFencedCode() = (%q, %q, %v)", lang, code, ok)
+	}
+
+	if _, _, ok := ExtractFencedCode("```go\na\n```\n\n```go\nb\n```"); ok {
+		t.Error("ExtractFencedCode() should reject multiple blocks")
+	}
+	if _, _, ok := ExtractFencedCode("func main() {}"); ok {
+		t.Error("ExtractFencedCode() should reject plain source code")
+	}
+}
+
+func TestParseLineRanges(t *testing.T) {
+	got, err := ParseLineRanges("1, 3-5,8")
+	if err != nil {
+		t.Fatalf("ParseLineRanges() error = %v", err)
+	}
+	want := [][2]int{{1, 1}, {3, 5}, {8, 8}}
+	if len(got) != len(want) {
+		t.Fatalf("ParseLineRanges() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ParseLineRanges()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	for _, invalid := range []string{"0", "a", "5-3", "1-x"} {
+		if _, err := ParseLineRanges(invalid); err == nil {
+			t.Errorf("ParseLineRanges(%q) should return error", invalid)
+		}
+	}
+}
+
+func TestRenderWatermark(t *testing.T) {
+	tests := []struct {
+		name      string
+		watermark *Watermark
+		wantParts []string
+		wantErr   bool
+	}{
+		{
+			name:      "文字水印",
+			watermark: &Watermark{Text: "<内部>", Position: WatermarkBottomRight, Rotation: -30},
+			wantParts: []string{
+				`class="watermark"`,
+				"opacity: 0.15;",
+				"align-items: flex-end; justify-content: flex-end;",
+				"rotate(-30deg)",
+				"&lt;内部&gt;",
+			},
+		},
+		{
+			name:      "平铺图片水印",
+			watermark: &Watermark{Image: "https://example.com/mark.png", Opacity: 0.3, Tile: true},
+			wantParts: []string{
+				"opacity: 0.3;",
+				"background-repeat: repeat;",
+				"https://example.com/mark.png",
+			},
+		},
+		{
+			name:      "平铺文字水印",
+			watermark: &Watermark{Text: "DRAFT", Tile: true},
+			wantParts: []string{"data:image/svg+xml;base64,"},
+		},
+		{name: "缺少内容", watermark: &Watermark{}, wantErr: true},
+		{name: "未知位置", watermark: &Watermark{Text: "x", Position: "middle"}, wantErr: true},
+		{name: "不透明度越界", watermark: &Watermark{Text: "x", Opacity: 2}, wantErr: true},
+		{name: "颜色注入", watermark: &Watermark{Text: "x", Color: "red;background:url(x)"}, wantErr: true},
+		{name: "本地图片", watermark: &Watermark{Image: "/etc/passwd"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderWatermark(tt.watermark, "light")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderWatermark() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, part := range tt.wantParts {
+				if !strings.Contains(string(got), part) {
+					t.Errorf("renderWatermark() output doesn't contain expected part: %s", part)
+				}
+			}
+		})
+	}
+
+	doc, err := WrapHTML("<p>x</p>", &HTMLTemplate{Title: "x", Watermark: &Watermark{Text: "DRAFT"}})
+	if err != nil {
+		t.Fatalf("WrapHTML() error = %v", err)
+	}
+	if !strings.Contains(doc, "body { position: relative; }") || !strings.Contains(doc, "DRAFT") {
+		t.Error("WrapHTML() should render watermark overlay on body")
+	}
+}
+
+func TestParseWithOutline(t *testing.T) {
+	markdown := "# 快速开始\n\n## Install `go` **now**\n\n## Install go now\n\n### 安装 Go 1.25\n"
+	html, outline, err := NewGoldmarkParser().ParseWithOutline([]byte(markdown))
+	if err != nil {
+		t.Fatalf("ParseWithOutline() error = %v", err)
+	}
+
+	want := []Heading{
+		{Level: 1, Text: "快速开始", ID: "快速开始"},
+		{Level: 2, Text: "Install go now", ID: "install-go-now"},
+		{Level: 2, Text: "Install go now", ID: "install-go-now-1"},
+		{Level: 3, Text: "安装 Go 1.25", ID: "安装-go-125"},
+	}
+	if len(outline) != len(want) {
+		t.Fatalf("ParseWithOutline() outline = %v, want %v", outline, want)
+	}
+	for i := range want {
+		if outline[i] != want[i] {
+			t.Errorf("outline[%d] = %+v, want %+v", i, outline[i], want[i])
+		}
+	}
+	if !strings.Contains(string(html), `<h1 id="快速开始">`) {
+		t.Errorf("ParseWithOutline() html should use outline IDs, got %s", html)
+	}
+
+	// 每次解析使用独立的 ID 集合
+	_, again, _ := NewGoldmarkParser().ParseWithOutline([]byte(markdown))
+	if again[1].ID != "install-go-now" {
+		t.Errorf("heading IDs should be stable across parses, got %q", again[1].ID)
+	}
+}
+
+func TestRenderTOC(t *testing.T) {
+	outline := []Heading{
+		{Level: 1, Text: "A", ID: "a"},
+		{Level: 2, Text: "B & C", ID: "b-c"},
+		{Level: 4, Text: "Deep", ID: "deep"},
+		{Level: 1, Text: "D", ID: "d"},
+	}
+
+	got := RenderTOC(outline, 3)
+	want := `<nav class="toc"><ul><li><a href="#a">A</a><ul><li><a href="#b-c">B &amp; C</a></li></ul></li><li><a href="#d">D</a></li></ul></nav>`
+	if got != want {
+		t.Errorf("RenderTOC() =\n%s\nwant\n%s", got, want)
+	}
+	if RenderTOC(nil, 3) != "" {
+		t.Error("RenderTOC(nil) should be empty")
+	}
+
+	content := []byte("<h1>x</h1>\n<p>[TOC]</p>\n")
+	if got := string(InsertTOC(content, "<nav></nav>", false)); got != "<h1>x</h1>\n<nav></nav>\n" {
+		t.Errorf("InsertTOC() with marker = %q", got)
+	}
+	if got := string(InsertTOC([]byte("<p>x</p>"), "<nav></nav>", true)); got != "<nav></nav>\n<p>x</p>" {
+		t.Errorf("InsertTOC() at start = %q", got)
+	}
+	if got := string(InsertTOC([]byte("<p>x</p>"), "<nav></nav>", false)); got != "<p>x</p>" {
+		t.Errorf("InsertTOC() without marker = %q", got)
+	}
+}
+
+func TestSplitSlides(t *testing.T) {
+	markdown := "# One\n<!-- notes -->\n---\n```yaml\n---\nkey: v\n```\n---\n<!-- only notes -->\n---\n# Three\n"
+	slides := SplitSlides([]byte(markdown))
+	if len(slides) != 3 {
+		t.Fatalf("SplitSlides() returned %d slides, want 3: %q", len(slides), slides)
+	}
+	if strings.Contains(string(slides[0]), "notes") {
+		t.Errorf("SplitSlides() should strip speaker notes, got %q", slides[0])
+	}
+	if !strings.Contains(string(slides[1]), "---\nkey: v") {
+		t.Errorf("SplitSlides() should keep separators inside code fences, got %q", slides[1])
+	}
+	if !strings.Contains(string(slides[2]), "# Three") {
+		t.Errorf("SplitSlides() last slide = %q", slides[2])
+	}
+}
+
+func TestWrapSlidesHTML(t *testing.T) {
+	size, err := LookupSlideAspect("4:3")
+	if err != nil {
+		t.Fatalf("LookupSlideAspect(4:3) error = %v", err)
+	}
+	if _, err := LookupSlideAspect("21:9"); err == nil {
+		t.Error("LookupSlideAspect(21:9) should return error")
+	}
+
+	tmpl := DefaultTemplate()
+	tmpl.Footer = "{{page}} / {{pages}}"
+	got, err := WrapSlidesHTML([]string{"<h1>A</h1>", "<h1>B</h1>"}, tmpl, size)
+	if err != nil {
+		t.Fatalf("WrapSlidesHTML() error = %v", err)
+	}
+	if n := strings.Count(got, `<section class="slide">`); n != 2 {
+		t.Errorf("WrapSlidesHTML() contains %d slides, want 2", n)
+	}
+	for _, part := range []string{"size: 1024px 768px;", "break-after: page;", "1 / 2</footer>", "2 / 2</footer>"} {
+		if !strings.Contains(got, part) {
+			t.Errorf("WrapSlidesHTML() output doesn't contain expected part: %s", part)
+		}
+	}
+
+	single, err := WrapSlideHTML("<h1>C</h1>", 3, 5, tmpl, size)
+	if err != nil {
+		t.Fatalf("WrapSlideHTML() error = %v", err)
+	}
+	if !strings.Contains(single, "3 / 5</footer>") {
+		t.Error("WrapSlideHTML() should number the slide 3 / 5")
+	}
+}
+
+func TestAdmonitions(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "GitHub alert",
+			markdown: "> [!NOTE]\n> Useful **info**.",
+			contains: []string{`<div class="admonition admonition-note">`, ">Note</p>", "<strong>info</strong>"},
+			excludes: []string{"<blockquote>", "[!NOTE]"},
+		},
+		{
+			name:     "自定义标题",
+			markdown: "> [!warning] 小心\n> 内容",
+			contains: []string{"admonition-warning", ">小心</p>", "<p>内容</p>"},
+			excludes: []string{"[!warning]"},
+		},
+		{
+			name:     "容器语法",
+			markdown: ":::tip 技巧\nSome *tip*\n\n- a\n:::\n\nafter",
+			contains: []string{"admonition-tip", ">技巧</p>", "<em>tip</em>", "<li>a</li>", "</div>\n<p>after</p>"},
+		},
+		{
+			name:     "类型别名",
+			markdown: ":::danger\nx\n:::",
+			contains: []string{"admonition-caution", ">Caution</p>"},
+		},
+		{
+			name:     "普通引用块",
+			markdown: "> plain",
+			contains: []string{"<blockquote>"},
+			excludes: []string{"admonition"},
+		},
+		{
+			name:     "未知容器类型",
+			markdown: ":::unknown\nx\n:::",
+			excludes: []string{"admonition"},
+		},
+	}
+
+	p := NewGoldmarkParser()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.ParseToString(tt.markdown)
+			if err != nil {
+				t.Fatalf("ParseToString() error = %v", err)
+			}
+			for _, part := range tt.contains {
+				if !strings.Contains(got, part) {
+					t.Errorf("output doesn't contain %q:\n%s", part, got)
+				}
+			}
+			for _, part := range tt.excludes {
+				if strings.Contains(got, part) {
+					t.Errorf("output shouldn't contain %q:\n%s", part, got)
+				}
+			}
+		})
+	}
+
+	html, err := WrapHTML("", DefaultTemplate())
+	if err != nil {
+		t.Fatalf("WrapHTML() error = %v", err)
+	}
+	if !strings.Contains(html, ".admonition-note {") {
+		t.Error("WrapHTML() should include admonition CSS")
+	}
+}
+
+func TestResolveExtensions(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   []string
+		want    string
+		wantErr bool
+	}{
+		{"默认扩展", nil, "emoji,heading-ids", false},
+		{"追加扩展", []string{"footnote, CJK", "deflist"}, "cjk,deflist,emoji,footnote,heading-ids", false},
+		{"禁用默认扩展", []string{"-heading-ids,-emoji,cjk"}, "cjk", false},
+		{"未知扩展", []string{"mermaid"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveExtensions(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveExtensions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && strings.Join(got, ",") != tt.want {
+				t.Errorf("ResolveExtensions() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveDir(t *testing.T) {
+	tests := []struct {
+		name string
+		dir  string
+		lang string
+		text string
+		want string
+	}{
+		{"默认从左到右", "", "", "Hello 世界", DirLTR},
+		{"阿拉伯文内容", DirAuto, "", "<h1>مرحبا</h1><p>هذا نص عربي مع كلمة API</p>", DirRTL},
+		{"忽略代码块", "", "", "<p>שלום</p><pre><code>func main() {}</code></pre>", DirRTL},
+		{"从右到左的语言", "", "fa-IR", "Hello", DirRTL},
+		{"显式指定优先", DirLTR, "ar", "مرحبا", DirLTR},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveDir(tt.dir, tt.lang, tt.text); got != tt.want {
+				t.Errorf("ResolveDir() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if err := ValidateDir("vertical"); err == nil {
+		t.Error("ValidateDir() should reject unknown direction")
+	}
+	if err := ValidateLang("not a lang"); err == nil {
+		t.Error("ValidateLang() should reject invalid language tag")
+	}
+}
+
+func TestCachedGoldmarkParser(t *testing.T) {
+	p, err := CachedGoldmarkParser([]string{"footnote,deflist,attributes,emoji,wikilink"})
+	if err != nil {
+		t.Fatalf("CachedGoldmarkParser() error = %v", err)
+	}
+	again, _ := CachedGoldmarkParser([]string{"wikilink", "emoji", "attributes", "deflist", "footnote"})
+	if p != again {
+		t.Error("CachedGoldmarkParser() should reuse the parser for the same extension set")
+	}
+
+	markdown := "# 标题 {#custom}\n\n正文[^1] :smile: [[Home Page|首页]] [[文档#安装]]\n\n术语\n: 定义\n\n[^1]: 脚注\n"
+	got, err := p.ParseToString(markdown)
+	if err != nil {
+		t.Fatalf("ParseToString() error = %v", err)
+	}
+	for _, part := range []string{
+		`<h1 id="custom">`,
+		`class="footnote-ref"`,
+		`class="footnotes"`,
+		"😄",
+		`<a href="Home%20Page" class="wikilink">首页</a>`,
+		`href="%E6%96%87%E6%A1%A3#%E5%AE%89%E8%A3%85"`,
+		"<dt>术语</dt>",
+	} {
+		if !strings.Contains(got, part) {
+			t.Errorf("output doesn't contain %q:\n%s", part, got)
+		}
+	}
+
+	plain, err := CachedGoldmarkParser([]string{"-heading-ids,-emoji"})
+	if err != nil {
+		t.Fatalf("CachedGoldmarkParser() error = %v", err)
+	}
+	got, _ = plain.ParseToString("# T\n\n:smile: [[x]]")
+	if strings.Contains(got, "id=") || !strings.Contains(got, ":smile: [[x]]") {
+		t.Errorf("disabled extensions should not apply, got %s", got)
+	}
+}
+
+func TestEmojiFont(t *testing.T) {
+	html, err := WrapHTML("<p>plain</p>", DefaultTemplate())
+	if err != nil {
+		t.Fatalf("WrapHTML() error = %v", err)
+	}
+	if strings.Contains(html, "@font-face") {
+		t.Error("WrapHTML() should not embed the emoji font without emoji")
+	}
+
+	content, err := NewGoldmarkParser().ParseToString("Release :rocket:")
+	if err != nil {
+		t.Fatalf("ParseToString() error = %v", err)
+	}
+	if !strings.Contains(content, "🚀") {
+		t.Fatalf("emoji shortcode should expand by default, got %s", content)
+	}
+
+	html, err = WrapHTML(content, DefaultTemplate())
+	if err != nil {
+		t.Fatalf("WrapHTML() error = %v", err)
+	}
+	for _, part := range []string{"@font-face", `font-family: "Gomarkdown2image Emoji", Arial, sans-serif;`} {
+		if !strings.Contains(html, part) {
+			t.Errorf("WrapHTML() output doesn't contain expected part: %s", part)
+		}
+	}
+
+	// 按名称引用已注册字体
+	tmpl := DefaultTemplate()
+	tmpl.FontFamily = `"Gomarkdown2image Emoji", serif`
+	html, err = WrapHTML("<p>plain</p>", tmpl)
+	if err != nil {
+		t.Fatalf("WrapHTML() error = %v", err)
+	}
+	if !strings.Contains(html, "@font-face") {
+		t.Error("WrapHTML() should embed registered fonts referenced by fontFamily")
+	}
+
+	card, err := WrapCardHTML(&CardTemplate{Title: "Launch 🎉"})
+	if err != nil {
+		t.Fatalf("WrapCardHTML() error = %v", err)
+	}
+	if !strings.Contains(card, "@font-face") {
+		t.Error("WrapCardHTML() should embed the emoji font for emoji titles")
+	}
+}
+
 // 基准测试
 func BenchmarkParse(b *testing.B) {
 	parser := NewGoldmarkParser()
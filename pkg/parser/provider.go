@@ -3,11 +3,20 @@ package parser
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/Cshiyuan/Gomarkdown2image/pkg/ai"
 	"github.com/Cshiyuan/Gomarkdown2image/pkg/ai/factory"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracer AI 调用链路追踪 (使用全局 TracerProvider,未设置时不记录)
+var tracer = otel.Tracer("github.com/Cshiyuan/Gomarkdown2image/pkg/parser")
+
 // ParserProvider 解析器提供器接口
 //
 // 该接口定义了创建 Parser 的工厂方法,支持不同的解析策略:
@@ -50,10 +59,21 @@ type ProviderConfig struct {
 
 	// CustomPrompt 自定义提示词 (可选,覆盖模板)
 	CustomPrompt string
+
+	// Extensions 启用的 Markdown 扩展 (可选,规则同 ResolveExtensions)
+	Extensions []string
+
+	// Logger 日志记录器 (可选,默认 slog.Default())
+	Logger *slog.Logger
+
+	// Context AI 调用的 context (可选,AI 调用的 span 以其中的 span 为父级,取消时中止 AI 调用)
+	Context context.Context
 }
 
 // GoldmarkProvider 传统 Goldmark 解析器提供器
-type GoldmarkProvider struct{}
+type GoldmarkProvider struct {
+	extensions []string
+}
 
 // NewGoldmarkProvider 创建 Goldmark 提供器
 func NewGoldmarkProvider() *GoldmarkProvider {
@@ -62,7 +82,7 @@ func NewGoldmarkProvider() *GoldmarkProvider {
 
 // CreateParser 创建 GoldmarkParser 实例
 func (p *GoldmarkProvider) CreateParser() (Parser, error) {
-	return NewGoldmarkParser(), nil
+	return CachedGoldmarkParser(p.extensions)
 }
 
 // Name 返回提供器名称
@@ -76,7 +96,11 @@ type AIParserProvider struct {
 	promptTemplate   string
 	promptData       map[string]interface{}
 	customPrompt     string
+	extensions       []string
 	fallbackProvider ParserProvider
+	logger           *slog.Logger
+	ctx              context.Context
+	model            string
 }
 
 // NewAIParserProvider 创建 AI 增强解析器提供器
@@ -106,18 +130,28 @@ func NewAIParserProvider(aiConfig *ai.Config, promptTemplate string, promptData
 		promptData:       promptData,
 		customPrompt:     customPrompt,
 		fallbackProvider: fallbackProvider,
+		model:            aiConfig.Model,
 	}, nil
 }
 
 // CreateParser 创建 AI 增强的 Parser 实例
 func (p *AIParserProvider) CreateParser() (Parser, error) {
+	markdownParser, err := CachedGoldmarkParser(p.extensions)
+	if err != nil {
+		return nil, err
+	}
+
 	return &AIParser{
 		aiProvider:     p.aiProvider,
 		promptTemplate: p.promptTemplate,
 		promptData:     p.promptData,
 		customPrompt:   p.customPrompt,
-		fallbackParser: NewGoldmarkParser(),
+		fallbackParser: markdownParser,
+		markdownParser: markdownParser,
 		enableFallback: true,
+		logger:         p.logger,
+		ctx:            p.ctx,
+		model:          p.model,
 	}, nil
 }
 
@@ -141,7 +175,11 @@ type AIParser struct {
 	promptData     map[string]interface{}
 	customPrompt   string
 	fallbackParser Parser
+	markdownParser *GoldmarkParser
 	enableFallback bool
+	logger         *slog.Logger
+	ctx            context.Context
+	model          string
 }
 
 // Parse 使用 AI 增强 Markdown 内容,然后转换为 HTML
@@ -151,25 +189,55 @@ type AIParser struct {
 //  2. 使用 Goldmark 解析增强后的 Markdown
 //  3. 如果 AI 失败且启用降级,直接使用 Goldmark 解析原始内容
 func (p *AIParser) Parse(markdown []byte) ([]byte, error) {
+	html, _, err := p.ParseWithOutline(markdown)
+	return html, err
+}
+
+// ParseWithOutline 使用 AI 增强 Markdown 内容,然后转换为 HTML 并收集标题大纲
+//
+// 大纲来自增强后的内容 (降级时来自原始内容)。
+func (p *AIParser) ParseWithOutline(markdown []byte) ([]byte, []Heading, error) {
 	// 第 1 步: 使用 AI 增强内容
 	enhancedMarkdown, err := p.enhanceWithAI(string(markdown))
 	if err != nil {
 		// AI 失败,检查是否启用降级
 		if p.enableFallback && p.fallbackParser != nil {
 			// 降级到传统解析
-			return p.fallbackParser.Parse(markdown)
+			p.log().Info("falling back to traditional parser", "provider", p.aiProvider.Name())
+			if op, ok := p.fallbackParser.(OutlineParser); ok {
+				return op.ParseWithOutline(markdown)
+			}
+			html, err := p.fallbackParser.Parse(markdown)
+			return html, nil, err
 		}
-		return nil, fmt.Errorf("AI enhancement failed: %w", err)
+		return nil, nil, fmt.Errorf("AI enhancement failed: %w", err)
 	}
 
 	// 第 2 步: 使用 Goldmark 解析增强后的内容
-	parser := NewGoldmarkParser()
-	return parser.Parse([]byte(enhancedMarkdown))
+	parser := p.markdownParser
+	if parser == nil {
+		parser = NewGoldmarkParser()
+	}
+	return parser.ParseWithOutline([]byte(enhancedMarkdown))
+}
+
+// log 返回 Parser 的日志记录器
+func (p *AIParser) log() *slog.Logger {
+	if p.logger != nil {
+		return p.logger
+	}
+	return slog.Default()
 }
 
 // enhanceWithAI 使用 AI 增强 Markdown 内容
 func (p *AIParser) enhanceWithAI(markdown string) (string, error) {
-	ctx := context.Background()
+	logger := p.log()
+	// 超时由提供器控制,调用方可以通过 ctx 提前取消 (如关闭服务时)
+	ctx := p.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = ai.WithLogger(ctx, logger)
 
 	// 构建提示词
 	var prompt string
@@ -208,10 +276,32 @@ func (p *AIParser) enhanceWithAI(markdown string) (string, error) {
 		Context:     ctx,
 	}
 
+	ctx, span := tracer.Start(ctx, "ai.generate", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("gen_ai.system", p.aiProvider.Name()),
+		attribute.String("gen_ai.request.model", p.model),
+		attribute.Int("gen_ai.request.max_tokens", req.MaxTokens),
+	))
+	defer span.End()
+	req.Context = ctx
+
+	start := time.Now()
 	resp, err := p.aiProvider.Generate(ctx, req)
+	elapsed := time.Since(start)
+	ai.ObserveGenerate(p.aiProvider.Name(), resp, err, elapsed)
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.SetAttributes(attribute.String("error.type", string(ai.ErrorTypeOf(err))))
+		logger.Warn("ai generate failed", "provider", p.aiProvider.Name(),
+			"error_type", ai.ErrorTypeOf(err), "duration_ms", elapsed.Milliseconds(), "error", err)
 		return "", err
 	}
+	span.SetAttributes(
+		attribute.Int("gen_ai.usage.total_tokens", resp.TokensUsed),
+		attribute.StringSlice("gen_ai.response.finish_reasons", []string{resp.FinishReason}),
+	)
+	logger.Info("ai generate", "provider", p.aiProvider.Name(),
+		"tokens", resp.TokensUsed, "duration_ms", elapsed.Milliseconds())
 
 	return resp.Content, nil
 }
@@ -227,18 +317,25 @@ func NewProvider(cfg *ProviderConfig) (ParserProvider, error) {
 
 	switch cfg.Type {
 	case ProviderTypeTraditional:
-		return NewGoldmarkProvider(), nil
+		return &GoldmarkProvider{extensions: cfg.Extensions}, nil
 
 	case ProviderTypeAI:
 		if cfg.AIConfig == nil {
 			return nil, fmt.Errorf("AI config is required for AI provider")
 		}
-		return NewAIParserProvider(
+		provider, err := NewAIParserProvider(
 			cfg.AIConfig,
 			cfg.AIPromptTemplate,
 			cfg.AIPromptData,
 			cfg.CustomPrompt,
 		)
+		if err != nil {
+			return nil, err
+		}
+		provider.extensions = cfg.Extensions
+		provider.logger = cfg.Logger
+		provider.ctx = cfg.Context
+		return provider, nil
 
 	default:
 		return nil, fmt.Errorf("unsupported provider type: %s", cfg.Type)
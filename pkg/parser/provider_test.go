@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/ai"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fakeAIProvider 返回固定结果的 AI 提供器 (记录收到的 context)
+type fakeAIProvider struct {
+	resp *ai.GenerateResponse
+	err  error
+	ctx  context.Context
+}
+
+func (f *fakeAIProvider) Generate(ctx context.Context, req *ai.GenerateRequest) (*ai.GenerateResponse, error) {
+	f.ctx = ctx
+	return f.resp, f.err
+}
+
+func (f *fakeAIProvider) GenerateStream(ctx context.Context, req *ai.GenerateRequest) (<-chan ai.StreamChunk, error) {
+	return nil, f.err
+}
+
+func (f *fakeAIProvider) Name() string { return "fake" }
+
+func (f *fakeAIProvider) Close() error { return nil }
+
+// TestAIParserTracing 测试 AI 调用的 span 属性、父级和降级
+func TestAIParserTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	tests := []struct {
+		name       string
+		provider   *fakeAIProvider
+		wantHTML   string
+		wantStatus codes.Code
+		wantAttrs  []attribute.KeyValue
+	}{
+		{
+			name:       "AI 调用成功",
+			provider:   &fakeAIProvider{resp: &ai.GenerateResponse{Content: "# Enhanced", TokensUsed: 42, FinishReason: "stop"}},
+			wantHTML:   "Enhanced",
+			wantStatus: codes.Unset,
+			wantAttrs: []attribute.KeyValue{
+				attribute.String("gen_ai.system", "fake"),
+				attribute.String("gen_ai.request.model", "fake-model"),
+				attribute.Int("gen_ai.usage.total_tokens", 42),
+			},
+		},
+		{
+			name:       "AI 调用失败降级",
+			provider:   &fakeAIProvider{err: ai.NewError(ai.ErrorTypeRateLimit, "quota exceeded", nil)},
+			wantHTML:   "Original",
+			wantStatus: codes.Error,
+			wantAttrs:  []attribute.KeyValue{attribute.String("error.type", "rate_limit")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			ctx, parent := otel.Tracer("test").Start(context.Background(), "parse")
+			defer parent.End()
+
+			p := &AIParser{
+				aiProvider:     tt.provider,
+				fallbackParser: NewGoldmarkParser(),
+				markdownParser: NewGoldmarkParser(),
+				enableFallback: true,
+				ctx:            ctx,
+				model:          "fake-model",
+			}
+			html, err := p.Parse([]byte("# Original"))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !strings.Contains(string(html), tt.wantHTML) {
+				t.Errorf("Parse() = %s, want %q", html, tt.wantHTML)
+			}
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 || spans[0].Name != "ai.generate" {
+				t.Fatalf("spans = %v, want one ai.generate span", spans)
+			}
+			span := spans[0]
+			if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+				t.Error("ai.generate span is not a child of the parse span")
+			}
+			if got := trace.SpanFromContext(tt.provider.ctx).SpanContext().SpanID(); got != span.SpanContext.SpanID() {
+				t.Error("provider context does not carry the ai.generate span")
+			}
+			if span.Status.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", span.Status.Code, tt.wantStatus)
+			}
+			for _, want := range tt.wantAttrs {
+				found := false
+				for _, attr := range span.Attributes {
+					if attr == want {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("missing attribute %s=%s", want.Key, want.Value.Emit())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SlideAspects 幻灯片宽高比对应的像素尺寸
+var SlideAspects = map[string]PageSize{
+	"16:9": {Width: 1280, Height: 720},
+	"4:3":  {Width: 1024, Height: 768},
+}
+
+// DefaultSlideAspect 默认幻灯片宽高比
+const DefaultSlideAspect = "16:9"
+
+// htmlCommentPattern 匹配 HTML 注释 (演讲者备注)
+var htmlCommentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+// LookupSlideAspect 根据宽高比查找幻灯片尺寸
+func LookupSlideAspect(aspect string) (PageSize, error) {
+	if aspect == "" {
+		aspect = DefaultSlideAspect
+	}
+	size, ok := SlideAspects[aspect]
+	if !ok {
+		names := make([]string, 0, len(SlideAspects))
+		for n := range SlideAspects {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return PageSize{}, fmt.Errorf("unknown slide aspect: %s (available: %v)", aspect, names)
+	}
+	return size, nil
+}
+
+// SplitSlides 按 "---" 分隔行将 Markdown 拆分为多张幻灯片
+//
+// 规则:
+//   - 围栏代码块内的 "---" 不作为分隔符
+//   - HTML 注释视为演讲者备注,不输出到幻灯片
+//   - 去除备注后为空的幻灯片被忽略
+//
+// 调用前应先使用 ExtractFrontMatter 去除 front matter。
+func SplitSlides(markdown []byte) [][]byte {
+	var slides [][]byte
+	var current, text strings.Builder
+	fence := ""
+
+	// flushText 输出代码块外的文本 (去除备注)
+	flushText := func() {
+		current.WriteString(htmlCommentPattern.ReplaceAllString(text.String(), ""))
+		text.Reset()
+	}
+	flushSlide := func() {
+		flushText()
+		if strings.TrimSpace(current.String()) != "" {
+			slides = append(slides, []byte(current.String()))
+		}
+		current.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(string(markdown), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if fence != "" {
+			current.WriteString(line)
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "```"), strings.HasPrefix(trimmed, "~~~"):
+			flushText()
+			fence = trimmed[:3]
+			current.WriteString(line)
+		case trimmed == "---":
+			flushSlide()
+		default:
+			text.WriteString(line)
+		}
+	}
+	flushSlide()
+
+	return slides
+}
+
+// WrapSlidesHTML 将多张幻灯片的 HTML 内容包装为完整的 HTML 文档
+//
+// 每张幻灯片为固定尺寸的 section.slide,并设置 CSS 分页,
+// 用于打印为多页 PDF (逐张图片由 WrapSlideHTML 单独渲染)。
+//
+// 参数:
+//   - slides: 每张幻灯片的 HTML 内容 (由 Parser 生成)
+//   - tmpl: HTML 模板配置 (主题、字体等)
+//   - size: 幻灯片尺寸
+//
+// 返回:
+//   - string: 完整的 HTML 文档
+//   - error: 模板渲染错误(如有)
+func WrapSlidesHTML(slides []string, tmpl *HTMLTemplate, size PageSize) (string, error) {
+	return wrapSlides(slides, 1, len(slides), tmpl, size)
+}
+
+// WrapSlideHTML 将单张幻灯片包装为完整的 HTML 文档 (用于逐页截图)
+//
+// page 和 pages 用于页眉页脚中的 {{page}} 和 {{pages}} 占位符。
+func WrapSlideHTML(slide string, page, pages int, tmpl *HTMLTemplate, size PageSize) (string, error) {
+	return wrapSlides([]string{slide}, page, pages, tmpl, size)
+}
+
+// wrapSlides 包装从第 first 页开始的幻灯片 (共 pages 页)
+func wrapSlides(slides []string, first, pages int, tmpl *HTMLTemplate, size PageSize) (string, error) {
+	if tmpl == nil {
+		tmpl = DefaultTemplate()
+	}
+
+	watermark, err := renderWatermark(tmpl.Watermark, tmpl.Theme)
+	if err != nil {
+		return "", err
+	}
+
+	// 页眉页脚和水印按页渲染到每张幻灯片中,页码为幻灯片序号
+	var content strings.Builder
+	for i, slide := range slides {
+		header, footer, err := renderBranding(tmpl, first+i, pages)
+		if err != nil {
+			return "", err
+		}
+
+		content.WriteString(`<section class="slide">`)
+		if header != "" {
+			fmt.Fprintf(&content, `<header class="page-header">%s</header>`, header)
+		}
+		content.WriteString(slide)
+		if footer != "" {
+			fmt.Fprintf(&content, `<footer class="page-footer">%s</footer>`, footer)
+		}
+		content.WriteString(string(watermark))
+		content.WriteString("</section>\n")
+	}
+
+	deck := *tmpl
+	deck.Width = size.Width
+	deck.Header, deck.Footer, deck.Logo = "", "", ""
+	deck.Watermark = nil
+	deck.ExtraCSS = generateSlidesCSS(&deck, size) + deck.ExtraCSS
+
+	return WrapHTML(content.String(), &deck)
+}
+
+// generateSlidesCSS 生成幻灯片布局样式
+func generateSlidesCSS(tmpl *HTMLTemplate, size PageSize) string {
+	background := getContainerColor(tmpl.Theme)
+	if tmpl.Transparent {
+		background = "transparent"
+	}
+
+	return fmt.Sprintf(`
+        @page {
+            size: %dpx %dpx;
+            margin: 0;
+        }
+
+        body {
+            padding: 0;
+        }
+
+        .container {
+            max-width: none;
+            padding: 0;
+            border-radius: 0;
+            box-shadow: none;
+            background: transparent;
+        }
+
+        .slide {
+            position: relative;
+            display: flex;
+            flex-direction: column;
+            justify-content: center;
+            width: %dpx;
+            height: %dpx;
+            padding: 56px 80px;
+            overflow: hidden;
+            font-size: %dpx;
+            background: %s;
+            break-after: page;
+        }
+
+        .slide .page-header, .slide .page-footer {
+            position: absolute;
+            left: 80px;
+            right: 80px;
+            margin: 0;
+        }
+
+        .slide .page-header { top: 20px; }
+        .slide .page-footer { bottom: 20px; }
+
+        .slide:last-child {
+            break-after: auto;
+        }
+
+        .slide h1 { font-size: 2.2em; border-bottom: none; }
+        .slide h2 { font-size: 1.6em; border-bottom: none; }
+`,
+		size.Width, size.Height,
+		size.Width, size.Height,
+		size.Height/24,
+		background,
+	)
+}
@@ -0,0 +1,320 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/fonts"
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// DefaultSnippetBackground 代码片段默认背景渐变
+const DefaultSnippetBackground = "linear-gradient(135deg, #667eea 0%, #764ba2 100%)"
+
+// SnippetSelector 代码片段图片的截图区域选择器
+const SnippetSelector = ".snippet"
+
+// SnippetTemplate 代码片段 (Carbon 风格) 模板配置
+type SnippetTemplate struct {
+	Language       string     // 代码语言 (为空时自动识别)
+	Style          string     // Chroma 样式名称 (默认 monokai)
+	Title          string     // 窗口标题 (可选)
+	Window         bool       // 是否显示窗口标题栏 (含红黄绿按钮)
+	HighlightLines [][2]int   // 高亮行范围 (从 1 开始,闭区间)
+	LineNumbers    bool       // 是否显示行号
+	Background     string     // 背景 CSS (颜色或渐变,默认 DefaultSnippetBackground)
+	FontFamily     string     // 代码字体族
+	FontSize       int        // 代码字体大小
+	Lang           string     // 语言 (默认 DefaultLang)
+	Dir            string     // 标题文字方向: auto (默认), ltr, rtl (代码始终从左到右)
+	Transparent    bool       // 透明背景 (不绘制外层背景)
+	Watermark      *Watermark // 水印 (可选)
+}
+
+// snippetData 代码片段模板渲染数据
+type snippetData struct {
+	*SnippetTemplate
+	Lang        string
+	Dir         string
+	Code        template.HTML
+	ChromaCSS   template.CSS
+	Background  template.CSS
+	WindowColor template.CSS
+	FontFamily  template.CSS
+	FontFace    template.CSS
+	FontSize    int
+	Watermark   template.HTML
+}
+
+// snippetHTML 代码片段 HTML 模板
+var snippetHTML = template.Must(template.New("snippet").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}" dir="{{.Dir}}">
+<head>
+    <meta charset="UTF-8">
+    <title>{{.Title}}</title>
+    <style>
+        {{.FontFace}}
+        * { margin: 0; padding: 0; box-sizing: border-box; }
+
+        body { background: transparent; }
+
+        .snippet {
+            position: relative;
+            display: inline-block;
+            padding: 56px;
+            background: {{.Background}};
+        }
+
+        .window {
+            border-radius: 10px;
+            overflow: hidden;
+            background: {{.WindowColor}};
+            box-shadow: 0 20px 68px rgba(0, 0, 0, 0.55);
+        }
+
+        .titlebar {
+            position: relative;
+            height: 40px;
+            padding: 0 16px;
+            display: flex;
+            align-items: center;
+        }
+
+        .titlebar .dot {
+            width: 12px;
+            height: 12px;
+            margin-right: 8px;
+            border-radius: 50%;
+        }
+
+        .titlebar .red { background: #ff5f56; }
+        .titlebar .yellow { background: #ffbd2e; }
+        .titlebar .green { background: #27c93f; }
+
+        .titlebar .title {
+            position: absolute;
+            left: 0;
+            right: 0;
+            text-align: center;
+            font-family: -apple-system, "Segoe UI", sans-serif;
+            font-size: 13px;
+            opacity: 0.7;
+            pointer-events: none;
+        }
+
+        .window pre.chroma {
+            margin: 0;
+            padding: 16px 24px 24px;
+            font-family: {{.FontFamily}};
+            font-size: {{.FontSize}}px;
+            line-height: 1.5;
+            white-space: pre;
+            direction: ltr;
+            text-align: left;
+        }
+
+        {{.ChromaCSS}}
+    </style>
+</head>
+<body>
+    <div class="snippet">
+        <div class="window">
+            {{- if .Window}}
+            <div class="titlebar">
+                <span class="dot red"></span><span class="dot yellow"></span><span class="dot green"></span>
+                <span class="title">{{.Title}}</span>
+            </div>
+            {{- end}}
+            {{.Code}}
+        </div>
+        {{.Watermark}}
+    </div>
+</body>
+</html>`))
+
+// WrapSnippetHTML 将源代码渲染为带窗口框的代码片段 HTML 文档
+//
+// 语法高亮使用与 GoldmarkParser 相同的 Chroma 格式化器 (CSS 类模式),
+// 并按所选样式生成对应的 CSS。
+//
+// 参数:
+//   - code: 源代码
+//   - snippet: 代码片段模板配置
+//
+// 返回:
+//   - string: 完整的 HTML 文档 (截图区域为 SnippetSelector)
+//   - error: 高亮或模板渲染错误(如有)
+func WrapSnippetHTML(code string, snippet *SnippetTemplate) (string, error) {
+	if snippet == nil {
+		snippet = &SnippetTemplate{Window: true}
+	}
+
+	styleName := snippet.Style
+	if styleName == "" {
+		styleName = DefaultCodeStyle
+	}
+	style, ok := styles.Registry[styleName]
+	if !ok {
+		return "", fmt.Errorf("unknown code style: %s", styleName)
+	}
+
+	// 选择词法分析器: 指定语言 > 内容识别 > 纯文本
+	var lexer chroma.Lexer
+	if snippet.Language != "" {
+		lexer = lexers.Get(snippet.Language)
+	}
+	if lexer == nil {
+		lexer = lexers.Analyse(code)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	iterator, err := lexer.Tokenise(nil, strings.TrimRight(code, "\n"))
+	if err != nil {
+		return "", fmt.Errorf("failed to tokenise code: %w", err)
+	}
+
+	formatter := html.New(
+		html.WithClasses(true),
+		html.WithLineNumbers(snippet.LineNumbers),
+		html.HighlightLines(snippet.HighlightLines),
+		html.TabWidth(4),
+	)
+
+	var codeBuf, cssBuf bytes.Buffer
+	if err := formatter.Format(&codeBuf, style, iterator); err != nil {
+		return "", fmt.Errorf("failed to highlight code: %w", err)
+	}
+	if err := formatter.WriteCSS(&cssBuf, style); err != nil {
+		return "", fmt.Errorf("failed to write code style: %w", err)
+	}
+
+	background := snippet.Background
+	if background == "" {
+		background = DefaultSnippetBackground
+	}
+	if snippet.Transparent {
+		background = "transparent"
+	}
+
+	fontFamily := snippet.FontFamily
+	if fontFamily == "" {
+		fontFamily = `"JetBrains Mono", "Fira Code", Menlo, Consolas, monospace`
+	}
+	fontSize := snippet.FontSize
+	if fontSize <= 0 {
+		fontSize = 14
+	}
+
+	// 窗口底色取自样式背景,保证标题栏与代码区一致
+	windowColor := "#272822"
+	if bg := style.Get(chroma.Background).Background; bg.IsSet() {
+		windowColor = bg.String()
+	}
+
+	lang := snippet.Lang
+	if lang == "" {
+		lang = DefaultLang
+	}
+
+	// 注入已注册字体 (含 Emoji 和中日韩回退字体)
+	fontFamily = fonts.Default.Stack(fontFamily, code+snippet.Title)
+	fontFace := fonts.Default.FontFaces(fontFamily)
+
+	watermark, err := renderWatermark(snippet.Watermark, "dark")
+	if err != nil {
+		return "", err
+	}
+
+	data := &snippetData{
+		SnippetTemplate: snippet,
+		Lang:            lang,
+		Dir:             ResolveDir(snippet.Dir, lang, snippet.Title),
+		Code:            template.HTML(codeBuf.String()),
+		ChromaCSS:       template.CSS(cssBuf.String()),
+		Background:      template.CSS(background),
+		WindowColor:     template.CSS(windowColor),
+		FontFamily:      template.CSS(fontFamily),
+		FontFace:        template.CSS(fontFace),
+		FontSize:        fontSize,
+		Watermark:       watermark,
+	}
+
+	var buf bytes.Buffer
+	if err := snippetHTML.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render snippet template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// HasCodeStyle 判断 Chroma 样式是否存在
+func HasCodeStyle(name string) bool {
+	_, ok := styles.Registry[name]
+	return ok
+}
+
+// ExtractFencedCode 从仅包含单个围栏代码块的输入中提取语言和代码
+//
+// 输入不是单个围栏代码块时返回 ok=false,调用方应将输入视为源代码。
+func ExtractFencedCode(input string) (lang string, code string, ok bool) {
+	trimmed := strings.TrimSpace(input)
+	for _, fence := range []string{"```", "~~~"} {
+		if !strings.HasPrefix(trimmed, fence) || !strings.HasSuffix(trimmed, fence) {
+			continue
+		}
+
+		firstLine, rest, found := strings.Cut(trimmed, "\n")
+		if !found {
+			return "", "", false
+		}
+		body := strings.TrimSuffix(rest, fence)
+		if strings.Contains(body, "\n"+fence) {
+			// 包含多个代码块
+			return "", "", false
+		}
+
+		info := strings.TrimSpace(strings.TrimLeft(firstLine, fence[:1]))
+		if name, _, _ := strings.Cut(info, " "); name != "" {
+			lang = strings.Trim(name, "{}")
+		}
+		return lang, strings.TrimSuffix(body, "\n"), true
+	}
+	return "", "", false
+}
+
+// ParseLineRanges 解析行号范围表达式
+//
+// 格式: 逗号分隔的行号或区间,例如 "1,3-5,8"。
+func ParseLineRanges(expr string) ([][2]int, error) {
+	var ranges [][2]int
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		startStr, endStr, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(startStr))
+		if err != nil || start < 1 {
+			return nil, fmt.Errorf("invalid line number: %s", part)
+		}
+		end := start
+		if isRange {
+			end, err = strconv.Atoi(strings.TrimSpace(endStr))
+			if err != nil || end < start {
+				return nil, fmt.Errorf("invalid line range: %s", part)
+			}
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges, nil
+}
@@ -1,11 +1,22 @@
 package parser
 
 import (
+	"bytes"
 	"fmt"
 	"html/template"
+	"os"
+	"path/filepath"
 	"strings"
+
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/fonts"
 )
 
+// DefaultLang 默认文档语言
+const DefaultLang = "zh-CN"
+
+// codeFontFamily 行内代码和代码块字体族
+const codeFontFamily = "'Courier New', Courier, monospace"
+
 // HTMLTemplate HTML 模板配置
 type HTMLTemplate struct {
 	Title      string // 页面标题
@@ -14,8 +25,87 @@ type HTMLTemplate struct {
 	Width      int    // 页面宽度
 	FontSize   int    // 字体大小
 	FontFamily string // 字体族
+	Lang       string // 文档语言 (html lang 属性,默认 DefaultLang)
+	Dir        string // 文字方向: auto (默认,根据语言和内容检测), ltr, rtl
+
+	// Transparent 透明背景变体: 移除 body/container 背景和阴影
+	Transparent bool
+
+	// ExtraCSS 附加样式 (由布局生成的可信 CSS,写入基础样式之后)
+	ExtraCSS string
+
+	// FrontMatter 文档 front matter (供自定义文档模板使用)
+	FrontMatter FrontMatter
+
+	// TOC 目录 HTML (供自定义文档模板使用)
+	TOC string
+
+	// 页眉页脚 (纯文本或 HTML 片段,支持 {{title}}、{{date}}、{{page}} 等占位符)
+	Header string // 页眉
+	Footer string // 页脚
+	Logo   string // 页眉 Logo 图片 URL (http(s) 或 data:image/)
+
+	// Watermark 水印 (可选)
+	Watermark *Watermark
+
+	// Document 自定义文档模板 (为空时使用 DefaultDocumentTemplate)
+	Document *template.Template
+}
+
+// DocumentData 文档模板渲染数据
+//
+// 自定义模板可使用的字段:
+//
+//	{{.Title}} {{.Lang}} {{.Dir}} {{.Content}} {{.ThemeCSS}} {{.CustomCSS}}
+//	{{.FrontMatter.author}} {{.TOC}} {{.Header}} {{.Footer}} {{.Watermark}}
+//	{{.Options.Theme}} {{.Options.Width}}
+type DocumentData struct {
+	Title       string        // 页面标题
+	Lang        string        // 文档语言
+	Dir         string        // 文字方向 (ltr 或 rtl)
+	Content     template.HTML // 渲染后的 Markdown 内容
+	ThemeCSS    template.CSS  // 主题及布局样式
+	CustomCSS   template.CSS  // 用户自定义 CSS
+	FrontMatter FrontMatter   // 文档 front matter (可能为 nil)
+	TOC         template.HTML // 目录 HTML (可能为空)
+	Header      template.HTML // 页眉 HTML (占位符已替换,可能为空)
+	Footer      template.HTML // 页脚 HTML (占位符已替换,可能为空)
+	Watermark   template.HTML // 水印覆盖层 HTML (可能为空)
+	Options     *HTMLTemplate // 模板配置
 }
 
+// DefaultDocumentTemplate 默认文档模板
+var DefaultDocumentTemplate = template.Must(ParseDocumentTemplate("default", `<!DOCTYPE html>
+<html lang="{{.Lang}}" dir="{{.Dir}}">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{.Title}}</title>
+    <style>
+        {{.ThemeCSS}}
+    </style>
+    {{- if .CustomCSS}}
+    <style>
+        {{.CustomCSS}}
+    </style>
+    {{- end}}
+</head>
+<body>
+    <div class="container">
+        {{- if .Header}}
+        <header class="page-header">{{.Header}}</header>
+        {{- end}}
+        {{.Content}}
+        {{- if .Footer}}
+        <footer class="page-footer">{{.Footer}}</footer>
+        {{- end}}
+    </div>
+    {{- if .Watermark}}
+    {{.Watermark}}
+    {{- end}}
+</body>
+</html>`))
+
 // DefaultTemplate 返回默认模板配置
 func DefaultTemplate() *HTMLTemplate {
 	return &HTMLTemplate{
@@ -24,6 +114,7 @@ func DefaultTemplate() *HTMLTemplate {
 		Width:      1200,
 		FontSize:   16,
 		FontFamily: "Arial, sans-serif",
+		Lang:       DefaultLang,
 	}
 }
 
@@ -41,37 +132,125 @@ func WrapHTML(content string, tmpl *HTMLTemplate) (string, error) {
 		tmpl = DefaultTemplate()
 	}
 
-	// 构建完整的 HTML 文档
-	htmlDoc := fmt.Sprintf(`<!DOCTYPE html>
-<html lang="zh-CN">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>%s</title>
-    <style>
-        %s
-    </style>
-    %s
-</head>
-<body>
-    <div class="container">
-        %s
-    </div>
-</body>
-</html>`,
-		template.HTMLEscapeString(tmpl.Title),
-		generateBaseCSS(tmpl),
-		tmpl.CustomCSS,
-		content,
-	)
-
-	return htmlDoc, nil
+	doc := tmpl.Document
+	if doc == nil {
+		doc = DefaultDocumentTemplate
+	}
+
+	lang := tmpl.Lang
+	if lang == "" {
+		lang = DefaultLang
+	}
+
+	// 非分页文档只有一页
+	header, footer, err := renderBranding(tmpl, 1, 1)
+	if err != nil {
+		return "", err
+	}
+
+	// 水印覆盖整个页面
+	watermark, err := renderWatermark(tmpl.Watermark, tmpl.Theme)
+	if err != nil {
+		return "", err
+	}
+	themeCSS := generateBaseCSS(tmpl) + generateBrandingCSS(tmpl)
+	if watermark != "" {
+		themeCSS += "\n        body { position: relative; }\n"
+	}
+
+	// 注入已注册字体: font-family 中引用的字体、Emoji 和中日韩回退字体 (不依赖系统字体)
+	text := content + tmpl.TOC + tmpl.Title + string(header) + string(footer)
+	bodyFont := fonts.Default.Stack(tmpl.FontFamily, text)
+	codeFont := fonts.Default.Stack(codeFontFamily, text)
+	if faces := fonts.Default.FontFaces(bodyFont, codeFont); faces != "" {
+		themeCSS = faces + themeCSS + fmt.Sprintf(`
+        body { font-family: %s; }
+        code { font-family: %s; }
+`, bodyFont, codeFont)
+	}
+
+	data := &DocumentData{
+		Title:       tmpl.Title,
+		Lang:        lang,
+		Dir:         ResolveDir(tmpl.Dir, lang, tmpl.Title+content),
+		Content:     template.HTML(content),
+		ThemeCSS:    template.CSS(themeCSS + tmpl.ExtraCSS),
+		CustomCSS:   template.CSS(tmpl.CustomCSS),
+		FrontMatter: tmpl.FrontMatter,
+		TOC:         template.HTML(tmpl.TOC),
+		Header:      header,
+		Footer:      footer,
+		Watermark:   watermark,
+		Options:     tmpl,
+	}
+
+	var buf bytes.Buffer
+	if err := doc.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render document template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// ParseDocumentTemplate 解析 html/template 格式的文档模板
+//
+// 模板的渲染数据为 DocumentData。
+func ParseDocumentTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse document template %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// LoadDocumentTemplate 从文件加载文档模板
+func LoadDocumentTemplate(path string) (*template.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document template: %w", err)
+	}
+	return ParseDocumentTemplate(filepath.Base(path), string(data))
+}
+
+// LoadDocumentTemplateDir 加载目录下的所有文档模板 (*.html, *.tmpl)
+//
+// 返回以文件名 (不含扩展名) 为键的模板集合,例如 report.html -> "report"。
+func LoadDocumentTemplateDir(dir string) (map[string]*template.Template, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template directory: %w", err)
+	}
+
+	templates := make(map[string]*template.Template)
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".html" && ext != ".tmpl") {
+			continue
+		}
+
+		tmpl, err := LoadDocumentTemplate(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		templates[strings.TrimSuffix(entry.Name(), ext)] = tmpl
+	}
+	return templates, nil
 }
 
 // generateBaseCSS 生成基础 CSS 样式
 func generateBaseCSS(tmpl *HTMLTemplate) string {
 	var css strings.Builder
 
+	// 透明背景变体不绘制页面和容器背景
+	background := getBackgroundColor(tmpl.Theme)
+	containerBackground := getContainerColor(tmpl.Theme)
+	containerShadow := "0 2px 10px rgba(0,0,0,0.1)"
+	if tmpl.Transparent {
+		background = "transparent"
+		containerBackground = "transparent"
+		containerShadow = "none"
+	}
+
 	// 基础样式
 	css.WriteString(fmt.Sprintf(`
         * {
@@ -95,15 +274,16 @@ func generateBaseCSS(tmpl *HTMLTemplate) string {
             padding: 40px;
             background: %s;
             border-radius: 8px;
-            box-shadow: 0 2px 10px rgba(0,0,0,0.1);
+            box-shadow: %s;
         }
 `,
 		tmpl.FontFamily,
 		tmpl.FontSize,
 		getTextColor(tmpl.Theme),
-		getBackgroundColor(tmpl.Theme),
+		background,
 		tmpl.Width,
-		getContainerColor(tmpl.Theme),
+		containerBackground,
+		containerShadow,
 	))
 
 	// Markdown 元素样式
@@ -137,7 +317,7 @@ func generateBaseCSS(tmpl *HTMLTemplate) string {
 
         ul, ol {
             margin-bottom: 16px;
-            padding-left: 2em;
+            padding-inline-start: 2em;
         }
 
         li {
@@ -147,19 +327,23 @@ func generateBaseCSS(tmpl *HTMLTemplate) string {
         blockquote {
             padding: 0 1em;
             color: #6a737d;
-            border-left: 4px solid #dfe2e5;
+            border-inline-start: 4px solid #dfe2e5;
             margin-bottom: 16px;
         }
 
         code {
+            unicode-bidi: isolate;
+            direction: ltr;
             padding: 2px 6px;
-            font-family: 'Courier New', Courier, monospace;
+            font-family: ` + codeFontFamily + `;
             font-size: 0.9em;
             background-color: rgba(27,31,35,0.05);
             border-radius: 3px;
         }
 
         pre {
+            direction: ltr;
+            text-align: left;
             padding: 16px;
             overflow: auto;
             font-size: 0.9em;
@@ -185,7 +369,7 @@ func generateBaseCSS(tmpl *HTMLTemplate) string {
         th, td {
             padding: 12px;
             border: 1px solid #dfe2e5;
-            text-align: left;
+            text-align: start;
         }
 
         th {
@@ -193,6 +377,44 @@ func generateBaseCSS(tmpl *HTMLTemplate) string {
             font-weight: 600;
         }
 
+        .toc {
+            margin-bottom: 24px;
+            padding: 12px 20px;
+            border-inline-start: 4px solid #dfe2e5;
+        }
+
+        .toc ul {
+            list-style: none;
+            margin-bottom: 0;
+            padding-inline-start: 1.2em;
+        }
+
+        .toc > ul {
+            padding-inline-start: 0;
+        }
+
+        .toc li {
+            margin-bottom: 4px;
+        }
+
+        dt {
+            font-weight: 600;
+        }
+
+        dd {
+            margin: 0 0 16px;
+            padding-inline-start: 2em;
+        }
+
+        .footnotes {
+            font-size: 0.85em;
+            color: #6a737d;
+        }
+
+        .footnotes ol {
+            padding-inline-start: 1.5em;
+        }
+
         hr {
             height: 1px;
             margin: 24px 0;
@@ -221,11 +443,14 @@ func generateBaseCSS(tmpl *HTMLTemplate) string {
         }
 
         .chroma .ln {
-            margin-right: 12px;
+            margin-inline-end: 12px;
             color: #75715e;
         }
     `)
 
+	// 提示块样式
+	css.WriteString(generateAdmonitionCSS(tmpl.Theme))
+
 	return css.String()
 }
 
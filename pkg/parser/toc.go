@@ -0,0 +1,201 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+	goldmarkparser "github.com/yuin/goldmark/parser"
+)
+
+// TOCMarker 目录占位标记 (单独成段)
+const TOCMarker = "[TOC]"
+
+// DefaultTOCDepth 默认目录深度 (包含 h1-h3)
+const DefaultTOCDepth = 3
+
+// tocMarkerHTML TOCMarker 经 Goldmark 渲染后的 HTML
+const tocMarkerHTML = "<p>" + TOCMarker + "</p>"
+
+// Heading 文档大纲中的标题
+type Heading struct {
+	Level int    `json:"level"` // 标题级别 (1-6)
+	Text  string `json:"text"`  // 标题纯文本
+	ID    string `json:"id"`    // 锚点 ID
+}
+
+// OutlineParser 可以同时输出文档大纲的解析器
+type OutlineParser interface {
+	Parser
+
+	// ParseWithOutline 将 Markdown 转换为 HTML,并返回按文档顺序排列的标题大纲
+	ParseWithOutline(markdown []byte) ([]byte, []Heading, error)
+}
+
+// headingIDs 标题锚点 ID 生成器
+//
+// 与 Goldmark 默认实现不同,保留中文等 Unicode 字母,
+// 同一文档内重复的 ID 依次追加 -1、-2 后缀,相同输入始终生成相同的 ID。
+type headingIDs struct {
+	values map[string]bool
+}
+
+func newHeadingIDs() *headingIDs {
+	return &headingIDs{values: make(map[string]bool)}
+}
+
+// Generate 根据标题文本生成唯一 ID
+func (s *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(string(value))) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			b.WriteRune(r)
+			dash = false
+		case unicode.IsSpace(r) || r == '-':
+			if !dash && b.Len() > 0 {
+				b.WriteByte('-')
+				dash = true
+			}
+		}
+	}
+
+	base := strings.TrimSuffix(b.String(), "-")
+	if base == "" {
+		base = "heading"
+	}
+
+	id := base
+	for i := 1; s.values[id]; i++ {
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+	s.values[id] = true
+	return []byte(id)
+}
+
+// Put 记录已使用的 ID (显式设置的 {#id} 属性)
+func (s *headingIDs) Put(value []byte) {
+	s.values[string(value)] = true
+}
+
+// newParseContext 创建单次解析使用的上下文 (独立的标题 ID 集合)
+func newParseContext() goldmarkparser.Context {
+	return goldmarkparser.NewContext(goldmarkparser.WithIDs(newHeadingIDs()))
+}
+
+// collectOutline 遍历 AST 收集标题大纲
+func collectOutline(doc ast.Node, source []byte) []Heading {
+	var outline []Heading
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+
+		var id string
+		if v, ok := heading.AttributeString("id"); ok {
+			if b, ok := v.([]byte); ok {
+				id = string(b)
+			}
+		}
+		outline = append(outline, Heading{
+			Level: heading.Level,
+			Text:  strings.TrimSpace(nodeText(heading, source)),
+			ID:    id,
+		})
+		return ast.WalkSkipChildren, nil
+	})
+	return outline
+}
+
+// nodeText 提取节点的纯文本内容
+func nodeText(n ast.Node, source []byte) string {
+	var b strings.Builder
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch v := c.(type) {
+		case *ast.Text:
+			b.Write(v.Segment.Value(source))
+			if v.SoftLineBreak() || v.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(v.Value)
+		default:
+			b.WriteString(nodeText(c, source))
+		}
+	}
+	return b.String()
+}
+
+// RenderTOC 将标题大纲渲染为嵌套列表形式的目录 HTML
+//
+// 参数:
+//   - outline: 标题大纲
+//   - depth: 最大标题级别 (<= 0 时使用 DefaultTOCDepth)
+//
+// 返回:
+//   - string: 目录 HTML (无符合条件的标题时为空字符串)
+func RenderTOC(outline []Heading, depth int) string {
+	if depth <= 0 {
+		depth = DefaultTOCDepth
+	}
+
+	var items []Heading
+	minLevel := 7
+	for _, h := range outline {
+		if h.Level <= depth && h.ID != "" {
+			items = append(items, h)
+			minLevel = min(minLevel, h.Level)
+		}
+	}
+	if len(items) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(`<nav class="toc">`)
+	current := 0
+	for _, h := range items {
+		// 级别跳跃 (如 h1 后直接 h3) 时只缩进一层
+		level := min(h.Level-minLevel+1, current+1)
+		if level > current {
+			b.WriteString("<ul>")
+			current++
+		} else {
+			b.WriteString("</li>")
+			for ; current > level; current-- {
+				b.WriteString("</ul></li>")
+			}
+		}
+		fmt.Fprintf(&b, `<li><a href="#%s">%s</a>`,
+			template.HTMLEscapeString(h.ID), template.HTMLEscapeString(h.Text))
+	}
+	for ; current > 0; current-- {
+		b.WriteString("</li></ul>")
+	}
+	b.WriteString("</nav>")
+
+	return b.String()
+}
+
+// HasTOCMarker 判断 HTML 中是否包含目录占位标记
+func HasTOCMarker(html []byte) bool {
+	return bytes.Contains(html, []byte(tocMarkerHTML))
+}
+
+// InsertTOC 将目录插入 HTML 内容
+//
+// 内容中存在 [TOC] 标记段落时替换所有标记;否则 atStart 为 true 时插入到开头。
+func InsertTOC(html []byte, toc string, atStart bool) []byte {
+	if HasTOCMarker(html) {
+		return bytes.ReplaceAll(html, []byte(tocMarkerHTML), []byte(toc))
+	}
+	if atStart && toc != "" {
+		return append([]byte(toc+"\n"), html...)
+	}
+	return html
+}
@@ -0,0 +1,157 @@
+package parser
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+// 水印位置
+const (
+	WatermarkCenter      = "center"
+	WatermarkTopLeft     = "top-left"
+	WatermarkTopRight    = "top-right"
+	WatermarkBottomLeft  = "bottom-left"
+	WatermarkBottomRight = "bottom-right"
+)
+
+// DefaultWatermarkOpacity 默认水印不透明度
+const DefaultWatermarkOpacity = 0.15
+
+// DefaultWatermarkRotation 默认水印旋转角度 (度)
+const DefaultWatermarkRotation = -30
+
+// watermarkAlignments 水印位置对应的 flex 对齐方式 (align-items, justify-content)
+var watermarkAlignments = map[string][2]string{
+	WatermarkCenter:      {"center", "center"},
+	WatermarkTopLeft:     {"flex-start", "flex-start"},
+	WatermarkTopRight:    {"flex-start", "flex-end"},
+	WatermarkBottomLeft:  {"flex-end", "flex-start"},
+	WatermarkBottomRight: {"flex-end", "flex-end"},
+}
+
+// watermarkColorPattern 允许的水印颜色 (十六进制、颜色名、rgb/rgba)
+var watermarkColorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{3,8}|[a-zA-Z]+|rgba?\([0-9.,\s%]+\))$`)
+
+// Watermark 水印配置
+//
+// 水印以 CSS 覆盖层的形式绘制在内容之上,适用于所有输出格式。
+type Watermark struct {
+	Text     string  // 文字水印
+	Image    string  // 图片水印 URL (http(s) 或 data:image/,优先于文字)
+	Position string  // 位置: center (默认), top-left, top-right, bottom-left, bottom-right
+	Opacity  float64 // 不透明度 0-1 (默认 DefaultWatermarkOpacity)
+	Rotation int     // 旋转角度 (度)
+	Tile     bool    // 平铺 (忽略 Position)
+	FontSize int     // 文字大小 (默认 48px)
+	Color    string  // 文字颜色 (默认随主题)
+}
+
+// Validate 验证水印配置
+func (wm *Watermark) Validate() error {
+	if wm.Text == "" && wm.Image == "" {
+		return fmt.Errorf("watermark requires text or image")
+	}
+	if wm.Image != "" {
+		if err := ValidateImageURL(wm.Image); err != nil {
+			return err
+		}
+	}
+	if wm.Position != "" {
+		if _, ok := watermarkAlignments[wm.Position]; !ok {
+			return fmt.Errorf("unknown watermark position: %s", wm.Position)
+		}
+	}
+	if wm.Opacity < 0 || wm.Opacity > 1 {
+		return fmt.Errorf("watermark opacity must be between 0 and 1")
+	}
+	if wm.Rotation < -360 || wm.Rotation > 360 {
+		return fmt.Errorf("watermark rotation must be between -360 and 360")
+	}
+	if wm.Color != "" && !watermarkColorPattern.MatchString(wm.Color) {
+		return fmt.Errorf("invalid watermark color: %s", wm.Color)
+	}
+	return nil
+}
+
+// renderWatermark 生成水印覆盖层 HTML
+//
+// 覆盖层绝对定位并铺满最近的定位祖先元素,调用方需将其放入
+// position 非 static 的容器 (body、.slide、.card、.snippet)。
+func renderWatermark(wm *Watermark, theme string) (template.HTML, error) {
+	if wm == nil {
+		return "", nil
+	}
+	if err := wm.Validate(); err != nil {
+		return "", err
+	}
+
+	opacity := wm.Opacity
+	if opacity == 0 {
+		opacity = DefaultWatermarkOpacity
+	}
+	fontSize := wm.FontSize
+	if fontSize <= 0 {
+		fontSize = 48
+	}
+	color := wm.Color
+	if color == "" {
+		color = getTextColor(theme)
+	}
+
+	overlay := fmt.Sprintf(
+		"position: absolute; inset: 0; overflow: hidden; pointer-events: none; z-index: 1000; opacity: %g;",
+		opacity,
+	)
+
+	// 平铺: 旋转一个大于容器的重复背景层
+	if wm.Tile {
+		tile := wm.Image
+		size := "200px auto"
+		if tile == "" {
+			tile = textTileURI(wm.Text, fontSize, color)
+			size = "auto"
+		}
+		return template.HTML(fmt.Sprintf(
+			`<div class="watermark" aria-hidden="true" style="%s"><div style="position: absolute; inset: -50%%; background-image: url(&quot;%s&quot;); background-repeat: repeat; background-size: %s; transform: rotate(%ddeg);"></div></div>`,
+			overlay, template.HTMLEscapeString(tile), size, wm.Rotation,
+		)), nil
+	}
+
+	position := wm.Position
+	if position == "" {
+		position = WatermarkCenter
+	}
+	align := watermarkAlignments[position]
+
+	var mark string
+	if wm.Image != "" {
+		mark = fmt.Sprintf(`<img src="%s" alt="" style="max-width: 40%%; margin: 0; transform: rotate(%ddeg);">`,
+			template.HTMLEscapeString(wm.Image), wm.Rotation)
+	} else {
+		mark = fmt.Sprintf(`<span style="font-size: %dpx; font-weight: 700; color: %s; white-space: nowrap; transform: rotate(%ddeg);">%s</span>`,
+			fontSize, color, wm.Rotation, template.HTMLEscapeString(wm.Text))
+	}
+
+	return template.HTML(fmt.Sprintf(
+		`<div class="watermark" aria-hidden="true" style="%s display: flex; align-items: %s; justify-content: %s; padding: 24px;">%s</div>`,
+		overlay, align[0], align[1], mark,
+	)), nil
+}
+
+// textTileURI 将文字水印绘制为 SVG 平铺图块,返回 data URI
+func textTileURI(text string, fontSize int, color string) string {
+	width := fontSize*len([]rune(text))*6/10 + fontSize*3
+	height := fontSize * 4
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`, width, height)
+	fmt.Fprintf(&svg, `<text x="50%%" y="50%%" text-anchor="middle" dominant-baseline="middle" font-family="sans-serif" font-weight="700" font-size="%d" fill="%s">`,
+		fontSize, template.HTMLEscapeString(color))
+	svg.WriteString(template.HTMLEscapeString(text))
+	svg.WriteString(`</text></svg>`)
+
+	return "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte(svg.String()))
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	goldmarkparser "github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// wikilinkPattern Wiki 链接: [[页面]]、[[页面#章节]] 或 [[页面|显示文本]]
+var wikilinkPattern = regexp.MustCompile(`^\[\[([^\[\]|\n]+?)(?:\|([^\[\]\n]+?))?\]\]`)
+
+// Wikilinks Wiki 链接扩展
+//
+// [[页面|文本]] 渲染为 <a class="wikilink" href="页面">文本</a>,
+// 省略显示文本时使用页面名称。
+var Wikilinks goldmark.Extender = &wikilinkExtension{}
+
+type wikilinkExtension struct{}
+
+// Extend 实现 goldmark.Extender 接口
+func (e *wikilinkExtension) Extend(m goldmark.Markdown) {
+	// 优先于标准链接解析器 (200)
+	m.Parser().AddOptions(
+		goldmarkparser.WithInlineParsers(util.Prioritized(&wikilinkParser{}, 199)),
+	)
+}
+
+// wikilinkParser 解析 [[...]] 链接
+type wikilinkParser struct{}
+
+func (p *wikilinkParser) Trigger() []byte {
+	return []byte{'['}
+}
+
+func (p *wikilinkParser) Parse(parent ast.Node, block text.Reader, pc goldmarkparser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	m := wikilinkPattern.FindSubmatchIndex(line)
+	if m == nil {
+		return nil
+	}
+
+	target := strings.TrimSpace(string(line[m[2]:m[3]]))
+	label := text.NewSegment(segment.Start+m[2], segment.Start+m[3])
+	if m[4] >= 0 {
+		label = text.NewSegment(segment.Start+m[4], segment.Start+m[5])
+	}
+
+	link := ast.NewLink()
+	link.Destination = []byte(wikilinkDestination(target))
+	link.SetAttributeString("class", []byte("wikilink"))
+	label = label.TrimLeftSpace(block.Source())
+	link.AppendChild(link, ast.NewTextSegment(label.TrimRightSpace(block.Source())))
+
+	block.Advance(m[1])
+	return link
+}
+
+// wikilinkDestination 将页面名称转换为链接地址 (保留 #章节)
+func wikilinkDestination(target string) string {
+	page, fragment, found := strings.Cut(target, "#")
+	dest := url.PathEscape(strings.TrimSpace(page))
+	if found {
+		dest += "#" + url.PathEscape(strings.TrimSpace(fragment))
+	}
+	return dest
+}
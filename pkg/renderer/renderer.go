@@ -5,19 +5,32 @@ package renderer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/Cshiyuan/Gomarkdown2image/pkg/fonts"
 	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
 	"github.com/go-rod/rod/lib/proto"
 )
 
+// ErrElementNotFound 页面中没有匹配选择器或章节锚点的元素
+var ErrElementNotFound = errors.New("element not found")
+
 // Renderer HTML 渲染器接口
 type Renderer interface {
 	// RenderToImage 将 HTML 渲染为图片
 	RenderToImage(html string, opts *RenderOptions) ([]byte, error)
 
+	// RenderToPDF 将 HTML 打印为 PDF (每个 CSS 分页为一页)
+	RenderToPDF(html string, opts *RenderOptions) ([]byte, error)
+
 	// RenderToFile 将 HTML 渲染为图片并保存到文件
 	RenderToFile(html string, outputPath string, opts *RenderOptions) error
 
@@ -33,6 +46,21 @@ type RenderOptions struct {
 	Quality          int         // 图片质量 1-100 (仅 JPEG 有效,默认 90)
 	FullPage         bool        // 是否全页截图(默认 true)
 	DevicePixelRatio float64     // 设备像素比(默认 1.0)
+	Transparent      bool        // 透明背景 (仅 PNG/WebP 支持 alpha 通道)
+
+	// 元素截图选项 (设置后忽略 FullPage)
+	Selector string // 按 CSS 选择器截取单个元素 (如 ".container", "table")
+	Section  string // 按标题锚点 ID 截取章节 (从该标题到下一个同级或更高级标题之前)
+
+	Logger *slog.Logger // 日志记录器 (可选,默认 slog.Default())
+}
+
+// logger 返回渲染使用的日志记录器
+func (o *RenderOptions) logger() *slog.Logger {
+	if o.Logger != nil {
+		return o.Logger
+	}
+	return slog.Default()
 }
 
 // ImageFormat 图片格式
@@ -42,6 +70,9 @@ const (
 	FormatPNG  ImageFormat = "png"
 	FormatJPEG ImageFormat = "jpeg"
 	FormatWebP ImageFormat = "webp"
+
+	// FormatPDF 多页 PDF (由 RenderToPDF 生成,不支持截图)
+	FormatPDF ImageFormat = "pdf"
 )
 
 // DefaultRenderOptions 返回默认渲染选项
@@ -56,6 +87,25 @@ func DefaultRenderOptions() *RenderOptions {
 	}
 }
 
+// browserPath 浏览器可执行文件路径 (为空时由 Rod 自动查找或下载)
+var browserPath string
+
+// SetBrowserPath 设置启动浏览器使用的可执行文件
+//
+// 应在创建渲染器之前调用。
+func SetBrowserPath(path string) {
+	browserPath = path
+}
+
+// newBrowser 创建浏览器 (配置了 browserPath 时先启动该浏览器,失败时 panic)
+func newBrowser(ctx context.Context) *rod.Browser {
+	browser := rod.New().Context(ctx)
+	if browserPath != "" {
+		browser = browser.ControlURL(launcher.New().Context(ctx).Bin(browserPath).MustLaunch())
+	}
+	return browser
+}
+
 // RodRenderer 基于 Rod 的渲染器实现
 type RodRenderer struct {
 	browser *rod.Browser
@@ -78,7 +128,7 @@ func NewRodRenderer() (*RodRenderer, error) {
 				panicErr = fmt.Errorf("failed to connect to browser: %v", r)
 			}
 		}()
-		browser = rod.New().Timeout(10 * time.Second).MustConnect()
+		browser = newBrowser(context.Background()).Timeout(10 * time.Second).MustConnect()
 	}()
 
 	if panicErr != nil {
@@ -90,6 +140,29 @@ func NewRodRenderer() (*RodRenderer, error) {
 	}, nil
 }
 
+// CheckBrowser 检查能否启动浏览器 (用于就绪检查),返回浏览器版本
+//
+// 启动一个浏览器实例,读取版本后立即关闭。
+func CheckBrowser(ctx context.Context) (version string, err error) {
+	// 启动失败时 rod 会 panic (见 NewRodRenderer)
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to connect to browser: %v", r)
+		}
+	}()
+	browser := newBrowser(ctx)
+	if err := browser.Connect(); err != nil {
+		return "", fmt.Errorf("failed to connect to browser: %w", err)
+	}
+	defer browser.Close()
+
+	info, err := browser.Version()
+	if err != nil {
+		return "", fmt.Errorf("failed to get browser version: %w", err)
+	}
+	return info.Product, nil
+}
+
 // RenderToImage 将 HTML 渲染为图片字节数组
 //
 // 参数:
@@ -104,16 +177,129 @@ func (r *RodRenderer) RenderToImage(html string, opts *RenderOptions) ([]byte, e
 		opts = DefaultRenderOptions()
 	}
 
+	if opts.Transparent && opts.Format == FormatJPEG {
+		return nil, fmt.Errorf("transparent background is not supported for %s", opts.Format)
+	}
+
 	// 创建带超时的上下文 (30 秒总超时)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// 创建页面并加载 HTML
+	page, err := r.openPage(ctx, html, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer page.Close()
+
+	// 构建截图参数
+	screenshotOpts := &proto.PageCaptureScreenshot{
+		FromSurface: true,
+	}
+
+	// 设置图片格式
+	switch opts.Format {
+	case FormatPNG:
+		screenshotOpts.Format = proto.PageCaptureScreenshotFormatPng
+	case FormatJPEG:
+		screenshotOpts.Format = proto.PageCaptureScreenshotFormatJpeg
+		quality := opts.Quality
+		screenshotOpts.Quality = &quality
+	case FormatWebP:
+		screenshotOpts.Format = proto.PageCaptureScreenshotFormatWebp
+		quality := opts.Quality
+		screenshotOpts.Quality = &quality
+	default:
+		return nil, fmt.Errorf("unsupported image format: %s", opts.Format)
+	}
+
+	// 元素/章节截图: 使用元素包围盒裁剪
+	if opts.Selector != "" || opts.Section != "" {
+		clip, err := elementClip(page, opts.Selector, opts.Section)
+		if err != nil {
+			return nil, err
+		}
+		screenshotOpts.Clip = clip
+		screenshotOpts.CaptureBeyondViewport = true
+		return page.Screenshot(true, screenshotOpts)
+	}
+
+	// 全页截图
+	if opts.FullPage {
+		return page.Screenshot(true, screenshotOpts)
+	}
+
+	// 视口截图
+	return page.Screenshot(false, screenshotOpts)
+}
+
+// RenderToPDF 将 HTML 打印为 PDF 字节数组
+//
+// 页面尺寸优先使用文档中的 CSS @page 规则,否则使用 opts.Width/opts.Height (像素)。
+//
+// 参数:
+//   - html: 完整的 HTML 文档
+//   - opts: 渲染选项 (使用 Width、Height、DevicePixelRatio)
+//
+// 返回:
+//   - []byte: PDF 字节数组
+//   - error: 渲染错误(如有)
+func (r *RodRenderer) RenderToPDF(html string, opts *RenderOptions) ([]byte, error) {
+	if opts == nil {
+		opts = DefaultRenderOptions()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	page, err := r.openPage(ctx, html, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer page.Close()
+
+	// CSS 像素转换为英寸 (96 DPI)
+	const pixelsPerInch = 96.0
+	noMargin := 0.0
+	req := &proto.PagePrintToPDF{
+		PrintBackground:   true,
+		PreferCSSPageSize: true,
+		MarginTop:         &noMargin,
+		MarginBottom:      &noMargin,
+		MarginLeft:        &noMargin,
+		MarginRight:       &noMargin,
+	}
+	if opts.Width > 0 {
+		paperWidth := float64(opts.Width) / pixelsPerInch
+		req.PaperWidth = &paperWidth
+	}
+	if opts.Height > 0 {
+		paperHeight := float64(opts.Height) / pixelsPerInch
+		req.PaperHeight = &paperHeight
+	}
+
+	stream, err := page.PDF(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to print PDF: %w", err)
+	}
+
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF stream: %w", err)
+	}
+
+	return data, nil
+}
+
+// openPage 创建新页面,设置视口并加载 HTML 内容
+//
+// 调用方负责关闭返回的页面。
+func (r *RodRenderer) openPage(ctx context.Context, html string, opts *RenderOptions) (*rod.Page, error) {
 	// 创建新页面 (使用 Page 而非 MustPage,避免 panic)
 	page, err := r.browser.Page(proto.TargetCreateTarget{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create page: %w", err)
 	}
-	defer page.Close()
 
 	// 设置页面上下文为带超时的 context
 	page = page.Context(ctx)
@@ -126,56 +312,141 @@ func (r *RodRenderer) RenderToImage(html string, opts *RenderOptions) ([]byte, e
 		Mobile:            false,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to set viewport: %w", err)
+		return nil, closePage(page, fmt.Errorf("failed to set viewport: %w", err))
+	}
+
+	// 透明背景: 覆盖 Chrome 默认的白色背景
+	if opts.Transparent {
+		alpha := 0.0
+		err = proto.EmulationSetDefaultBackgroundColorOverride{
+			Color: &proto.DOMRGBA{R: 0, G: 0, B: 0, A: &alpha},
+		}.Call(page)
+		if err != nil {
+			return nil, closePage(page, fmt.Errorf("failed to set transparent background: %w", err))
+		}
+	}
+
+	// 已注册字体通过 URL 引用,由渲染器拦截请求并直接返回字体数据
+	// (路由随页面上下文结束)
+	if strings.Contains(html, fonts.BaseURL) {
+		router := page.HijackRequests()
+		if err := router.Add(fonts.BaseURL+"*", "", serveFont); err != nil {
+			return nil, closePage(page, fmt.Errorf("failed to intercept font requests: %w", err))
+		}
+		go router.Run()
 	}
 
 	// 注入 HTML 内容
 	err = page.SetDocumentContent(html)
 	if err != nil {
-		return nil, fmt.Errorf("failed to set document content: %w", err)
+		return nil, closePage(page, fmt.Errorf("failed to set document content: %w", err))
 	}
 
 	// 等待页面加载完成
 	err = page.WaitLoad()
 	if err != nil {
-		return nil, fmt.Errorf("failed to wait for page load: %w", err)
+		return nil, closePage(page, fmt.Errorf("failed to wait for page load: %w", err))
+	}
+
+	// 等待 @font-face 字体加载完成,避免截图时仍显示回退字体
+	if _, err := page.Eval(fontsReadyJS); err != nil {
+		return nil, closePage(page, fmt.Errorf("failed to wait for fonts: %w", err))
 	}
 
 	// 等待页面 idle (使用更短的超时,失败不影响主流程)
 	// 使用 5 秒超时,如果失败仅记录警告
 	idleCtx, idleCancel := context.WithTimeout(ctx, 5*time.Second)
 	defer idleCancel()
-	// WaitIdle 失败不阻止截图,忽略错误
-	_ = page.Context(idleCtx).WaitIdle(1 * time.Second)
+	// WaitIdle 失败不阻止截图
+	if err := page.Context(idleCtx).WaitIdle(1 * time.Second); err != nil {
+		opts.logger().Warn("page did not become idle before capture", "error", err)
+	}
 
-	// 构建截图参数
-	screenshotOpts := &proto.PageCaptureScreenshot{
-		FromSurface: true,
+	return page, nil
+}
+
+// serveFont 返回已注册字体的数据
+//
+// 页面 (about:blank) 跨域加载字体,需要 CORS 响应头。
+func serveFont(h *rod.Hijack) {
+	font, ok := fonts.Lookup(h.Request.URL().String())
+	if !ok {
+		h.Response.Payload().ResponseCode = http.StatusNotFound
+		return
 	}
+	h.Response.SetHeader("Content-Type", font.MIMEType(), "Access-Control-Allow-Origin", "*")
+	h.Response.SetBody(font.Data)
+}
 
-	// 设置图片格式
-	switch opts.Format {
-	case FormatPNG:
-		screenshotOpts.Format = proto.PageCaptureScreenshotFormatPng
-	case FormatJPEG:
-		screenshotOpts.Format = proto.PageCaptureScreenshotFormatJpeg
-		quality := opts.Quality
-		screenshotOpts.Quality = &quality
-	case FormatWebP:
-		screenshotOpts.Format = proto.PageCaptureScreenshotFormatWebp
-		quality := opts.Quality
-		screenshotOpts.Quality = &quality
-	default:
-		return nil, fmt.Errorf("unsupported image format: %s", opts.Format)
+// fontsReadyJS 等待文档中的 Web 字体全部加载 (document.fonts.ready)
+const fontsReadyJS = `() => document.fonts.ready.then(() => document.fonts.size)`
+
+// closePage 在页面初始化失败时关闭页面并返回原始错误
+func closePage(page *rod.Page, err error) error {
+	_ = page.Close()
+	return err
+}
+
+// elementBoundsJS 计算元素或章节在文档坐标系中的包围盒
+//
+// section 不为空时,从对应 ID 的标题开始,合并后续兄弟节点直到遇到同级或更高级标题;
+// 否则使用 selector 匹配的第一个元素。未找到时返回 null。
+const elementBoundsJS = `(selector, section) => {
+	let rect;
+	if (section) {
+		const heading = document.getElementById(section);
+		if (!heading) return null;
+		const level = /^H[1-6]$/.test(heading.tagName) ? Number(heading.tagName[1]) : 0;
+		let last = heading;
+		if (level > 0) {
+			for (let el = heading.nextElementSibling; el; el = el.nextElementSibling) {
+				const m = /^H([1-6])$/.exec(el.tagName);
+				if (m && Number(m[1]) <= level) break;
+				last = el;
+			}
+		}
+		const range = document.createRange();
+		range.setStartBefore(heading);
+		range.setEndAfter(last);
+		rect = range.getBoundingClientRect();
+	} else {
+		const el = document.querySelector(selector);
+		if (!el) return null;
+		rect = el.getBoundingClientRect();
 	}
+	return {
+		x: rect.left + window.scrollX,
+		y: rect.top + window.scrollY,
+		width: rect.width,
+		height: rect.height,
+	};
+}`
 
-	// 全页截图
-	if opts.FullPage {
-		return page.Screenshot(true, screenshotOpts)
+// elementClip 根据选择器或章节锚点计算截图裁剪区域
+func elementClip(page *rod.Page, selector, section string) (*proto.PageViewport, error) {
+	target := selector
+	if section != "" {
+		target = "#" + section
 	}
 
-	// 视口截图
-	return page.Screenshot(false, screenshotOpts)
+	res, err := page.Eval(elementBoundsJS, selector, section)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate element %q: %w", target, err)
+	}
+	if res.Value.Nil() {
+		return nil, fmt.Errorf("%w: %s", ErrElementNotFound, target)
+	}
+
+	var clip proto.PageViewport
+	if err := res.Value.Unmarshal(&clip); err != nil {
+		return nil, fmt.Errorf("failed to read element bounds: %w", err)
+	}
+	if clip.Width <= 0 || clip.Height <= 0 {
+		return nil, fmt.Errorf("element has empty bounds: %s", target)
+	}
+	clip.Scale = 1
+
+	return &clip, nil
 }
 
 // RenderToFile 将 HTML 渲染为图片并保存到文件